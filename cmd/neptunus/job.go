@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/gekatateam/neptunus/config"
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/pipeline"
+)
+
+func job(cCtx *cli.Context) error {
+	// stdout may be used by outputs, so logs are written to stderr
+	if err := logger.InitWriter(config.Common{
+		LogLevel:  cCtx.String("log-level"),
+		LogFormat: cCtx.String("log-format"),
+	}, os.Stderr); err != nil {
+		return fmt.Errorf("logger initialization failed: %v", err.Error())
+	}
+
+	file := cCtx.String("file")
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("error reading pipeline file: %v", err.Error())
+	}
+
+	pipeCfg, err := config.UnmarshalPipeline([]byte(os.ExpandEnv(string(buf))), filepath.Ext(file))
+	if err != nil {
+		return fmt.Errorf("error parsing pipeline file: %v", err.Error())
+	}
+
+	// shared keykeepers are declared in daemon configuration,
+	// so they are available in job only if config is passed
+	var keepers map[string]core.Keykeeper
+	if path := cCtx.String("config"); len(path) > 0 {
+		cfg, err := config.ReadConfig(path)
+		if err != nil {
+			return fmt.Errorf("error reading configuration file: %v", err.Error())
+		}
+
+		keepers, err = pipeline.BuildKeykeepers(cfg.Keykeepers, logger.Default.With(
+			slog.Group("pipeline",
+				"id", "::shared",
+			),
+		))
+		if err != nil {
+			return fmt.Errorf("shared keykeepers building failed: %v", err.Error())
+		}
+		defer pipeline.CloseKeykeepers(keepers)
+	}
+
+	pipe := pipeline.New(pipeCfg, keepers, logger.Default.With(
+		slog.Group("pipeline",
+			"id", pipeCfg.Settings.Id,
+		),
+	))
+	pipe.SetFinite(true)
+
+	if err := pipe.Build(); err != nil {
+		pipe.Close()
+		return fmt.Errorf("pipeline building failed: %v", err.Error())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	now := time.Now()
+	pipe.Run(ctx)
+	duration := time.Since(now)
+
+	summary := pipe.Summary()
+	fmt.Fprintf(os.Stderr, "duration: %v\nconsumed: %v\ndelivered: %v\nfailed: %v\n",
+		duration, summary.Consumed, summary.Delivered, summary.Failed,
+	)
+
+	if ctx.Err() != nil {
+		return cli.Exit("job interrupted before inputs are exhausted", 1)
+	}
+
+	if summary.Failed > 0 {
+		return cli.Exit(fmt.Sprintf("job completed with %v failed events", summary.Failed), 1)
+	}
+
+	return nil
+}
@@ -54,6 +54,34 @@ func main() {
 				},
 				Action: test,
 			},
+			{
+				Name:      "job",
+				Usage:     "run pipeline from file until it's inputs are exhausted",
+				UsageText: "job --file pipeline.toml [--config config.toml] [--log-level warn]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "file",
+						Aliases:  []string{"f"},
+						Required: true,
+						Usage:    "pipeline manifest file (json, toml, yaml supported)",
+					},
+					&cli.StringFlag{
+						Name:  "config",
+						Usage: "path to daemon configuration file with shared keykeepers",
+					},
+					&cli.StringFlag{
+						Name:  "log-level",
+						Value: config.Default.Common.LogLevel,
+						Usage: "log level (debug, info, warn, error)",
+					},
+					&cli.StringFlag{
+						Name:  "log-format",
+						Value: config.Default.Common.LogFormat,
+						Usage: "log format (logfmt, json, pretty)",
+					},
+				},
+				Action: job,
+			},
 			{
 				Name:  "pipeline",
 				Usage: "cli commands for pipeline management",
@@ -57,7 +57,7 @@ func run(cCtx *cli.Context) error {
 		return fmt.Errorf("storage initialization failed: %v", err.Error())
 	}
 
-	s := service.Internal(storage, logger.Default.With(
+	s := service.Internal(storage, cfg.Keykeepers, logger.Default.With(
 		slog.Group("service",
 			"kind", "internal",
 		),
@@ -31,8 +31,18 @@ func test(cCtx *cli.Context) error {
 		return fmt.Errorf("pipelines read failed: %v", err.Error())
 	}
 
+	keepers, err := pipeline.BuildKeykeepers(cfg.Keykeepers, logger.Default.With(
+		slog.Group("pipeline",
+			"id", "::shared",
+		),
+	))
+	if err != nil {
+		return fmt.Errorf("shared keykeepers building failed: %v", err.Error())
+	}
+	defer pipeline.CloseKeykeepers(keepers)
+
 	for _, v := range pipesCfg {
-		pipe := pipeline.New(v, logger.Default.With(
+		pipe := pipeline.New(v, keepers, logger.Default.With(
 			slog.Group("pipeline",
 				"id", v.Settings.Id,
 			),
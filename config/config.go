@@ -29,9 +29,10 @@ var (
 )
 
 type Config struct {
-	Common  Common  `toml:"common"  yaml:"common"  json:"common"`
-	Runtime Runtime `toml:"runtime" yaml:"runtime" json:"runtime"`
-	Engine  Engine  `toml:"engine"  yaml:"engine"  json:"engine"`
+	Common     Common      `toml:"common"     yaml:"common"     json:"common"`
+	Runtime    Runtime     `toml:"runtime"    yaml:"runtime"    json:"runtime"`
+	Engine     Engine      `toml:"engine"     yaml:"engine"     json:"engine"`
+	Keykeepers []PluginSet `toml:"keykeepers" yaml:"keykeepers" json:"keykeepers"`
 }
 
 type Common struct {
@@ -140,6 +140,40 @@ func (p Plugin) Filters() PluginSet {
 	return filters
 }
 
+// FilterSets returns nested filters of a filters group
+// "filters" section may be a set of filters, or a list of sets,
+// so a group can contain the same filter plugin more than once
+func (p Plugin) FilterSets() []PluginSet {
+	filtersRaw, ok := p["filters"]
+	if !ok {
+		return nil
+	}
+
+	var setsRaw []any
+	switch f := filtersRaw.(type) {
+	case map[string]any:
+		return []PluginSet{p.Filters()}
+	case []any:
+		setsRaw = f
+	case []map[string]any:
+		for _, s := range f {
+			setsRaw = append(setsRaw, s)
+		}
+	default:
+		return nil
+	}
+
+	var sets = make([]PluginSet, 0, len(setsRaw))
+	for _, setRaw := range setsRaw {
+		set, ok := setRaw.(map[string]any)
+		if !ok {
+			continue
+		}
+		sets = append(sets, Plugin{"filters": set}.Filters())
+	}
+	return sets
+}
+
 func UnmarshalPipeline(data []byte, format string) (*Pipeline, error) {
 	pipeline := Pipeline{}
 
@@ -2,6 +2,7 @@ package core
 
 import (
 	"io"
+	"time"
 )
 
 type Initer interface {
@@ -64,6 +65,14 @@ type Keykeeper interface {
 	Initer
 }
 
+// keykeepers which values has limited lifetime must implement this interface
+// ttl is a time during which a value is valid, zero ttl means unlimited lifetime
+// engine requests leased values again before ttl ends
+// and rebuilds pipelines that uses them, if a value changed
+type Leaser interface {
+	Lease(key string) (value any, ttl time.Duration, err error)
+}
+
 // plugins that need parsers must implement this interface
 type SetParser interface {
 	SetParser(p Parser)
@@ -74,6 +83,12 @@ type SetSerializer interface {
 	SetSerializer(s Serializer)
 }
 
+// plugins that combine other filters must implement this interface
+// nested filters are configured from plugin "filters" section
+type SetFilters interface {
+	SetFilters(f []Filter)
+}
+
 // plugins that need unique id must implement this interface
 // id is unique for each plugin, but it's same for one processor
 // in multiple lines
@@ -87,6 +102,13 @@ type SetLine interface {
 	SetLine(line int)
 }
 
+// inputs that can run in finite mode must implement this interface
+// SetFinite() is called before Init(), and after that
+// input Run() must return when all data is consumed
+type Finite interface {
+	SetFinite()
+}
+
 // core plugins
 // used in core units only
 type Fusion interface {
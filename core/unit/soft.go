@@ -176,9 +176,10 @@ type inSoftUnit struct {
 	out  chan<- *core.Event // first channel in chain
 	rej  chan *core.Event
 	stop <-chan struct{}
+	done chan<- struct{}
 }
 
-func newInputSoftUnit(i core.Input, f []core.Filter, stop <-chan struct{}, bufferSize int) (unit *inSoftUnit, unitOut <-chan *core.Event, chansStats []metrics.ChanStatsFunc) {
+func newInputSoftUnit(i core.Input, f []core.Filter, stop <-chan struct{}, done chan<- struct{}, bufferSize int) (unit *inSoftUnit, unitOut <-chan *core.Event, chansStats []metrics.ChanStatsFunc) {
 	out := make(chan *core.Event, bufferSize)
 	unit = &inSoftUnit{
 		i:    i,
@@ -186,6 +187,7 @@ func newInputSoftUnit(i core.Input, f []core.Filter, stop <-chan struct{}, buffe
 		out:  out,
 		rej:  make(chan *core.Event, bufferSize),
 		stop: stop,
+		done: done,
 	}
 	i.SetChannels(out)
 
@@ -206,8 +208,9 @@ func (u *inSoftUnit) Run() {
 	// run input
 	u.wg.Add(1)
 	go func() {
-		u.i.Run()    // blocking call, loop inside
-		close(u.out) // close first channel in unit chain (trigger filters to close)
+		u.i.Run()     // blocking call, loop inside
+		close(u.out)  // close first channel in unit chain (trigger filters to close)
+		close(u.done) // notify that input is exhausted
 		u.wg.Done()
 	}()
 
@@ -62,6 +62,7 @@ func NewOutput(c *config.PipeSettings, l *slog.Logger, o core.Output, f []core.F
 
 // input unit sends consumed events to output channel
 // input unit wait for the closing signal through a dedicated channel
+// when input stops by itself, unit closes done channel, but still waits for the closing signal
 // if filters are set, each event passes through them
 // rejected events are not going to next filter or processor
 //
@@ -72,12 +73,12 @@ func NewOutput(c *config.PipeSettings, l *slog.Logger, o core.Output, f []core.F
 // |        └┤ f ├──┼─
 // |         └───┘  |
 // └────────────────┘
-func NewInput(c *config.PipeSettings, l *slog.Logger, i core.Input, f []core.Filter, stop <-chan struct{}, bufferSize int) (unit Unit, unitOut <-chan *core.Event, chansStats []metrics.ChanStatsFunc) {
+func NewInput(c *config.PipeSettings, l *slog.Logger, i core.Input, f []core.Filter, stop <-chan struct{}, done chan<- struct{}, bufferSize int) (unit Unit, unitOut <-chan *core.Event, chansStats []metrics.ChanStatsFunc) {
 	switch c.Consistency {
 	case ConsistencyHard:
 		panic("not implemented")
 	default:
-		return newInputSoftUnit(i, f, stop, bufferSize)
+		return newInputSoftUnit(i, f, stop, done, bufferSize)
 	}
 }
 
@@ -17,6 +17,11 @@ var Default = slog.New(prettylog.NewHandler(&slog.HandlerOptions{
 }))
 
 func Init(cfg config.Common) error {
+	return InitWriter(cfg, os.Stdout)
+}
+
+// InitWriter initializes default logger that writes to w
+func InitWriter(cfg config.Common, w io.Writer) error {
 	var opts = &slog.HandlerOptions{}
 	var handler slog.Handler = nil
 
@@ -36,12 +41,12 @@ func Init(cfg config.Common) error {
 	switch f := cfg.LogFormat; f {
 	case "logfmt":
 		opts.ReplaceAttr = attrReplacer
-		handler = slog.NewTextHandler(os.Stdout, opts)
+		handler = slog.NewTextHandler(w, opts)
 	case "json":
 		opts.ReplaceAttr = attrReplacer
-		handler = slog.NewJSONHandler(os.Stdout, opts)
+		handler = slog.NewJSONHandler(w, opts)
 	case "pretty":
-		handler = prettylog.NewHandler(opts)
+		handler = prettylog.NewWriterHandler(w, opts)
 	default:
 		return fmt.Errorf("unknown log format: %v", f)
 	}
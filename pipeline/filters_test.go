@@ -0,0 +1,161 @@
+package pipeline
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/gekatateam/neptunus/config"
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+	_ "github.com/gekatateam/neptunus/plugins/filters/any"
+	_ "github.com/gekatateam/neptunus/plugins/filters/glob"
+)
+
+// names of closed test filters
+var (
+	closedMu      sync.Mutex
+	closedFilters []string
+)
+
+// configured filters observe global metrics, which can be registered only once
+var metricsOnce sync.Once
+
+type testClosingFilter struct {
+	*core.BaseFilter `mapstructure:"-"`
+	Name             string `mapstructure:"name"`
+}
+
+func (f *testClosingFilter) Init() error { return nil }
+func (f *testClosingFilter) Run()        {}
+
+func (f *testClosingFilter) Close() error {
+	closedMu.Lock()
+	defer closedMu.Unlock()
+	closedFilters = append(closedFilters, f.Name)
+	return nil
+}
+
+// testFailingGroup accepts nested filters, but its initialization always fails
+type testFailingGroup struct {
+	*core.BaseFilter `mapstructure:"-"`
+}
+
+func (f *testFailingGroup) Init() error              { return errors.New("init failed") }
+func (f *testFailingGroup) Run()                     {}
+func (f *testFailingGroup) Close() error             { return nil }
+func (f *testFailingGroup) SetFilters([]core.Filter) {}
+
+func init() {
+	plugins.AddFilter("test_closing", func() core.Filter {
+		return &testClosingFilter{}
+	})
+	plugins.AddFilter("test_failing_group", func() core.Filter {
+		return &testFailingGroup{}
+	})
+}
+
+func TestConfigureFilters_Sets(t *testing.T) {
+	metricsOnce.Do(metrics.Init)
+
+	p := New(&config.Pipeline{}, nil, logger.Mock())
+
+	filters, err := p.configureFilters(config.PluginSet{
+		"any": config.Plugin{
+			"filters": []any{
+				map[string]any{"glob": map[string]any{"routing_key": []any{"first.*"}}},
+				map[string]any{"glob": map[string]any{"routing_key": []any{"second.*"}}},
+			},
+		},
+	}, "test")
+	if err != nil {
+		t.Fatalf("filters not configured: %v", err)
+	}
+	defer closeFilters(filters)
+
+	input := make(chan *core.Event, 3)
+	accept := make(chan *core.Event, 3)
+	reject := make(chan *core.Event, 3)
+	filters[0].SetChannels(input, reject, accept)
+
+	input <- core.NewEvent("first.event")
+	input <- core.NewEvent("second.event")
+	input <- core.NewEvent("third.event")
+	close(input)
+	filters[0].Run()
+
+	if len(accept) != 2 || len(reject) != 1 {
+		t.Fatalf("unexpected events flow, accepted: %v, rejected: %v", len(accept), len(reject))
+	}
+
+	if e := <-reject; e.RoutingKey != "third.event" {
+		t.Fatalf("unexpected rejected event: %v", e.RoutingKey)
+	}
+}
+
+func TestConfigureFilters_CloseOnError(t *testing.T) {
+	tests := map[string]struct {
+		set          config.PluginSet
+		expectClosed []string
+	}{
+		"group-init-failed": {
+			set: config.PluginSet{
+				"test_failing_group": config.Plugin{
+					"filters": map[string]any{
+						"test_closing": map[string]any{"name": "nested"},
+					},
+				},
+			},
+			expectClosed: []string{"nested"},
+		},
+		"sibling-set-failed": {
+			set: config.PluginSet{
+				"any": config.Plugin{
+					"filters": []any{
+						map[string]any{"test_closing": map[string]any{"name": "first"}},
+						map[string]any{"glob": map[string]any{"routing_key": []any{"[broken"}}},
+					},
+				},
+			},
+			expectClosed: []string{"first"},
+		},
+		"deeply-nested": {
+			set: config.PluginSet{
+				"test_failing_group": config.Plugin{
+					"filters": []any{
+						map[string]any{"any": map[string]any{
+							"filters": map[string]any{
+								"test_closing": map[string]any{"name": "deep"},
+							},
+						}},
+						map[string]any{"test_closing": map[string]any{"name": "shallow"}},
+					},
+				},
+			},
+			expectClosed: []string{"deep", "shallow"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			closedMu.Lock()
+			closedFilters = nil
+			closedMu.Unlock()
+
+			p := New(&config.Pipeline{}, nil, logger.Mock())
+			if _, err := p.configureFilters(test.set, "test"); err == nil {
+				t.Fatal("filters configured, but error expected")
+			}
+
+			closedMu.Lock()
+			defer closedMu.Unlock()
+			slices.Sort(closedFilters)
+			if !slices.Equal(closedFilters, test.expectClosed) {
+				t.Fatalf("unexpected closed filters, want: %v, got: %v", test.expectClosed, closedFilters)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package pipeline
+
+import (
+	"log/slog"
+
+	"github.com/gekatateam/neptunus/config"
+	"github.com/gekatateam/neptunus/core"
+)
+
+// BuildKeykeepers configures and initializes keykeepers declared in daemon configuration
+// such keykeepers are shared between all pipelines and available in it by alias
+//
+// keykeepers are built in the same way as pipeline-local ones,
+// so key substitutions in keykeepers configuration can use keykeepers declared before
+func BuildKeykeepers(keykeepers []config.PluginSet, log *slog.Logger) (map[string]core.Keykeeper, error) {
+	p := New(&config.Pipeline{
+		Keykeepers: keykeepers,
+	}, nil, log)
+
+	if err := p.configureKeykeepers(); err != nil {
+		p.Close()
+		return nil, err
+	}
+
+	return p.keepers, nil
+}
+
+// CloseKeykeepers closes all keykeepers built by BuildKeykeepers
+func CloseKeykeepers(keepers map[string]core.Keykeeper) {
+	for _, k := range keepers {
+		k.Close()
+	}
+}
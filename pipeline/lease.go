@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+)
+
+const (
+	leaseRetryInterval    = 5 * time.Second
+	leaseMinCheckInterval = time.Second
+)
+
+// keyLease stores a key value received from a leaser keykeeper
+// during pipeline build
+type keyLease struct {
+	alias  string
+	key    string
+	leaser core.Leaser
+	value  any
+	ttl    time.Duration
+	next   time.Time
+}
+
+func (l *keyLease) schedule(now time.Time) {
+	l.next = now.Add(max(l.ttl/2, leaseMinCheckInterval))
+}
+
+// HasLeases reports whether pipeline configuration uses leased keys
+func (p *Pipeline) HasLeases() bool {
+	return len(p.leases) > 0
+}
+
+// WatchLeases requests each leased key again when half of it's ttl has passed
+// it blocks until any key value changes or ctx is done
+//
+// it returns true if key value changed, so pipeline needs to be rebuilt
+// and false if ctx is done
+func (p *Pipeline) WatchLeases(ctx context.Context) bool {
+	leases := make([]*keyLease, 0, len(p.leases))
+	now := time.Now()
+	for _, l := range p.leases {
+		l.schedule(now)
+		leases = append(leases, l)
+	}
+
+	timer := time.NewTimer(nextLeaseCheck(leases))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+			now := time.Now()
+			active := leases[:0]
+			for _, l := range leases {
+				if l.next.After(now) {
+					active = append(active, l)
+					continue
+				}
+
+				val, ttl, err := l.leaser.Lease(l.key)
+				if err != nil {
+					p.log.Warn("leased key request failed, retry scheduled",
+						"error", err,
+						slog.Group("key",
+							"keykeeper", l.alias,
+							"request", l.key,
+						),
+					)
+					l.next = now.Add(leaseRetryInterval)
+					active = append(active, l)
+					continue
+				}
+
+				if !reflect.DeepEqual(val, l.value) {
+					p.log.Warn("leased key value changed",
+						slog.Group("key",
+							"keykeeper", l.alias,
+							"request", l.key,
+						),
+					)
+					return true
+				}
+
+				// value is not leased anymore
+				if ttl <= 0 {
+					continue
+				}
+
+				l.ttl = ttl
+				l.schedule(now)
+				active = append(active, l)
+			}
+			leases = active
+
+			if len(leases) == 0 {
+				<-ctx.Done()
+				return false
+			}
+
+			timer.Reset(nextLeaseCheck(leases))
+		}
+	}
+}
+
+func nextLeaseCheck(leases []*keyLease) time.Duration {
+	if len(leases) == 0 {
+		return leaseMinCheckInterval
+	}
+
+	next := leases[0].next
+	for _, l := range leases[1:] {
+		if l.next.Before(next) {
+			next = l.next
+		}
+	}
+
+	return max(time.Until(next), 0)
+}
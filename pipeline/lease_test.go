@@ -0,0 +1,98 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gekatateam/neptunus/logger"
+)
+
+type leaseResponse struct {
+	value any
+	ttl   time.Duration
+}
+
+type testLeaser struct {
+	mu        sync.Mutex
+	calls     int
+	responses []leaseResponse
+}
+
+func (l *testLeaser) Lease(key string) (any, time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r := l.responses[min(l.calls, len(l.responses)-1)]
+	l.calls++
+	return r.value, r.ttl, nil
+}
+
+func TestWatchLeases(t *testing.T) {
+	tests := map[string]struct {
+		ttl       time.Duration
+		responses []leaseResponse
+		timeout   time.Duration
+		want      bool
+		wantCalls int
+	}{
+		"renewed-then-changed": {
+			ttl: 2 * time.Second,
+			responses: []leaseResponse{
+				{value: "secret", ttl: 2 * time.Second},
+				{value: "rotated", ttl: 2 * time.Second},
+			},
+			timeout:   5 * time.Second,
+			want:      true,
+			wantCalls: 2,
+		},
+		"lease-expired": {
+			ttl: 2 * time.Second,
+			responses: []leaseResponse{
+				{value: "secret", ttl: 0},
+			},
+			timeout:   2500 * time.Millisecond,
+			want:      false,
+			wantCalls: 1,
+		},
+		"context-done": {
+			ttl: time.Hour,
+			responses: []leaseResponse{
+				{value: "rotated", ttl: time.Hour},
+			},
+			timeout:   100 * time.Millisecond,
+			want:      false,
+			wantCalls: 0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			leaser := &testLeaser{responses: test.responses}
+			p := &Pipeline{
+				log: logger.Mock(),
+				leases: []*keyLease{{
+					alias:  "test",
+					key:    "key",
+					leaser: leaser,
+					value:  "secret",
+					ttl:    test.ttl,
+				}},
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), test.timeout)
+			defer cancel()
+
+			if got := p.WatchLeases(ctx); got != test.want {
+				t.Fatalf("unexpected result, want: %v, got: %v", test.want, got)
+			}
+
+			if leaser.calls != test.wantCalls {
+				t.Fatalf("unexpected lease calls, want: %v, got: %v", test.wantCalls, leaser.calls)
+			}
+		})
+	}
+}
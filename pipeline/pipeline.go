@@ -82,8 +82,12 @@ type Pipeline struct {
 	state   state
 	lastErr error
 	aliases map[string]struct{}
+	finite  bool
+	summary *summary
 
 	keepers map[string]core.Keykeeper
+	shared  map[string]core.Keykeeper
+	leases  []*keyLease
 	outs    []outputSet
 	procs   [][]procSet
 	ins     []inputSet
@@ -91,13 +95,22 @@ type Pipeline struct {
 	chansStatsFuncs []metrics.ChanStatsFunc
 }
 
-func New(config *config.Pipeline, log *slog.Logger) *Pipeline {
+// shared keykeepers are owned by the caller
+// pipeline never closes them, and pipeline-local keykeepers
+// with the same alias take precedence over them
+func New(config *config.Pipeline, shared map[string]core.Keykeeper, log *slog.Logger) *Pipeline {
+	if shared == nil {
+		shared = make(map[string]core.Keykeeper)
+	}
+
 	return &Pipeline{
 		config:  config,
 		log:     log,
 		state:   StateCreated,
 		aliases: make(map[string]struct{}),
+		summary: &summary{},
 		keepers: make(map[string]core.Keykeeper),
+		shared:  shared,
 		outs:    make([]outputSet, 0, len(config.Outputs)),
 		procs:   make([][]procSet, 0, config.Settings.Lines),
 		ins:     make([]inputSet, 0, len(config.Inputs)),
@@ -125,6 +138,19 @@ func (p *Pipeline) Config() *config.Pipeline {
 	return p.config
 }
 
+// SetFinite switches pipeline to finite mode, it must be called before Build()
+// in this mode Run() also returns when all inputs are stopped by themselves,
+// e.g. when stdin is closed, after all consumed events are processed
+// all inputs must implement core.Finite, and events are counted in pipeline summary
+func (p *Pipeline) SetFinite(finite bool) {
+	p.finite = finite
+}
+
+// Summary returns events counters of pipeline running in finite mode
+func (p *Pipeline) Summary() Summary {
+	return p.summary.load()
+}
+
 // Pipeline Close() MUST be called only if pipeline build failed.
 // After successfull Run(), each plugin will be closed dy it's unit.
 // As usual, there is a few exclusions:
@@ -161,6 +187,7 @@ func (p *Pipeline) Close() error {
 
 	p.aliases = make(map[string]struct{})
 	p.keepers = make(map[string]core.Keykeeper)
+	p.leases = nil
 	p.outs = make([]outputSet, 0, len(p.config.Outputs))
 	p.procs = make([][]procSet, 0, p.config.Settings.Lines)
 	p.ins = make([]inputSet, 0, len(p.config.Inputs))
@@ -248,10 +275,12 @@ func (p *Pipeline) Run(ctx context.Context) {
 
 	p.log.Info("starting inputs")
 	var inputsStopChannels = make([]chan struct{}, 0, len(p.ins))
+	var inputsDoneChannels = make([]chan struct{}, 0, len(p.ins))
 	var inputsOutChannels = make([]<-chan *core.Event, 0, len(p.ins))
 	for i, input := range p.ins {
 		inputsStopChannels = append(inputsStopChannels, make(chan struct{}))
-		inputUnit, outCh, chansStats := unit.NewInput(&p.config.Settings, p.log, input.i, input.f, inputsStopChannels[i], p.config.Settings.Buffer)
+		inputsDoneChannels = append(inputsDoneChannels, make(chan struct{}))
+		inputUnit, outCh, chansStats := unit.NewInput(&p.config.Settings, p.log, input.i, input.f, inputsStopChannels[i], inputsDoneChannels[i], p.config.Settings.Buffer)
 		p.chansStatsFuncs = append(p.chansStatsFuncs, chansStats...)
 		inputsOutChannels = append(inputsOutChannels, outCh)
 		wg.Add(1)
@@ -350,10 +379,18 @@ func (p *Pipeline) Run(ctx context.Context) {
 	p.state = StateRunning
 	p.log.Info("pipeline started")
 
-	<-ctx.Done()
+	if p.finite {
+		if p.waitInputs(ctx, inputsDoneChannels) {
+			p.log.Info("all inputs are exhausted, stopping pipeline")
+		} else {
+			p.log.Info("stop signal received, stopping pipeline")
+		}
+	} else {
+		<-ctx.Done()
+		p.log.Info("stop signal received, stopping pipeline")
+	}
 
 	p.state = StateStopping
-	p.log.Info("stop signal received, stopping pipeline")
 	for _, stop := range inputsStopChannels {
 		stop <- struct{}{}
 	}
@@ -367,6 +404,19 @@ func (p *Pipeline) Run(ctx context.Context) {
 	p.state = StateStopped
 }
 
+// waitInputs blocks until all inputs are stopped or context is done
+// it returns true if all inputs are stopped
+func (p *Pipeline) waitInputs(ctx context.Context, done []chan struct{}) bool {
+	for _, d := range done {
+		select {
+		case <-d:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
 func (p *Pipeline) configureKeykeepers() error {
 	for index, keykeepers := range p.config.Keykeepers {
 		for plugin, keeperCfg := range keykeepers {
@@ -478,7 +528,7 @@ func (p *Pipeline) configureOutputs() error {
 						"plugin", plugin,
 						"name", alias,
 					)),
-					Obs: metrics.ObserveOutputSummary,
+					Obs: p.observer(metrics.ObserveOutputSummary, core.KindOutput),
 				}))
 			} else {
 				return fmt.Errorf("%v output plugin does not contains BaseOutput", plugin)
@@ -571,7 +621,7 @@ func (p *Pipeline) configureProcessors() error {
 							"plugin", plugin,
 							"name", alias,
 						)),
-						Obs: metrics.ObserveProcessorSummary,
+						Obs: p.observer(metrics.ObserveProcessorSummary, core.KindProcessor),
 					}))
 				} else {
 					return fmt.Errorf("%v processor plugin does not contains BaseProcessor", plugin)
@@ -661,7 +711,7 @@ func (p *Pipeline) configureInputs() error {
 						"plugin", plugin,
 						"name", alias,
 					)),
-					Obs: metrics.ObserveInputSummary,
+					Obs: p.observer(metrics.ObserveInputSummary, core.KindInput),
 				}))
 			} else {
 				return fmt.Errorf("%v input plugin does not contains BaseInput", plugin)
@@ -671,6 +721,14 @@ func (p *Pipeline) configureInputs() error {
 				return fmt.Errorf("%v input configuration mapping error: %v", plugin, err.Error())
 			}
 
+			if p.finite {
+				finite, ok := input.(core.Finite)
+				if !ok {
+					return fmt.Errorf("%v input does not support finite mode", plugin)
+				}
+				finite.SetFinite()
+			}
+
 			if err := input.Init(); err != nil {
 				return fmt.Errorf("%v input initialization error: %v", plugin, err.Error())
 			}
@@ -686,8 +744,15 @@ func (p *Pipeline) configureInputs() error {
 	return nil
 }
 
-func (p *Pipeline) configureFilters(filtersSet config.PluginSet, parentName string) ([]core.Filter, error) {
+// already configured filters are closed if configuration of any next one failed
+func (p *Pipeline) configureFilters(filtersSet config.PluginSet, parentName string) (_ []core.Filter, err error) {
 	var filters []core.Filter
+	defer func() {
+		if err != nil {
+			closeFilters(filters)
+		}
+	}()
+
 	for plugin, filterCfg := range filtersSet {
 		filterFunc, ok := plugins.GetFilter(plugin)
 		if !ok {
@@ -731,6 +796,15 @@ func (p *Pipeline) configureFilters(filtersSet config.PluginSet, parentName stri
 			parserNeedy.SetParser(parser)
 		}
 
+		var nested []core.Filter
+		if filtersNeedy, ok := filter.(core.SetFilters); ok {
+			nested, err = p.configureFilterSets(filterCfg.FilterSets(), alias)
+			if err != nil {
+				return nil, fmt.Errorf("%v filter nested filters configuration error: %v", plugin, err.Error())
+			}
+			filtersNeedy.SetFilters(nested)
+		}
+
 		if idNeedy, ok := filter.(core.SetId); ok {
 			idNeedy.SetId(filterCfg.Id())
 		}
@@ -749,14 +823,17 @@ func (p *Pipeline) configureFilters(filtersSet config.PluginSet, parentName stri
 				Obs: metrics.ObserveFilterSummary,
 			}))
 		} else {
+			closeFilters(nested)
 			return nil, fmt.Errorf("%v filter plugin does not contains BaseInput", plugin)
 		}
 
 		if err := mapstructure.Decode(filterCfg, filter, p.decodeHook()); err != nil {
+			closeFilters(nested)
 			return nil, fmt.Errorf("%v filter configuration mapping error: %v", plugin, err.Error())
 		}
 
 		if err := filter.Init(); err != nil {
+			closeFilters(nested)
 			return nil, fmt.Errorf("%v filter initialization error: %v", plugin, err.Error())
 		}
 
@@ -765,6 +842,31 @@ func (p *Pipeline) configureFilters(filtersSet config.PluginSet, parentName stri
 	return filters, nil
 }
 
+// configureFilterSets configures nested filters of a group
+// each set in a list gets its index in default aliases, so sets may contain same plugins
+func (p *Pipeline) configureFilterSets(sets []config.PluginSet, parentName string) ([]core.Filter, error) {
+	if len(sets) == 1 {
+		return p.configureFilters(sets[0], parentName)
+	}
+
+	var filters []core.Filter
+	for i, set := range sets {
+		f, err := p.configureFilters(set, fmt.Sprintf("%v[%v]", parentName, i))
+		if err != nil {
+			closeFilters(filters)
+			return nil, err
+		}
+		filters = append(filters, f...)
+	}
+	return filters, nil
+}
+
+func closeFilters(filters []core.Filter) {
+	for _, f := range filters {
+		f.Close()
+	}
+}
+
 func (p *Pipeline) configureParser(parserCfg config.Plugin, parentName string) (core.Parser, error) {
 	plugin := parserCfg.Type()
 	parserFunc, ok := plugins.GetParser(plugin)
@@ -874,10 +976,33 @@ func (p *Pipeline) decodeHook() func(f reflect.Type, _ reflect.Type, data any) (
 
 		if match := keyConfigPattern.FindStringSubmatch(data.(string)); len(match) == 3 {
 			k, ok := p.keepers[match[1]]
+			if !ok {
+				k, ok = p.shared[match[1]]
+			}
+
 			if !ok {
 				return nil, fmt.Errorf("keykeeper not specified in configuration or still not initialized: %v", match[1])
 			}
 
+			if leaser, ok := k.(core.Leaser); ok {
+				val, ttl, err := leaser.Lease(match[2])
+				if err != nil {
+					return nil, fmt.Errorf("error reading key %v using %v keykeeper: %w", match[2], match[1], err)
+				}
+
+				if ttl > 0 {
+					p.leases = append(p.leases, &keyLease{
+						alias:  match[1],
+						key:    match[2],
+						leaser: leaser,
+						value:  val,
+						ttl:    ttl,
+					})
+				}
+
+				return val, nil
+			}
+
 			val, err := k.Get(match[2])
 			if err != nil {
 				return nil, fmt.Errorf("error reading key %v using %v keykeeper: %w", match[2], match[1], err)
@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 
 	"github.com/gekatateam/neptunus/config"
+	"github.com/gekatateam/neptunus/core"
 	"github.com/gekatateam/neptunus/logger"
 	"github.com/gekatateam/neptunus/metrics"
 	"github.com/gekatateam/neptunus/pipeline"
@@ -21,6 +23,7 @@ type pipeUnit struct {
 	p  *pipeline.Pipeline
 	mu *sync.Mutex
 	c  context.CancelFunc
+	d  <-chan struct{} // closed when pipeline stopped
 }
 
 type internalService struct {
@@ -28,18 +31,42 @@ type internalService struct {
 	log   *slog.Logger
 	wg    *sync.WaitGroup
 	s     pipeline.Storage
+
+	keepersCfg []config.PluginSet
+	keepers    map[string]core.Keykeeper
+
+	stopping *atomic.Bool
 }
 
-func Internal(s pipeline.Storage, log *slog.Logger) *internalService {
+func Internal(s pipeline.Storage, keykeepers []config.PluginSet, log *slog.Logger) *internalService {
 	return &internalService{
-		log:   log,
-		s:     s,
-		pipes: &syncs.Map[string, pipeUnit]{},
-		wg:    &sync.WaitGroup{},
+		log:        log,
+		s:          s,
+		pipes:      &syncs.Map[string, pipeUnit]{},
+		wg:         &sync.WaitGroup{},
+		keepersCfg: keykeepers,
+		keepers:    make(map[string]core.Keykeeper),
+		stopping:   &atomic.Bool{},
 	}
 }
 
+// StartAll initializes daemon-level keykeepers first,
+// because they are shared between all pipelines
+// then it creates all pipelines from storage and runs them, if needed
 func (m *internalService) StartAll() error {
+	if len(m.keepersCfg) > 0 {
+		m.log.Info("building shared keykeepers")
+		keepers, err := pipeline.BuildKeykeepers(m.keepersCfg, logger.Default.With(
+			slog.Group("pipeline",
+				"id", "::shared",
+			),
+		))
+		if err != nil {
+			return fmt.Errorf("shared keykeepers building failed: %w", err)
+		}
+		m.keepers = keepers
+	}
+
 	pipes, err := m.s.List()
 	if err != nil {
 		return err
@@ -47,7 +74,7 @@ func (m *internalService) StartAll() error {
 
 	var errs xerrors.Errorlist
 	for _, pipeCfg := range pipes {
-		unit := pipeUnit{m.createPipeline(pipeCfg), &sync.Mutex{}, nil}
+		unit := pipeUnit{m.createPipeline(pipeCfg), &sync.Mutex{}, nil, nil}
 		m.pipes.Store(pipeCfg.Settings.Id, unit)
 		if pipeCfg.Settings.Run {
 			if err := m.runPipeline(unit); err != nil {
@@ -64,9 +91,11 @@ func (m *internalService) StartAll() error {
 }
 
 func (m *internalService) StopAll() {
-	m.pipes.Range(func(_ string, u pipeUnit) bool {
+	m.stopping.Store(true)
+	m.pipes.Range(func(id string, u pipeUnit) bool {
 		u.mu.Lock()
-		if u.c != nil {
+		// unit may be replaced by restart while waiting for lock
+		if u, ok := m.pipes.Load(id); ok && u.c != nil {
 			u.c()
 		}
 		u.mu.Unlock()
@@ -75,6 +104,10 @@ func (m *internalService) StopAll() {
 	})
 
 	m.wg.Wait()
+
+	// shared keykeepers are closed only after all pipelines stopped
+	pipeline.CloseKeykeepers(m.keepers)
+	m.keepers = make(map[string]core.Keykeeper)
 }
 
 func (m *internalService) Start(id string) error {
@@ -83,7 +116,7 @@ func (m *internalService) Start(id string) error {
 		return err
 	}
 
-	unit, _ := m.pipes.LoadOrStore(id, pipeUnit{m.createPipeline(pipeCfg), &sync.Mutex{}, nil})
+	unit, _ := m.pipes.LoadOrStore(id, pipeUnit{m.createPipeline(pipeCfg), &sync.Mutex{}, nil, nil})
 
 	switch unit.p.State() {
 	case pipeline.StateRunning:
@@ -178,7 +211,7 @@ func (m *internalService) Add(pipeCfg *config.Pipeline) error {
 		return err
 	}
 
-	m.pipes.Store(pipeCfg.Settings.Id, pipeUnit{m.createPipeline(pipeCfg), &sync.Mutex{}, nil})
+	m.pipes.Store(pipeCfg.Settings.Id, pipeUnit{m.createPipeline(pipeCfg), &sync.Mutex{}, nil, nil})
 	return nil
 }
 
@@ -207,7 +240,7 @@ func (m *internalService) Update(pipeCfg *config.Pipeline) error {
 		return err
 	}
 
-	m.pipes.Store(pipeCfg.Settings.Id, pipeUnit{m.createPipeline(pipeCfg), unit.mu, nil})
+	m.pipes.Store(pipeCfg.Settings.Id, pipeUnit{m.createPipeline(pipeCfg), unit.mu, nil, nil})
 	return nil
 }
 
@@ -241,7 +274,7 @@ func (m *internalService) Delete(id string) error {
 }
 
 func (m *internalService) createPipeline(pipeCfg *config.Pipeline) *pipeline.Pipeline {
-	return pipeline.New(pipeCfg, logger.Default.With(
+	return pipeline.New(pipeCfg, m.keepers, logger.Default.With(
 		slog.Group("pipeline",
 			"id", pipeCfg.Settings.Id,
 		),
@@ -271,7 +304,9 @@ func (m *internalService) runPipeline(pipeUnit pipeUnit) error {
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
 	pipeUnit.c = cancel
+	pipeUnit.d = done
 	m.pipes.Store(id, pipeUnit)
 
 	m.wg.Add(1)
@@ -279,11 +314,65 @@ func (m *internalService) runPipeline(pipeUnit pipeUnit) error {
 		p.Run(ctx)
 		m.wg.Done()
 		cancel()
+		close(done)
 	}(pipeUnit.p)
 
+	if pipeUnit.p.HasLeases() {
+		go func(p *pipeline.Pipeline) {
+			if !p.WatchLeases(ctx) {
+				return
+			}
+
+			m.log.Warn("pipeline uses changed leased key, restarting",
+				slog.Group("pipeline",
+					"id", id,
+				),
+			)
+			if err := m.restartPipeline(id, p); err != nil {
+				m.log.Error("pipeline restart failed",
+					"error", err.Error(),
+					slog.Group("pipeline",
+						"id", id,
+					),
+				)
+			}
+		}(pipeUnit.p)
+	}
+
 	return nil
 }
 
+// restartPipeline stops running pipeline, waits until it stopped
+// then creates a new one from storage configuration and runs it
+func (m *internalService) restartPipeline(id string, p *pipeline.Pipeline) error {
+	unit, ok := m.pipes.Load(id)
+	if !ok {
+		return &pipeline.NotFoundError{Err: errors.New("pipeline unit not found in runtime registry")}
+	}
+
+	unit.mu.Lock()
+	defer unit.mu.Unlock()
+
+	// pipeline may be stopped, updated or already restarted
+	// while waiting for lock
+	unit, ok = m.pipes.Load(id)
+	if !ok || unit.p != p || unit.p.State() != pipeline.StateRunning || m.stopping.Load() {
+		return nil
+	}
+
+	unit.c()
+	<-unit.d
+
+	pipeCfg, err := m.s.Get(id)
+	if err != nil {
+		return err
+	}
+
+	unit = pipeUnit{m.createPipeline(pipeCfg), unit.mu, nil, nil}
+	m.pipes.Store(id, unit)
+	return m.runPipeline(unit)
+}
+
 func (m *internalService) Stats() []metrics.PipelineStats {
 	pipesState := []metrics.PipelineStats{}
 
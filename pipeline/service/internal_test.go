@@ -0,0 +1,225 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gekatateam/neptunus/config"
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/pipeline"
+	"github.com/gekatateam/neptunus/plugins"
+)
+
+// leased value, that test keykeeper returns
+var testSecret = &atomic.Value{}
+
+// values, with which test input was initialized
+var testInits = make(chan string, 10)
+
+type testKeykeeper struct {
+	*core.BaseKeykeeper `mapstructure:"-"`
+}
+
+func (k *testKeykeeper) Init() error  { return nil }
+func (k *testKeykeeper) Close() error { return nil }
+
+func (k *testKeykeeper) Get(key string) (any, error) {
+	val, _, err := k.Lease(key)
+	return val, err
+}
+
+func (k *testKeykeeper) Lease(key string) (any, time.Duration, error) {
+	return testSecret.Load(), 2 * time.Second, nil
+}
+
+type testInput struct {
+	*core.BaseInput `mapstructure:"-"`
+	Secret          string `mapstructure:"secret"`
+
+	stop chan struct{}
+}
+
+func (i *testInput) Init() error {
+	i.stop = make(chan struct{})
+	testInits <- i.Secret
+	return nil
+}
+
+func (i *testInput) Run()         { <-i.stop }
+func (i *testInput) Close() error { close(i.stop); return nil }
+
+type testOutput struct {
+	*core.BaseOutput `mapstructure:"-"`
+}
+
+func (o *testOutput) Init() error  { return nil }
+func (o *testOutput) Close() error { return nil }
+
+func (o *testOutput) Run() {
+	for e := range o.In {
+		o.Done <- e
+	}
+}
+
+type testStorage struct {
+	mu    sync.Mutex
+	pipes map[string]*config.Pipeline
+}
+
+func (s *testStorage) List() ([]*config.Pipeline, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pipes []*config.Pipeline
+	for _, p := range s.pipes {
+		pipes = append(pipes, p)
+	}
+	return pipes, nil
+}
+
+func (s *testStorage) Get(id string) (*config.Pipeline, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.pipes[id]
+	if !ok {
+		return nil, &pipeline.NotFoundError{Err: errors.New("pipeline not found")}
+	}
+	return p, nil
+}
+
+func (s *testStorage) Add(pipe *config.Pipeline) error    { return nil }
+func (s *testStorage) Update(pipe *config.Pipeline) error { return nil }
+func (s *testStorage) Delete(id string) error             { return nil }
+func (s *testStorage) Close() error                       { return nil }
+
+func init() {
+	plugins.AddKeykeeper("test_leaser", func() core.Keykeeper {
+		return &testKeykeeper{}
+	})
+	plugins.AddInput("test_leaser", func() core.Input {
+		return &testInput{}
+	})
+	plugins.AddOutput("test_leaser", func() core.Output {
+		return &testOutput{}
+	})
+}
+
+func TestInternalService_RestartOnLeaseChange(t *testing.T) {
+	testSecret.Store("secret")
+
+	s := Internal(&testStorage{pipes: map[string]*config.Pipeline{
+		"test": {
+			Settings: config.PipeSettings{Id: "test", Lines: 1, Run: true, Buffer: 10},
+			Keykeepers: []config.PluginSet{
+				{"test_leaser": {"alias": "leaser"}},
+			},
+			Inputs: []config.PluginSet{
+				{"test_leaser": {"secret": "@{leaser:key}"}},
+			},
+			Outputs: []config.PluginSet{
+				{"test_leaser": {}},
+			},
+		},
+	}}, nil, logger.Mock())
+
+	if err := s.StartAll(); err != nil {
+		t.Fatalf("pipeline start failed: %v", err)
+	}
+	defer s.StopAll()
+
+	if got := waitInit(t); got != "secret" {
+		t.Fatalf("unexpected initial secret: %v", got)
+	}
+
+	unit, _ := s.pipes.Load("test")
+	testSecret.Store("rotated")
+
+	if got := waitInit(t); got != "rotated" {
+		t.Fatalf("unexpected secret after restart: %v", got)
+	}
+
+	waitRunning(t, s, unit.p)
+
+	if state := unit.p.State(); state != pipeline.StateStopped {
+		t.Fatalf("old pipeline is not stopped, state: %v", state)
+	}
+}
+
+func TestInternalService_RestartSkippedIfStopped(t *testing.T) {
+	testSecret.Store("secret")
+
+	s := Internal(&testStorage{pipes: map[string]*config.Pipeline{
+		"test": {
+			Settings: config.PipeSettings{Id: "test", Lines: 1, Run: true, Buffer: 10},
+			Keykeepers: []config.PluginSet{
+				{"test_leaser": {"alias": "leaser"}},
+			},
+			Inputs: []config.PluginSet{
+				{"test_leaser": {"secret": "@{leaser:key}"}},
+			},
+			Outputs: []config.PluginSet{
+				{"test_leaser": {}},
+			},
+		},
+	}}, nil, logger.Mock())
+
+	if err := s.StartAll(); err != nil {
+		t.Fatalf("pipeline start failed: %v", err)
+	}
+	waitInit(t)
+	waitRunning(t, s, nil)
+
+	unit, _ := s.pipes.Load("test")
+	if err := s.Stop("test"); err != nil {
+		t.Fatalf("pipeline stop failed: %v", err)
+	}
+	<-unit.d
+
+	// stopped pipeline must not be started again by restart
+	if err := s.restartPipeline("test", unit.p); err != nil {
+		t.Fatalf("restart failed: %v", err)
+	}
+
+	select {
+	case v := <-testInits:
+		t.Fatalf("stopped pipeline was restarted with: %v", v)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	s.StopAll()
+}
+
+func waitInit(t *testing.T) string {
+	t.Helper()
+
+	select {
+	case v := <-testInits:
+		return v
+	case <-time.After(5 * time.Second):
+		t.Fatal("input is not initialized")
+		return ""
+	}
+}
+
+// waitRunning waits until pipeline, other than passed one, is stored and running
+func waitRunning(t *testing.T, s *internalService, old *pipeline.Pipeline) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		unit, _ := s.pipes.Load("test")
+		if unit.p != old && unit.p.State() == pipeline.StateRunning {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("pipeline is not running, state: %v", unit.p.State())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,56 @@
+package pipeline
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+)
+
+// Summary contains events counters of pipeline running in finite mode
+//   - Consumed - number of events accepted by inputs
+//   - Delivered - number of events accepted by outputs; if there is more than one output, each event is counted by each of them
+//   - Failed - number of events failed in inputs, processors and outputs, or rejected by inputs
+type Summary struct {
+	Consumed  int64
+	Delivered int64
+	Failed    int64
+}
+
+type summary struct {
+	consumed  atomic.Int64
+	delivered atomic.Int64
+	failed    atomic.Int64
+}
+
+func (s *summary) load() Summary {
+	return Summary{
+		Consumed:  s.consumed.Load(),
+		Delivered: s.delivered.Load(),
+		Failed:    s.failed.Load(),
+	}
+}
+
+// observer wraps plugin observe func with summary counters, if pipeline runs in finite mode
+// parsers and serializers are not counted, because their failures are reported by plugins that use them
+func (p *Pipeline) observer(obs metrics.ObserveFunc, kind string) metrics.ObserveFunc {
+	if !p.finite {
+		return obs
+	}
+
+	return func(plugin, name, pipeline string, status metrics.EventStatus, t time.Duration) {
+		switch {
+		case status == metrics.EventFailed:
+			p.summary.failed.Add(1)
+		case status == metrics.EventRejected && kind == core.KindInput:
+			p.summary.failed.Add(1)
+		case status == metrics.EventAccepted && kind == core.KindInput:
+			p.summary.consumed.Add(1)
+		case status == metrics.EventAccepted && kind == core.KindOutput:
+			p.summary.delivered.Add(1)
+		}
+
+		obs(plugin, name, pipeline, status, t)
+	}
+}
@@ -0,0 +1,168 @@
+package pipeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gekatateam/neptunus/config"
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+)
+
+// testFiniteInput produces configured events and returns by itself
+// "reject" events are rejected by input, others are sent to pipeline
+// if finite mode is not set, input produces nothing
+type testFiniteInput struct {
+	*core.BaseInput `mapstructure:"-"`
+	Events          []string `mapstructure:"events"`
+	finite          bool
+}
+
+func (i *testFiniteInput) Init() error  { return nil }
+func (i *testFiniteInput) Close() error { return nil }
+func (i *testFiniteInput) SetFinite()   { i.finite = true }
+
+func (i *testFiniteInput) Run() {
+	if !i.finite {
+		return
+	}
+
+	for _, key := range i.Events {
+		if key == "reject" {
+			i.Observe(metrics.EventRejected, 0)
+			continue
+		}
+		i.Out <- core.NewEvent(key)
+		i.Observe(metrics.EventAccepted, 0)
+	}
+}
+
+// testInfiniteInput does not implement core.Finite
+type testInfiniteInput struct {
+	*core.BaseInput `mapstructure:"-"`
+}
+
+func (i *testInfiniteInput) Init() error  { return nil }
+func (i *testInfiniteInput) Close() error { return nil }
+func (i *testInfiniteInput) Run()         {}
+
+// testSummaryOutput fails events with "fail" routing key and accepts others
+type testSummaryOutput struct {
+	*core.BaseOutput `mapstructure:"-"`
+}
+
+func (o *testSummaryOutput) Init() error  { return nil }
+func (o *testSummaryOutput) Close() error { return nil }
+
+func (o *testSummaryOutput) Run() {
+	for e := range o.In {
+		if e.RoutingKey == "fail" {
+			o.Observe(metrics.EventFailed, 0)
+		} else {
+			o.Observe(metrics.EventAccepted, 0)
+		}
+		o.Done <- e
+	}
+}
+
+func init() {
+	plugins.AddInput("test_finite", func() core.Input {
+		return &testFiniteInput{}
+	})
+	plugins.AddInput("test_infinite", func() core.Input {
+		return &testInfiniteInput{}
+	})
+	plugins.AddOutput("test_summary", func() core.Output {
+		return &testSummaryOutput{}
+	})
+}
+
+func TestPipeline_Finite(t *testing.T) {
+	metricsOnce.Do(metrics.Init)
+
+	tests := map[string]struct {
+		inputs       []config.PluginSet
+		outputs      []config.PluginSet
+		expectErr    string
+		expectResult Summary
+	}{
+		"single-input-single-output": {
+			inputs: []config.PluginSet{
+				{"test_finite": config.Plugin{"events": []any{"ok", "ok", "fail", "reject"}}},
+			},
+			outputs: []config.PluginSet{
+				{"test_summary": config.Plugin{}},
+			},
+			expectResult: Summary{Consumed: 3, Delivered: 2, Failed: 2},
+		},
+		"multiple-inputs-multiple-outputs": {
+			inputs: []config.PluginSet{
+				{"test_finite": config.Plugin{"events": []any{"ok", "ok"}}},
+				{"test_finite": config.Plugin{"events": []any{"ok"}}},
+			},
+			outputs: []config.PluginSet{
+				{"test_summary": config.Plugin{}},
+				{"test_summary": config.Plugin{}},
+			},
+			expectResult: Summary{Consumed: 3, Delivered: 6, Failed: 0},
+		},
+		"no-events": {
+			inputs: []config.PluginSet{
+				{"test_finite": config.Plugin{}},
+			},
+			outputs: []config.PluginSet{
+				{"test_summary": config.Plugin{}},
+			},
+			expectResult: Summary{},
+		},
+		"infinite-input-rejected": {
+			inputs: []config.PluginSet{
+				{"test_finite": config.Plugin{}},
+				{"test_infinite": config.Plugin{}},
+			},
+			outputs: []config.PluginSet{
+				{"test_summary": config.Plugin{}},
+			},
+			expectErr: "test_infinite input does not support finite mode",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p := New(&config.Pipeline{
+				Settings: config.PipeSettings{Id: "test", Lines: 1, Consistency: "soft"},
+				Inputs:   test.inputs,
+				Outputs:  test.outputs,
+			}, nil, logger.Mock())
+			p.SetFinite(true)
+
+			err := p.Build()
+			if len(test.expectErr) > 0 {
+				if err == nil || !strings.Contains(err.Error(), test.expectErr) {
+					t.Fatalf("unexpected build error, want: %v, got: %v", test.expectErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("pipeline not built: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			p.Run(ctx)
+			if ctx.Err() != nil {
+				t.Fatal("pipeline not stopped by itself")
+			}
+
+			if got := p.Summary(); got != test.expectResult {
+				t.Fatalf("unexpected summary, want: %+v, got: %+v", test.expectResult, got)
+			}
+		})
+	}
+}
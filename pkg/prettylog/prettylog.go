@@ -8,7 +8,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -46,6 +48,7 @@ type Handler struct {
 	r func([]string, slog.Attr) slog.Attr
 	b *bytes.Buffer
 	m *sync.Mutex
+	w io.Writer
 }
 
 func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
@@ -53,11 +56,11 @@ func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
 }
 
 func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &Handler{h: h.h.WithAttrs(attrs), r: h.r, b: h.b, m: h.m}
+	return &Handler{h: h.h.WithAttrs(attrs), r: h.r, b: h.b, m: h.m, w: h.w}
 }
 
 func (h *Handler) WithGroup(name string) slog.Handler {
-	return &Handler{h: h.h.WithGroup(name), r: h.r, b: h.b, m: h.m}
+	return &Handler{h: h.h.WithGroup(name), r: h.r, b: h.b, m: h.m, w: h.w}
 }
 
 func (h *Handler) computeAttrs(
@@ -153,7 +156,7 @@ func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
 	if len(bytes) > 0 {
 		out.WriteString(colorize(darkGray, string(bytes)))
 	}
-	fmt.Println(out.String())
+	fmt.Fprintln(h.w, out.String())
 
 	return nil
 }
@@ -175,6 +178,10 @@ func suppressDefaults(
 }
 
 func NewHandler(opts *slog.HandlerOptions) *Handler {
+	return NewWriterHandler(os.Stdout, opts)
+}
+
+func NewWriterHandler(w io.Writer, opts *slog.HandlerOptions) *Handler {
 	if opts == nil {
 		opts = &slog.HandlerOptions{}
 	}
@@ -193,5 +200,6 @@ func NewHandler(opts *slog.HandlerOptions) *Handler {
 		}),
 		r: opts.ReplaceAttr,
 		m: &sync.Mutex{},
+		w: w,
 	}
 }
@@ -0,0 +1,136 @@
+package cel
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"github.com/google/cel-go/ext"
+	"github.com/google/cel-go/interpreter"
+
+	"github.com/gekatateam/neptunus/core"
+)
+
+const (
+	varId         = "id"
+	varRoutingKey = "routing_key"
+	varTimestamp  = "timestamp"
+	varLabels     = "labels"
+	varTags       = "tags"
+	varFields     = "fields"
+)
+
+// NewEnv creates CEL environment with event variables declared
+// and strings, math, lists, sets and encoders extensions enabled
+func NewEnv() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable(varId, cel.StringType),
+		cel.Variable(varRoutingKey, cel.StringType),
+		cel.Variable(varTimestamp, cel.TimestampType),
+		cel.Variable(varLabels, cel.MapType(cel.StringType, cel.StringType)),
+		cel.Variable(varTags, cel.ListType(cel.StringType)),
+		cel.Variable(varFields, cel.DynType),
+		cel.CrossTypeNumericComparisons(true),
+		cel.OptionalTypes(),
+		ext.Strings(),
+		ext.Math(),
+		ext.Lists(),
+		ext.Sets(),
+		ext.Encoders(),
+	)
+}
+
+// Compile parses, checks and plans an expression
+// if outType is not nil, expression result type must be assignable to it
+func Compile(env *cel.Env, expression string, outType *cel.Type) (cel.Program, error) {
+	ast, iss := env.Compile(expression)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+
+	if outType != nil && !ast.OutputType().IsAssignableType(outType) && ast.OutputType() != cel.DynType {
+		return nil, fmt.Errorf("expression must return %v, got %v", outType, ast.OutputType())
+	}
+
+	return env.Program(ast, cel.EvalOptions(cel.OptOptimize))
+}
+
+// Activation resolves event variables lazily,
+// so there is no need to build a variables map for each event
+type Activation struct {
+	e *core.Event
+}
+
+func NewActivation(e *core.Event) *Activation {
+	return &Activation{e: e}
+}
+
+func (a *Activation) Reset(e *core.Event) {
+	a.e = e
+}
+
+func (a *Activation) ResolveName(name string) (any, bool) {
+	switch name {
+	case varId:
+		return a.e.Id, true
+	case varRoutingKey:
+		return a.e.RoutingKey, true
+	case varTimestamp:
+		return a.e.Timestamp, true
+	case varLabels:
+		return a.e.Labels, true
+	case varTags:
+		return a.e.Tags, true
+	case varFields:
+		return a.e.Data, true
+	default:
+		return nil, false
+	}
+}
+
+func (a *Activation) Parent() interpreter.Activation {
+	return nil
+}
+
+// ToNative converts an expression result into Go types,
+// suitable for event data - maps with string keys, slices and primitives
+func ToNative(val ref.Val) (any, error) {
+	switch v := val.(type) {
+	case types.Null:
+		return nil, nil
+	case *types.Err:
+		return nil, v
+	case traits.Mapper:
+		m := make(map[string]any)
+		it := v.Iterator()
+		for it.HasNext() == types.True {
+			k := it.Next()
+			key, ok := k.Value().(string)
+			if !ok {
+				return nil, fmt.Errorf("map key must be a string, got %v", k.Type())
+			}
+
+			value, err := ToNative(v.Get(k))
+			if err != nil {
+				return nil, err
+			}
+			m[key] = value
+		}
+		return m, nil
+	case traits.Lister:
+		l := make([]any, 0)
+		it := v.Iterator()
+		for it.HasNext() == types.True {
+			value, err := ToNative(it.Next())
+			if err != nil {
+				return nil, err
+			}
+			l = append(l, value)
+		}
+		return l, nil
+	default:
+		return v.Value(), nil
+	}
+}
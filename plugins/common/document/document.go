@@ -0,0 +1,151 @@
+package document
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gekatateam/mappath"
+	"github.com/goccy/go-yaml"
+)
+
+const (
+	FormatJson   = "json"
+	FormatYaml   = "yaml"
+	FormatDotenv = "dotenv"
+)
+
+// Format returns document format by file extension
+// encryption extensions, like .age or .enc, are skipped
+// so secrets.yaml.age is a yaml document
+func Format(path string) (string, error) {
+	for {
+		switch e := filepath.Ext(path); e {
+		case ".age", ".enc":
+			path = strings.TrimSuffix(path, e)
+			continue
+		case ".json":
+			return FormatJson, nil
+		case ".yaml", ".yml":
+			return FormatYaml, nil
+		case ".env":
+			return FormatDotenv, nil
+		default:
+			if filepath.Base(path) == ".env" {
+				return FormatDotenv, nil
+			}
+			return "", fmt.Errorf("unknown document extension: %v", e)
+		}
+	}
+}
+
+// Unmarshal decodes structured document
+// json and yaml documents are decoded into maps and slices
+// dotenv documents are decoded into flat map of strings
+func Unmarshal(data []byte, format string) (any, error) {
+	var doc any
+
+	switch format {
+	case FormatJson:
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	case FormatYaml:
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	case FormatDotenv:
+		env, err := UnmarshalDotenv(data)
+		if err != nil {
+			return nil, err
+		}
+
+		m := make(map[string]any, len(env))
+		for k, v := range env {
+			m[k] = v
+		}
+		doc = m
+	default:
+		return nil, fmt.Errorf("unknown document format: %v, expected one of: json, yaml, dotenv", format)
+	}
+
+	return doc, nil
+}
+
+// Get returns value from document by dotted path, e.g. "path.to.key"
+// an empty path returns whole document
+func Get(doc any, path string) (any, error) {
+	if len(path) == 0 {
+		return doc, nil
+	}
+
+	return mappath.Get(doc, path)
+}
+
+// UnmarshalDotenv decodes KEY=VALUE lines
+// empty lines and lines starting with # are skipped, "export " prefix is allowed
+// double quoted values are unquoted with escape sequences, single quoted - as is
+func UnmarshalDotenv(data []byte) (map[string]string, error) {
+	vars, err := UnmarshalDotenvVars(data)
+	if err != nil {
+		return nil, err
+	}
+
+	env := make(map[string]string, len(vars))
+	for _, v := range vars {
+		env[v.Key] = v.Value
+	}
+
+	return env, nil
+}
+
+type EnvVar struct {
+	Key   string
+	Value string
+}
+
+// UnmarshalDotenvVars decodes KEY=VALUE lines in the same way as UnmarshalDotenv,
+// but keeps variables order
+func UnmarshalDotenvVars(data []byte) ([]EnvVar, error) {
+	var vars []EnvVar
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+		text = strings.TrimPrefix(text, "export ")
+
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %v: no key-value separator found", line)
+		}
+
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if len(key) == 0 {
+			return nil, fmt.Errorf("line %v: empty key", line)
+		}
+
+		switch {
+		case len(value) > 1 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %v: %w", line, err)
+			}
+			value = unquoted
+		case len(value) > 1 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		}
+
+		vars = append(vars, EnvVar{Key: key, Value: value})
+	}
+
+	return vars, scanner.Err()
+}
@@ -0,0 +1,80 @@
+package group
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+)
+
+const (
+	ModeAny = "any" // at least one nested filter accepts an event
+	ModeAll = "all" // all nested filters accept an event
+	ModeNot = "not" // at least one nested filter rejects an event
+)
+
+// Filter is a filter plugin that evaluates nested filters group
+// as one logical expression, depending on mode
+type Filter struct {
+	*core.BaseFilter `mapstructure:"-"`
+
+	mode  string
+	group *Group
+}
+
+func NewFilter(mode string) *Filter {
+	return &Filter{mode: mode}
+}
+
+func (f *Filter) Init() error {
+	switch f.mode {
+	case ModeAny, ModeAll, ModeNot:
+	default:
+		return fmt.Errorf("unknown group mode: %v", f.mode)
+	}
+
+	if f.group == nil || f.group.Len() == 0 {
+		return errors.New("at least one nested filter required")
+	}
+
+	return nil
+}
+
+func (f *Filter) SetFilters(filters []core.Filter) {
+	f.group = New(filters)
+}
+
+func (f *Filter) Close() error {
+	if f.group == nil {
+		return nil
+	}
+	return f.group.Stop()
+}
+
+func (f *Filter) Run() {
+	f.group.Start()
+
+	for e := range f.In {
+		now := time.Now()
+		if f.test(e) {
+			f.Acc <- e
+			f.Observe(metrics.EventAccepted, time.Since(now))
+		} else {
+			f.Rej <- e
+			f.Observe(metrics.EventRejected, time.Since(now))
+		}
+	}
+}
+
+func (f *Filter) test(e *core.Event) bool {
+	switch f.mode {
+	case ModeAny:
+		return f.group.Any(e)
+	case ModeNot:
+		return !f.group.All(e)
+	default:
+		return f.group.All(e)
+	}
+}
@@ -0,0 +1,167 @@
+package group_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins/common/group"
+)
+
+// labelFilter accepts events with label key equal to value
+type labelFilter struct {
+	*core.BaseFilter
+	key, value string
+	calls      int
+	closed     bool
+}
+
+func (f *labelFilter) Init() error  { return nil }
+func (f *labelFilter) Close() error { f.closed = true; return nil }
+
+func (f *labelFilter) Run() {
+	for e := range f.In {
+		f.calls++
+		if e.Labels[f.key] == f.value {
+			f.Acc <- e
+		} else {
+			f.Rej <- e
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	tests := map[string]struct {
+		mode           string
+		expectAccepted []string
+		expectRejected []string
+		expectCalls    []int
+	}{
+		"any": {
+			mode:           group.ModeAny,
+			expectAccepted: []string{"a-and-b", "a-only", "b-only"},
+			expectRejected: []string{"none"},
+			expectCalls:    []int{4, 2}, // second filter is called only for events rejected by the first one
+		},
+		"all": {
+			mode:           group.ModeAll,
+			expectAccepted: []string{"a-and-b"},
+			expectRejected: []string{"a-only", "b-only", "none"},
+			expectCalls:    []int{4, 2}, // second filter is called only for events accepted by the first one
+		},
+		"not": {
+			mode:           group.ModeNot,
+			expectAccepted: []string{"a-only", "b-only", "none"},
+			expectRejected: []string{"a-and-b"},
+			expectCalls:    []int{4, 2},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			nested := []*labelFilter{
+				{BaseFilter: &core.BaseFilter{}, key: "a", value: "1"},
+				{BaseFilter: &core.BaseFilter{}, key: "b", value: "1"},
+			}
+
+			filter := group.NewFilter(test.mode)
+			filter.BaseFilter = &core.BaseFilter{
+				Log: logger.Mock(),
+				Obs: metrics.ObserveMock,
+			}
+			filter.SetFilters([]core.Filter{nested[0], nested[1]})
+			if err := filter.Init(); err != nil {
+				t.Fatalf("filter not initialized: %v", err)
+			}
+
+			input := make(chan *core.Event, 100)
+			accept := make(chan *core.Event, 100)
+			reject := make(chan *core.Event, 100)
+			filter.SetChannels(input, reject, accept)
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				filter.Run()
+				wg.Done()
+			}()
+
+			for rk, labels := range map[string]map[string]string{
+				"a-and-b": {"a": "1", "b": "1"},
+				"a-only":  {"a": "1"},
+				"b-only":  {"b": "1"},
+				"none":    {},
+			} {
+				e := core.NewEvent(rk)
+				e.Labels = labels
+				input <- e
+			}
+
+			close(input)
+			wg.Wait()
+			filter.Close()
+			close(accept)
+			close(reject)
+
+			assertKeys(t, "accepted", accept, test.expectAccepted)
+			assertKeys(t, "rejected", reject, test.expectRejected)
+
+			for i, f := range nested {
+				if f.calls != test.expectCalls[i] {
+					t.Fatalf("unexpected nested filter %v calls, want: %v, got: %v", i, test.expectCalls[i], f.calls)
+				}
+
+				if !f.closed {
+					t.Fatalf("nested filter %v not closed", i)
+				}
+			}
+		})
+	}
+}
+
+func TestFilter_Init(t *testing.T) {
+	tests := map[string]struct {
+		mode   string
+		nested []core.Filter
+	}{
+		"unknown-mode": {
+			mode:   "xor",
+			nested: []core.Filter{&labelFilter{BaseFilter: &core.BaseFilter{}}},
+		},
+		"no-nested-filters": {
+			mode:   group.ModeAll,
+			nested: nil,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			filter := group.NewFilter(test.mode)
+			filter.SetFilters(test.nested)
+			if err := filter.Init(); err == nil {
+				t.Fatal("filter initialized, but error expected")
+			}
+		})
+	}
+}
+
+func assertKeys(t *testing.T, flow string, ch chan *core.Event, expect []string) {
+	t.Helper()
+
+	got := make(map[string]struct{})
+	for e := range ch {
+		got[e.RoutingKey] = struct{}{}
+	}
+
+	if len(got) != len(expect) {
+		t.Fatalf("unexpected %v events, want: %v, got: %v", flow, expect, got)
+	}
+
+	for _, rk := range expect {
+		if _, ok := got[rk]; !ok {
+			t.Fatalf("event %v expected in %v flow, got: %v", rk, flow, got)
+		}
+	}
+}
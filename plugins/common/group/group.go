@@ -0,0 +1,115 @@
+package group
+
+import (
+	"sync"
+
+	"github.com/gekatateam/neptunus/core"
+)
+
+// Group runs nested filters and passes events through them one by one,
+// so a set of filters can be evaluated as one logical expression
+//
+// each nested filter has its own goroutine and unbuffered channels,
+// a group sends an event to a filter and waits until the filter
+// accepts or rejects it
+type Group struct {
+	members []*member
+	started bool
+	wg      *sync.WaitGroup
+}
+
+type member struct {
+	f   core.Filter
+	in  chan *core.Event
+	acc chan *core.Event
+	rej chan *core.Event
+}
+
+func New(filters []core.Filter) *Group {
+	g := &Group{
+		members: make([]*member, 0, len(filters)),
+		wg:      &sync.WaitGroup{},
+	}
+
+	for _, f := range filters {
+		m := &member{
+			f:   f,
+			in:  make(chan *core.Event),
+			acc: make(chan *core.Event),
+			rej: make(chan *core.Event),
+		}
+		f.SetChannels(m.in, m.rej, m.acc)
+		g.members = append(g.members, m)
+	}
+
+	return g
+}
+
+func (g *Group) Len() int {
+	return len(g.members)
+}
+
+// Start runs nested filters
+func (g *Group) Start() {
+	if g.started {
+		return
+	}
+	g.started = true
+
+	for _, m := range g.members {
+		g.wg.Add(1)
+		go func(f core.Filter) {
+			f.Run() // blocking call, loop inside
+			g.wg.Done()
+		}(m.f)
+	}
+}
+
+// Stop stops and closes nested filters
+func (g *Group) Stop() error {
+	if g.started {
+		for _, m := range g.members {
+			close(m.in)
+		}
+		g.wg.Wait()
+		g.started = false
+	}
+
+	for _, m := range g.members {
+		m.f.Close()
+	}
+
+	return nil
+}
+
+// Any returns true if at least one of nested filters accepts an event
+// filters after first accepted are not called
+func (g *Group) Any(e *core.Event) bool {
+	for _, m := range g.members {
+		if m.test(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// All returns true if all nested filters accept an event
+// filters after first rejected are not called
+func (g *Group) All(e *core.Event) bool {
+	for _, m := range g.members {
+		if !m.test(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *member) test(e *core.Event) bool {
+	m.in <- e
+	select {
+	case <-m.acc:
+		return true
+	case <-m.rej:
+		return false
+	}
+}
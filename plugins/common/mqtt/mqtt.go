@@ -0,0 +1,159 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	pkgtls "github.com/gekatateam/neptunus/plugins/common/tls"
+)
+
+// Client contains MQTT client settings, common for input and output plugins
+// client reconnects endlessly, so plugins do not need to handle connection loss
+type Client struct {
+	Brokers              []string      `mapstructure:"brokers"`
+	ClientId             string        `mapstructure:"client_id"`
+	Username             string        `mapstructure:"username"`
+	Password             string        `mapstructure:"password"`
+	ProtocolVersion      string        `mapstructure:"protocol_version"`
+	CleanSession         bool          `mapstructure:"clean_session"`
+	SessionExpiry        time.Duration `mapstructure:"session_expiry"`
+	KeepAlive            time.Duration `mapstructure:"keep_alive"`
+	ConnectTimeout       time.Duration `mapstructure:"connect_timeout"`
+	MaxReconnectInterval time.Duration `mapstructure:"max_reconnect_interval"`
+
+	*pkgtls.TLSClientConfig `mapstructure:",squash"`
+}
+
+// Subscription contains topic filters, that are subscribed on each successful connection,
+// including reconnects, and handler for received messages
+//
+// messages are passed to handler in the order in which they were received,
+// and must be ACKed in the same order, as MQTT requires
+type Subscription struct {
+	Topics  []string
+	Qos     byte
+	Handler func(*Message)
+}
+
+// Message is a received or published message, independent of protocol version
+type Message struct {
+	Topic        string
+	Subscription string // topic filter by which message was received, if known
+	Payload      []byte
+	Qos          byte
+	Retained     bool
+	Duplicate    bool
+	MessageId    uint16
+	Properties   []Property // user properties, MQTT 5 only
+
+	// Ack acknowledges received message, it is never nil for received messages
+	Ack func() error
+}
+
+type Property struct {
+	Key   string
+	Value string
+}
+
+// Property returns value of the first user property with the key,
+// because MQTT allows the same key to appear more than once
+func (m *Message) Property(key string) (string, bool) {
+	for _, p := range m.Properties {
+		if p.Key == key {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// Conn is a connection to broker, independent of protocol version
+type Conn interface {
+	// Publish starts message publishing and returns a function, that waits for its completion
+	// with QoS 1 and 2 publishing is completed when broker acknowledges a message,
+	// with QoS 0 - when it is written to connection
+	Publish(ctx context.Context, msg *Message) func() error
+	// Disconnect closes connection, waiting up to timeout for in-flight work
+	Disconnect(timeout time.Duration)
+}
+
+// Connect validates settings and connects to broker
+// if sub is not nil, it's topics are subscribed and received messages are passed to it's handler
+func (c *Client) Connect(log *slog.Logger, sub *Subscription) (Conn, error) {
+	if len(c.Brokers) == 0 {
+		return nil, errors.New("at least one broker address required")
+	}
+
+	if len(c.ClientId) == 0 {
+		return nil, errors.New("client_id required")
+	}
+
+	if c.SessionExpiry < 0 {
+		return nil, errors.New("session_expiry must not be negative")
+	}
+
+	tlsConfig, err := c.TLSClientConfig.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.ProtocolVersion {
+	case "3.1":
+		return c.connectV3(log, 3, tlsConfig, sub)
+	case "3.1.1":
+		return c.connectV3(log, 4, tlsConfig, sub)
+	case "5":
+		return c.connectV5(log, tlsConfig, sub)
+	default:
+		return nil, fmt.Errorf("unknown protocol version: %v; expected one of: 3.1, 3.1.1, 5", c.ProtocolVersion)
+	}
+}
+
+// matchTopic reports whether topic matches topic filter
+// shared subscription prefix of a filter is ignored
+func matchTopic(filter, topic string) bool {
+	if shared, ok := strings.CutPrefix(filter, "$share/"); ok {
+		_, f, ok := strings.Cut(shared, "/")
+		if !ok {
+			return false
+		}
+		filter = f
+	}
+
+	// topics beginning with $ are not matched by wildcards at the first level
+	if strings.HasPrefix(topic, "$") && (strings.HasPrefix(filter, "+") || strings.HasPrefix(filter, "#")) {
+		return false
+	}
+
+	filterLevels, topicLevels := strings.Split(filter, "/"), strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+
+		if i >= len(topicLevels) || (level != "+" && level != topicLevels[i]) {
+			return false
+		}
+	}
+
+	return len(filterLevels) == len(topicLevels)
+}
+
+// reconnectBackoff doubles delay after each failed attempt, starting from one second,
+// up to max interval, but not less than one second, as MQTT 3 client does
+func reconnectBackoff(maxInterval time.Duration) func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		if attempt <= 0 {
+			return 0
+		}
+
+		delay := time.Second
+		for i := 1; i < attempt && delay < maxInterval; i++ {
+			delay *= 2
+		}
+		return min(delay, max(maxInterval, time.Second))
+	}
+}
@@ -0,0 +1,158 @@
+package mqtt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.golang/paho"
+
+	"github.com/gekatateam/neptunus/logger"
+	pkgtls "github.com/gekatateam/neptunus/plugins/common/tls"
+)
+
+func TestMatchTopic(t *testing.T) {
+	tests := map[string]struct {
+		filter string
+		topic  string
+		want   bool
+	}{
+		"exact":                      {filter: "sensors/1/telemetry", topic: "sensors/1/telemetry", want: true},
+		"exact-mismatch":             {filter: "sensors/1/telemetry", topic: "sensors/2/telemetry", want: false},
+		"single-level":               {filter: "sensors/+/telemetry", topic: "sensors/1/telemetry", want: true},
+		"single-level-empty":         {filter: "sensors/+/telemetry", topic: "sensors//telemetry", want: true},
+		"single-level-too-deep":      {filter: "sensors/+", topic: "sensors/1/telemetry", want: false},
+		"multi-level":                {filter: "sensors/#", topic: "sensors/1/telemetry", want: true},
+		"multi-level-parent":         {filter: "sensors/#", topic: "sensors", want: true},
+		"multi-level-other":          {filter: "sensors/#", topic: "gateways/1", want: false},
+		"shorter-topic":              {filter: "sensors/1/telemetry", topic: "sensors/1", want: false},
+		"system-topic-wildcard":      {filter: "#", topic: "$SYS/uptime", want: false},
+		"system-topic-single-level":  {filter: "+/uptime", topic: "$SYS/uptime", want: false},
+		"system-topic-explicit":      {filter: "$SYS/#", topic: "$SYS/uptime", want: true},
+		"shared-subscription":        {filter: "$share/group/sensors/+/telemetry", topic: "sensors/1/telemetry", want: true},
+		"shared-subscription-broken": {filter: "$share/group", topic: "group", want: false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := matchTopic(test.filter, test.topic); got != test.want {
+				t.Fatalf("unexpected result, want: %v, got: %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestReconnectBackoff(t *testing.T) {
+	backoff := reconnectBackoff(5 * time.Second)
+	want := []time.Duration{0, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+
+	for attempt, delay := range want {
+		if got := backoff(attempt); got != delay {
+			t.Fatalf("attempt %v: unexpected delay, want: %v, got: %v", attempt, delay, got)
+		}
+	}
+
+	if got := reconnectBackoff(0)(3); got != time.Second {
+		t.Fatalf("unexpected delay without max interval, want: %v, got: %v", time.Second, got)
+	}
+}
+
+func TestMessageV5(t *testing.T) {
+	p := &paho.Publish{
+		PacketID: 42,
+		QoS:      1,
+		Retain:   true,
+		Topic:    "sensors/1/telemetry",
+		Payload:  []byte("21.5"),
+		Properties: &paho.PublishProperties{
+			User: paho.UserProperties{
+				{Key: "device-id", Value: "first"},
+				{Key: "device-id", Value: "second"},
+			},
+		},
+	}
+
+	msg := messageV5(paho.PublishReceived{Packet: p}, []string{"gateways/#", "sensors/+/telemetry", "sensors/#"})
+
+	if msg.Topic != p.Topic || string(msg.Payload) != string(p.Payload) {
+		t.Fatalf("unexpected message: %v - %s", msg.Topic, msg.Payload)
+	}
+
+	if msg.Qos != 1 || !msg.Retained || msg.Duplicate || msg.MessageId != 42 {
+		t.Fatalf("unexpected message flags: qos %v, retained %v, duplicate %v, id %v", msg.Qos, msg.Retained, msg.Duplicate, msg.MessageId)
+	}
+
+	if msg.Subscription != "sensors/+/telemetry" {
+		t.Fatalf("unexpected subscription, want: sensors/+/telemetry, got: %v", msg.Subscription)
+	}
+
+	want := []Property{{Key: "device-id", Value: "first"}, {Key: "device-id", Value: "second"}}
+	if !reflect.DeepEqual(msg.Properties, want) {
+		t.Fatalf("unexpected properties, want: %v, got: %v", want, msg.Properties)
+	}
+
+	if v, ok := msg.Property("device-id"); !ok || v != "first" {
+		t.Fatalf("unexpected property value, want: first, got: %v (%v)", v, ok)
+	}
+
+	if _, ok := msg.Property("missing"); ok {
+		t.Fatal("missing property found")
+	}
+
+	msg = messageV5(paho.PublishReceived{Packet: &paho.Publish{Topic: "other"}}, []string{"sensors/#"})
+	if len(msg.Subscription) > 0 || len(msg.Properties) > 0 {
+		t.Fatalf("unexpected subscription or properties: %v, %v", msg.Subscription, msg.Properties)
+	}
+}
+
+func TestClient_ConnectFailed(t *testing.T) {
+	tests := map[string]struct {
+		client Client
+		err    string
+	}{
+		"no-brokers": {
+			client: Client{ClientId: "test", ProtocolVersion: "5"},
+			err:    "at least one broker address required",
+		},
+		"no-client-id": {
+			client: Client{Brokers: []string{"tcp://localhost:1883"}, ProtocolVersion: "5"},
+			err:    "client_id required",
+		},
+		"negative-session-expiry": {
+			client: Client{Brokers: []string{"tcp://localhost:1883"}, ClientId: "test", ProtocolVersion: "5", SessionExpiry: -time.Second},
+			err:    "session_expiry must not be negative",
+		},
+		"unknown-protocol-version": {
+			client: Client{Brokers: []string{"tcp://localhost:1883"}, ClientId: "test", ProtocolVersion: "4"},
+			err:    "unknown protocol version: 4",
+		},
+		"bad-broker-address-v5": {
+			client: Client{Brokers: []string{"tcp://local host:1883"}, ClientId: "test", ProtocolVersion: "5"},
+			err:    "broker address parsing failed",
+		},
+		"connection-refused-v5": {
+			client: Client{Brokers: []string{"tcp://127.0.0.1:1"}, ClientId: "test", ProtocolVersion: "5", ConnectTimeout: 500 * time.Millisecond},
+			err:    "connection failed",
+		},
+		"connection-refused-v3": {
+			client: Client{Brokers: []string{"tcp://127.0.0.1:1"}, ClientId: "test", ProtocolVersion: "3.1.1", ConnectTimeout: 500 * time.Millisecond},
+			err:    "connection failed",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.client.TLSClientConfig = &pkgtls.TLSClientConfig{}
+
+			_, err := test.client.Connect(logger.Mock(), nil)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+
+			if !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("unexpected error, want: %v, got: %v", test.err, err)
+			}
+		})
+	}
+}
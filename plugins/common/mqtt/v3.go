@@ -0,0 +1,132 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// connV3 is a MQTT 3.1 and 3.1.1 connection
+type connV3 struct {
+	client mqtt.Client
+}
+
+func (c *Client) connectV3(log *slog.Logger, version uint, tlsConfig *tls.Config, sub *Subscription) (Conn, error) {
+	opts := mqtt.NewClientOptions().
+		SetClientID(c.ClientId).
+		SetUsername(c.Username).
+		SetPassword(c.Password).
+		SetProtocolVersion(version).
+		SetCleanSession(c.CleanSession).
+		SetKeepAlive(c.KeepAlive).
+		SetConnectTimeout(c.ConnectTimeout).
+		SetMaxReconnectInterval(c.MaxReconnectInterval).
+		SetAutoReconnect(true).
+		SetConnectRetry(false).
+		SetOnConnectHandler(func(client mqtt.Client) {
+			log.Info("connection established")
+			if sub != nil {
+				c.subscribeV3(log, client, sub)
+			}
+		}).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			log.Warn("connection lost, reconnecting",
+				"error", err,
+			)
+		})
+
+	for _, broker := range c.Brokers {
+		opts.AddBroker(broker)
+	}
+
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	// messages are ACKed by subscriber
+	// persistent session messages may be received before subscriptions are restored
+	// so they are passed to handler with empty subscription
+	if sub != nil {
+		opts.SetAutoAckDisabled(true).
+			SetOrderMatters(true).
+			SetDefaultPublishHandler(handlerV3(sub, ""))
+	}
+
+	client := mqtt.NewClient(opts)
+	if err := waitToken(context.Background(), client.Connect(), c.ConnectTimeout); err != nil {
+		client.Disconnect(0)
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+
+	return &connV3{client: client}, nil
+}
+
+func (c *Client) subscribeV3(log *slog.Logger, client mqtt.Client, sub *Subscription) {
+	for _, topic := range sub.Topics {
+		if err := waitToken(context.Background(), client.Subscribe(topic, sub.Qos, handlerV3(sub, topic)), c.ConnectTimeout); err != nil {
+			log.Error(fmt.Sprintf("subscription to %v failed", topic),
+				"error", err,
+			)
+			continue
+		}
+		log.Info(fmt.Sprintf("subscribed to %v", topic))
+	}
+}
+
+func handlerV3(sub *Subscription, subscription string) mqtt.MessageHandler {
+	return func(_ mqtt.Client, msg mqtt.Message) {
+		sub.Handler(&Message{
+			Topic:        msg.Topic(),
+			Subscription: subscription,
+			Payload:      msg.Payload(),
+			Qos:          msg.Qos(),
+			Retained:     msg.Retained(),
+			Duplicate:    msg.Duplicate(),
+			MessageId:    msg.MessageID(),
+			Ack: func() error {
+				msg.Ack()
+				return nil
+			},
+		})
+	}
+}
+
+func (c *connV3) Publish(ctx context.Context, msg *Message) func() error {
+	token := c.client.Publish(msg.Topic, msg.Qos, msg.Retained, msg.Payload)
+	return func() error {
+		return waitToken(ctx, token, 0)
+	}
+}
+
+func (c *connV3) Disconnect(timeout time.Duration) {
+	c.client.Disconnect(uint(timeout.Milliseconds()))
+}
+
+// waitToken waits for token completion and returns it's error, if any
+// if timeout is positive, it limits waiting in addition to context
+// already completed token error is returned even if context is done
+func waitToken(ctx context.Context, token mqtt.Token, timeout time.Duration) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	default:
+	}
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return errors.New("operation timed out")
+	}
+}
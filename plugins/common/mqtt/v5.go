@@ -0,0 +1,228 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.golang/autopaho"
+	"github.com/eclipse/paho.golang/paho"
+)
+
+// manual ACKs are sent by client in batches with this interval
+const ackInterval = 100 * time.Millisecond
+
+// connV5 is a MQTT 5 connection
+type connV5 struct {
+	manager    *autopaho.ConnectionManager
+	subscribed bool
+}
+
+func (c *Client) connectV5(log *slog.Logger, tlsConfig *tls.Config, sub *Subscription) (Conn, error) {
+	brokers := make([]*url.URL, 0, len(c.Brokers))
+	for _, broker := range c.Brokers {
+		u, err := url.Parse(broker)
+		if err != nil {
+			return nil, fmt.Errorf("broker address parsing failed: %w", err)
+		}
+		brokers = append(brokers, u)
+	}
+
+	// in MQTT 5 session lifetime is controlled by session expiry interval,
+	// zero interval means that session ends when connection is closed
+	var sessionExpiry uint32
+	if !c.CleanSession {
+		sessionExpiry = math.MaxUint32 // session does not expire
+		if c.SessionExpiry > 0 {
+			sessionExpiry = uint32(min(c.SessionExpiry/time.Second, math.MaxUint32-1))
+		}
+	}
+
+	var (
+		mu         sync.Mutex
+		connectErr error
+	)
+
+	cfg := autopaho.ClientConfig{
+		ServerUrls:                    brokers,
+		TlsCfg:                        tlsConfig,
+		KeepAlive:                     uint16(min(c.KeepAlive/time.Second, math.MaxUint16)),
+		CleanStartOnInitialConnection: c.CleanSession,
+		SessionExpiryInterval:         sessionExpiry,
+		ReconnectBackoff:              reconnectBackoff(c.MaxReconnectInterval),
+		ConnectTimeout:                c.ConnectTimeout,
+		ConnectUsername:               c.Username,
+		ConnectPassword:               []byte(c.Password),
+		// if any connect property is set, paho does not request problem information,
+		// and brokers may drop user properties of messages sent to such client
+		ConnectPacketBuilder: func(cp *paho.Connect, _ *url.URL) (*paho.Connect, error) {
+			if cp.Properties != nil {
+				cp.Properties.RequestProblemInfo = true
+			}
+			return cp, nil
+		},
+		OnConnectionUp: func(manager *autopaho.ConnectionManager, _ *paho.Connack) {
+			log.Info("connection established")
+			if sub != nil {
+				c.subscribeV5(log, manager, sub)
+			}
+		},
+		OnConnectError: func(err error) {
+			mu.Lock()
+			connectErr = err
+			mu.Unlock()
+			log.Warn("connection attempt failed",
+				"error", err,
+			)
+		},
+		ClientConfig: paho.ClientConfig{
+			ClientID: c.ClientId,
+			OnClientError: func(err error) {
+				log.Warn("connection lost, reconnecting",
+					"error", err,
+				)
+			},
+			OnServerDisconnect: func(d *paho.Disconnect) {
+				log.Warn("disconnected by broker, reconnecting",
+					"reason_code", d.ReasonCode,
+				)
+			},
+		},
+	}
+
+	// messages are ACKed by subscriber
+	// paho client sends ACKs in the order in which messages were received
+	if sub != nil {
+		cfg.EnableManualAcknowledgment = true
+		cfg.SendAcksInterval = ackInterval
+		cfg.OnPublishReceived = []func(paho.PublishReceived) (bool, error){
+			func(pr paho.PublishReceived) (bool, error) {
+				sub.Handler(messageV5(pr, sub.Topics))
+				return true, nil
+			},
+		}
+	}
+
+	manager, err := autopaho.NewConnection(context.Background(), cfg)
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.ConnectTimeout)
+	defer cancel()
+
+	if err := manager.AwaitConnection(ctx); err != nil {
+		manager.Disconnect(context.Background())
+
+		mu.Lock()
+		defer mu.Unlock()
+		if connectErr != nil {
+			err = connectErr
+		}
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+
+	return &connV5{manager: manager, subscribed: sub != nil}, nil
+}
+
+// each topic filter is subscribed individually, as in MQTT 3 client,
+// so failed subscription does not affect others
+func (c *Client) subscribeV5(log *slog.Logger, manager *autopaho.ConnectionManager, sub *Subscription) {
+	for _, topic := range sub.Topics {
+		ctx, cancel := context.WithTimeout(context.Background(), c.ConnectTimeout)
+		_, err := manager.Subscribe(ctx, &paho.Subscribe{
+			Subscriptions: []paho.SubscribeOptions{
+				{Topic: topic, QoS: sub.Qos},
+			},
+		})
+		cancel()
+
+		if err != nil {
+			log.Error(fmt.Sprintf("subscription to %v failed", topic),
+				"error", err,
+			)
+			continue
+		}
+		log.Info(fmt.Sprintf("subscribed to %v", topic))
+	}
+}
+
+// messageV5 converts received message
+// MQTT 5 messages have no handler per subscription, so subscription is the first
+// configured topic filter that matches message topic; persistent session messages
+// may be received by subscriptions that are no longer configured, in this case
+// subscription is empty
+func messageV5(pr paho.PublishReceived, topics []string) *Message {
+	p := pr.Packet
+	msg := &Message{
+		Topic:     p.Topic,
+		Payload:   p.Payload,
+		Qos:       p.QoS,
+		Retained:  p.Retain,
+		Duplicate: p.Duplicate(),
+		MessageId: p.PacketID,
+		Ack: func() error {
+			return pr.Client.Ack(p)
+		},
+	}
+
+	if p.Properties != nil {
+		for _, u := range p.Properties.User {
+			msg.Properties = append(msg.Properties, Property{Key: u.Key, Value: u.Value})
+		}
+	}
+
+	for _, topic := range topics {
+		if matchTopic(topic, p.Topic) {
+			msg.Subscription = topic
+			break
+		}
+	}
+
+	return msg
+}
+
+// Publish blocks until publishing is completed, so it runs in a separate goroutine
+// and messages order is not guaranteed
+func (c *connV5) Publish(ctx context.Context, msg *Message) func() error {
+	p := &paho.Publish{
+		Topic:   msg.Topic,
+		QoS:     msg.Qos,
+		Retain:  msg.Retained,
+		Payload: msg.Payload,
+	}
+
+	if len(msg.Properties) > 0 {
+		p.Properties = &paho.PublishProperties{}
+		for _, prop := range msg.Properties {
+			p.Properties.User.Add(prop.Key, prop.Value)
+		}
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.manager.Publish(ctx, p)
+		done <- err
+	}()
+
+	return func() error {
+		return <-done
+	}
+}
+
+func (c *connV5) Disconnect(timeout time.Duration) {
+	// client has no way to flush pending ACKs,
+	// so it is given time to send them before disconnection
+	if c.subscribed {
+		time.Sleep(2 * ackInterval)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	c.manager.Disconnect(ctx)
+}
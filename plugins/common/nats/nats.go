@@ -0,0 +1,88 @@
+package nats
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+
+	pkgtls "github.com/gekatateam/neptunus/plugins/common/tls"
+)
+
+// Connection contains NATS connection settings, common for input and output plugins
+// client reconnects endlessly, so plugins do not need to handle connection loss
+type Connection struct {
+	Servers         []string      `mapstructure:"servers"`
+	ConnectionName  string        `mapstructure:"connection_name"`
+	Username        string        `mapstructure:"username"`
+	Password        string        `mapstructure:"password"`
+	Token           string        `mapstructure:"token"`
+	CredentialsFile string        `mapstructure:"credentials_file"`
+	DialTimeout     time.Duration `mapstructure:"dial_timeout"`
+	ReconnectWait   time.Duration `mapstructure:"reconnect_wait"`
+
+	*pkgtls.TLSClientConfig `mapstructure:",squash"`
+}
+
+func (c *Connection) Connect(log *slog.Logger) (*nats.Conn, error) {
+	if len(c.Servers) == 0 {
+		return nil, errors.New("at least one server address required")
+	}
+
+	tlsConfig, err := c.TLSClientConfig.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []nats.Option{
+		nats.Name(c.ConnectionName),
+		nats.Timeout(c.DialTimeout),
+		nats.ReconnectWait(c.ReconnectWait),
+		nats.MaxReconnects(-1),
+		nats.RetryOnFailedConnect(false),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				log.Warn("connection lost, reconnecting",
+					"error", err,
+				)
+			}
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			log.Info("connection restored",
+				"server", nc.ConnectedUrlRedacted(),
+			)
+		}),
+		nats.ErrorHandler(func(_ *nats.Conn, s *nats.Subscription, err error) {
+			if s != nil {
+				log.Error("async error occurred",
+					"error", err,
+					"subject", s.Subject,
+				)
+				return
+			}
+			log.Error("async error occurred",
+				"error", err,
+			)
+		}),
+	}
+
+	if len(c.Username) > 0 {
+		opts = append(opts, nats.UserInfo(c.Username, c.Password))
+	}
+
+	if len(c.Token) > 0 {
+		opts = append(opts, nats.Token(c.Token))
+	}
+
+	if len(c.CredentialsFile) > 0 {
+		opts = append(opts, nats.UserCredentials(c.CredentialsFile))
+	}
+
+	if tlsConfig != nil {
+		opts = append(opts, nats.Secure(tlsConfig))
+	}
+
+	return nats.Connect(strings.Join(c.Servers, ","), opts...)
+}
@@ -0,0 +1,57 @@
+package redis
+
+import (
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/gekatateam/neptunus/plugins/common/tls"
+)
+
+// Redis contains Redis connection settings, common for all plugins that use Redis
+type Redis struct {
+	Servers          []string      `mapstructure:"servers"`
+	Username         string        `mapstructure:"username"`
+	Password         string        `mapstructure:"password"`
+	Timeout          time.Duration `mapstructure:"timeout"`
+	ConnsMaxIdleTime time.Duration `mapstructure:"conns_max_idle_time"`
+	ConnsMaxLifetime time.Duration `mapstructure:"conns_max_life_time"`
+	ConnsMaxOpen     int           `mapstructure:"conns_max_open"`
+	ConnsMaxIdle     int           `mapstructure:"conns_max_idle"`
+
+	*tls.TLSClientConfig `mapstructure:",squash"`
+}
+
+// Client creates new universal client: a single node client for one server,
+// or a cluster client for multiple servers
+func (r *Redis) Client() (redis.UniversalClient, error) {
+	if len(r.Servers) == 0 {
+		return nil, errors.New("at least one Redis server address required")
+	}
+
+	tlsConfig, err := r.TLSClientConfig.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	// also, pool options:
+	// MinIdleConns    int
+	// MaxActiveConns  int
+	return redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:                 r.Servers,
+		Username:              r.Username,
+		Password:              r.Password,
+		MaxRetries:            0,
+		DialTimeout:           r.Timeout,
+		ReadTimeout:           r.Timeout,
+		WriteTimeout:          r.Timeout,
+		ContextTimeoutEnabled: true,
+		PoolSize:              r.ConnsMaxOpen,
+		PoolTimeout:           r.Timeout,
+		MaxIdleConns:          r.ConnsMaxIdle,
+		ConnMaxIdleTime:       r.ConnsMaxIdleTime,
+		ConnMaxLifetime:       r.ConnsMaxLifetime,
+		TLSConfig:             tlsConfig,
+	}), nil
+}
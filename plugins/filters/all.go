@@ -1,8 +1,12 @@
 package filters
 
 import (
+	_ "github.com/gekatateam/neptunus/plugins/filters/all"
+	_ "github.com/gekatateam/neptunus/plugins/filters/any"
+	_ "github.com/gekatateam/neptunus/plugins/filters/cel"
 	_ "github.com/gekatateam/neptunus/plugins/filters/glob"
 	_ "github.com/gekatateam/neptunus/plugins/filters/noerrors"
+	_ "github.com/gekatateam/neptunus/plugins/filters/not"
 	_ "github.com/gekatateam/neptunus/plugins/filters/pass"
 	_ "github.com/gekatateam/neptunus/plugins/filters/starlark"
 )
@@ -0,0 +1,13 @@
+package all
+
+import (
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/plugins"
+	"github.com/gekatateam/neptunus/plugins/common/group"
+)
+
+func init() {
+	plugins.AddFilter("all", func() core.Filter {
+		return group.NewFilter(group.ModeAll)
+	})
+}
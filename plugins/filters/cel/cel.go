@@ -0,0 +1,98 @@
+package cel
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/cel-go/cel"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+	common "github.com/gekatateam/neptunus/plugins/common/cel"
+)
+
+type Cel struct {
+	*core.BaseFilter `mapstructure:"-"`
+	Expression       string `mapstructure:"expression"`
+
+	program    cel.Program
+	activation *common.Activation
+}
+
+func (f *Cel) Init() error {
+	if len(f.Expression) == 0 {
+		return errors.New("expression required")
+	}
+
+	env, err := common.NewEnv()
+	if err != nil {
+		return err
+	}
+
+	program, err := common.Compile(env, f.Expression, cel.BoolType)
+	if err != nil {
+		return fmt.Errorf("expression compilation failed: %w", err)
+	}
+
+	f.program = program
+	f.activation = common.NewActivation(nil)
+
+	return nil
+}
+
+func (f *Cel) Close() error {
+	return nil
+}
+
+func (f *Cel) Run() {
+	for e := range f.In {
+		now := time.Now()
+		f.activation.Reset(e)
+
+		ok, err := f.eval()
+		if err != nil {
+			f.Log.Error("expression evaluation failed",
+				"error", err,
+				slog.Group("event",
+					"id", e.Id,
+					"key", e.RoutingKey,
+				),
+			)
+			e.StackError(fmt.Errorf("expression evaluation failed: %v", err))
+			f.Rej <- e
+			f.Observe(metrics.EventRejected, time.Since(now))
+			continue
+		}
+
+		if ok {
+			f.Acc <- e
+			f.Observe(metrics.EventAccepted, time.Since(now))
+		} else {
+			f.Rej <- e
+			f.Observe(metrics.EventRejected, time.Since(now))
+		}
+	}
+}
+
+func (f *Cel) eval() (bool, error) {
+	result, _, err := f.program.Eval(f.activation)
+	if err != nil {
+		return false, err
+	}
+
+	ok, isBool := result.Value().(bool)
+	if !isBool {
+		return false, fmt.Errorf("unknown expression result, expected bool, got %v", result.Type())
+	}
+
+	return ok, nil
+}
+
+func init() {
+	plugins.AddFilter("cel", func() core.Filter {
+		return &Cel{}
+	})
+}
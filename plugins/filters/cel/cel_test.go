@@ -0,0 +1,240 @@
+package cel_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/pkg/mapstructure"
+	common "github.com/gekatateam/neptunus/plugins/common/starlark"
+	"github.com/gekatateam/neptunus/plugins/filters/cel"
+	"github.com/gekatateam/neptunus/plugins/filters/starlark"
+)
+
+func TestCel(t *testing.T) {
+	tests := map[string]struct {
+		config         map[string]any
+		input          chan *core.Event
+		accept         chan *core.Event
+		reject         chan *core.Event
+		events         []*core.Event
+		expectedAccept int
+		expectedReject int
+	}{
+		"must-split-by-routing-key": {
+			config: map[string]any{
+				"expression": `routing_key.startsWith("pass")`,
+			},
+			input:  make(chan *core.Event, 100),
+			accept: make(chan *core.Event, 100),
+			reject: make(chan *core.Event, 100),
+			events: []*core.Event{
+				core.NewEvent("pass-me"),
+				core.NewEvent("reject-me"),
+			},
+			expectedAccept: 1,
+			expectedReject: 1,
+		},
+		"must-split-by-label-and-field": {
+			config: map[string]any{
+				"expression": `labels["env"] == "prod" && fields.request.status >= 500`,
+			},
+			input:  make(chan *core.Event, 100),
+			accept: make(chan *core.Event, 100),
+			reject: make(chan *core.Event, 100),
+			events: []*core.Event{
+				{
+					Labels: map[string]string{"env": "prod"},
+					Data: map[string]any{
+						"request": map[string]any{"status": 503},
+					},
+				},
+				{
+					Labels: map[string]string{"env": "prod"},
+					Data: map[string]any{
+						"request": map[string]any{"status": 200.0},
+					},
+				},
+				{
+					Labels: map[string]string{"env": "dev"},
+					Data: map[string]any{
+						"request": map[string]any{"status": 500},
+					},
+				},
+			},
+			expectedAccept: 1,
+			expectedReject: 2,
+		},
+		"must-reject-on-missing-field": {
+			config: map[string]any{
+				"expression": `fields.user.name == "admin"`,
+			},
+			input:  make(chan *core.Event, 100),
+			accept: make(chan *core.Event, 100),
+			reject: make(chan *core.Event, 100),
+			events: []*core.Event{
+				{
+					Data: map[string]any{
+						"user": map[string]any{"name": "admin"},
+					},
+				},
+				{
+					Data: map[string]any{
+						"message": "hello",
+					},
+				},
+			},
+			expectedAccept: 1,
+			expectedReject: 1,
+		},
+		"must-accept-with-optional-field": {
+			config: map[string]any{
+				"expression": `fields.?user.name.orValue("") == "" && "debug" in tags`,
+			},
+			input:  make(chan *core.Event, 100),
+			accept: make(chan *core.Event, 100),
+			reject: make(chan *core.Event, 100),
+			events: []*core.Event{
+				{
+					Tags: []string{"debug"},
+					Data: map[string]any{
+						"message": "hello",
+					},
+				},
+				{
+					Tags: []string{"debug"},
+					Data: map[string]any{
+						"user": map[string]any{"name": "admin"},
+					},
+				},
+			},
+			expectedAccept: 1,
+			expectedReject: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			filter := &cel.Cel{
+				BaseFilter: &core.BaseFilter{
+					Log: logger.Mock(),
+					Obs: metrics.ObserveMock,
+				},
+			}
+			if err := mapstructure.Decode(test.config, filter); err != nil {
+				t.Fatalf("filter config not applied: %v", err)
+			}
+			if err := filter.Init(); err != nil {
+				t.Fatalf("filter not initialized: %v", err)
+			}
+
+			wg := &sync.WaitGroup{}
+			filter.SetChannels(test.input, test.reject, test.accept)
+			wg.Add(1)
+			go func() {
+				filter.Run()
+				wg.Done()
+			}()
+
+			for _, e := range test.events {
+				test.input <- e
+			}
+
+			close(test.input)
+			filter.Close()
+			wg.Wait()
+
+			if len(test.accept) != test.expectedAccept {
+				t.Fatalf("unexpected accepted messages count - want: %v, got: %v", test.expectedAccept, len(test.accept))
+			}
+
+			if len(test.reject) != test.expectedReject {
+				t.Fatalf("unexpected rejected messages count - want: %v, got: %v", test.expectedReject, len(test.reject))
+			}
+		})
+	}
+}
+
+func BenchmarkCel(b *testing.B) {
+	filter := &cel.Cel{
+		BaseFilter: &core.BaseFilter{
+			Log: logger.Mock(),
+			Obs: metrics.ObserveMock,
+		},
+		Expression: `labels["env"] == "prod" && fields.request.status >= 500`,
+	}
+	if err := filter.Init(); err != nil {
+		b.Fatalf("filter not initialized: %v", err)
+	}
+
+	benchmarkFilter(b, filter)
+}
+
+func BenchmarkStarlark(b *testing.B) {
+	filter := &starlark.Starlark{
+		Starlark: &common.Starlark{},
+		BaseFilter: &core.BaseFilter{
+			Log: logger.Mock(),
+			Obs: metrics.ObserveMock,
+		},
+	}
+	if err := mapstructure.Decode(map[string]any{
+		"code": `
+def filter(event):
+    return event.getLabel("env") == "prod" and event.getField("request.status") >= 500
+`,
+	}, filter); err != nil {
+		b.Fatalf("filter config not applied: %v", err)
+	}
+	if err := filter.Init(); err != nil {
+		b.Fatalf("filter not initialized: %v", err)
+	}
+
+	benchmarkFilter(b, filter)
+}
+
+func benchmarkFilter(b *testing.B, filter core.Filter) {
+	input := make(chan *core.Event)
+	accept := make(chan *core.Event)
+	reject := make(chan *core.Event)
+	filter.SetChannels(input, reject, accept)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-accept:
+			case <-reject:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		filter.Run()
+		wg.Done()
+	}()
+
+	e := &core.Event{
+		Labels: map[string]string{"env": "prod"},
+		Data: map[string]any{
+			"request": map[string]any{"status": 503},
+		},
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		input <- e
+	}
+	b.StopTimer()
+
+	close(input)
+	wg.Wait()
+	close(done)
+	filter.Close()
+}
@@ -4,10 +4,18 @@ import (
 	_ "github.com/gekatateam/neptunus/plugins/inputs/beats"
 	_ "github.com/gekatateam/neptunus/plugins/inputs/cronjob"
 	_ "github.com/gekatateam/neptunus/plugins/inputs/elasticsearch"
+	_ "github.com/gekatateam/neptunus/plugins/inputs/exec"
 	_ "github.com/gekatateam/neptunus/plugins/inputs/grpc"
 	_ "github.com/gekatateam/neptunus/plugins/inputs/http"
 	_ "github.com/gekatateam/neptunus/plugins/inputs/httpl"
 	_ "github.com/gekatateam/neptunus/plugins/inputs/kafka"
+	_ "github.com/gekatateam/neptunus/plugins/inputs/mqtt"
+	_ "github.com/gekatateam/neptunus/plugins/inputs/nats"
 	_ "github.com/gekatateam/neptunus/plugins/inputs/rabbitmq"
+	_ "github.com/gekatateam/neptunus/plugins/inputs/redis"
+	_ "github.com/gekatateam/neptunus/plugins/inputs/socket"
 	_ "github.com/gekatateam/neptunus/plugins/inputs/sql"
+	_ "github.com/gekatateam/neptunus/plugins/inputs/stdin"
+	_ "github.com/gekatateam/neptunus/plugins/inputs/syslog"
+	_ "github.com/gekatateam/neptunus/plugins/inputs/tail"
 )
@@ -24,6 +24,8 @@ type Elasticsearch struct {
 
 	client *elasticsearch.Client
 	cron   *cron.Cron
+	finite bool
+	stopCh chan struct{}
 }
 
 func (i *Elasticsearch) Init() error {
@@ -76,15 +78,36 @@ func (i *Elasticsearch) Init() error {
 			return fmt.Errorf("query %v scheduling failed: %w", q.Name, err)
 		}
 	}
+
+	i.stopCh = make(chan struct{})
 	return nil
 }
 
+// in finite mode, each query runs once, regardless of it's schedule,
+// and reads all matching documents page by page
+func (i *Elasticsearch) SetFinite() {
+	i.finite = true
+}
+
 func (i *Elasticsearch) Close() error {
+	close(i.stopCh)
 	i.cron.Stop()
 	return nil
 }
 
 func (i *Elasticsearch) Run() {
+	if i.finite {
+		for _, q := range i.Queries {
+			select {
+			case <-i.stopCh:
+				return
+			default:
+				q.RunPaged(i.stopCh)
+			}
+		}
+		return
+	}
+
 	i.cron.Run()
 }
 
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"time"
 
@@ -27,64 +28,247 @@ type Query struct {
 	client *elasticsearch.Client
 }
 
+// point in time is kept alive between pages requests for this time
+const pitKeepAlive = "1m"
+
 func (q *Query) Run() {
 	now := time.Now()
-	ctx := context.Background()
 
-	if q.TimeoutSeconds > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, time.Duration(q.TimeoutSeconds)*time.Second)
-		defer cancel()
+	query, err := q.body()
+	if err != nil {
+		q.Log.Error("failed to marshal query", slog.String("error", err.Error()))
+		q.Observe(metrics.EventRejected, time.Since(now))
+		return
 	}
 
-	// Convert query body to JSON
-	var query []byte
-	var err error
+	r, _, err := q.search([]string{q.Index}, query)
+	if err != nil {
+		q.Log.Error("elasticsearch query failed",
+			slog.String("error", err.Error()),
+			slog.String("query", q.Name),
+		)
+		q.Observe(metrics.EventRejected, time.Since(now))
+		return
+	}
+
+	q.produce(r, now)
+}
+
+// RunPaged reads all query hits page by page, using point in time and search_after,
+// each page is produced as a separate event; it stops when a page has no hits,
+// when any request fails, or when stopCh is closed
+//
+// if query has no sort, hits are sorted by _shard_doc, which is the most efficient order
+func (q *Query) RunPaged(stopCh <-chan struct{}) {
+	now := time.Now()
+
+	var query map[string]any
 	if q.QueryBodyRaw != "" {
-		query = []byte(q.QueryBodyRaw)
-	} else {
-		query, err = json.Marshal(q.QueryBody)
-		if err != nil {
-			q.Log.Error("failed to marshal query", slog.String("error", err.Error()))
+		if err := json.Unmarshal([]byte(q.QueryBodyRaw), &query); err != nil {
+			q.Log.Error("failed to unmarshal query", slog.String("error", err.Error()))
 			q.Observe(metrics.EventRejected, time.Since(now))
 			return
 		}
+	} else {
+		query = make(map[string]any, len(q.QueryBody)+3)
+		for k, v := range q.QueryBody {
+			query[k] = v
+		}
 	}
 
-	// Perform the search request
-	req := esapi.SearchRequest{
-		Index: []string{q.Index},
-		Body:  bytes.NewReader(query),
+	if _, ok := query["sort"]; !ok {
+		query["sort"] = []any{"_shard_doc"}
 	}
 
-	res, err := req.Do(ctx, q.client)
+	pit, err := q.openPit()
 	if err != nil {
-		q.Log.Error("elasticsearch query failed",
+		q.Log.Error("elasticsearch point in time opening failed",
 			slog.String("error", err.Error()),
 			slog.String("query", q.Name),
 		)
 		q.Observe(metrics.EventRejected, time.Since(now))
 		return
 	}
+	defer func() {
+		if err := q.closePit(pit); err != nil {
+			q.Log.Warn("elasticsearch point in time closing failed",
+				slog.String("error", err.Error()),
+				slog.String("query", q.Name),
+			)
+		}
+	}()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+
+		// search request with point in time must not contain index
+		query["pit"] = map[string]any{"id": pit, "keep_alive": pitKeepAlive}
+		body, err := json.Marshal(query)
+		if err != nil {
+			q.Log.Error("failed to marshal query", slog.String("error", err.Error()))
+			q.Observe(metrics.EventRejected, time.Since(now))
+			return
+		}
+
+		r, page, err := q.search(nil, body)
+		if err != nil {
+			q.Log.Error("elasticsearch query failed",
+				slog.String("error", err.Error()),
+				slog.String("query", q.Name),
+			)
+			q.Observe(metrics.EventRejected, time.Since(now))
+			return
+		}
+
+		if len(page.PitId) > 0 {
+			pit = page.PitId
+		}
+
+		if len(page.Hits.Hits) == 0 {
+			q.Log.Debug("elasticsearch query returns no more hits",
+				slog.String("query", q.Name),
+			)
+			return
+		}
+
+		searchAfter := page.Hits.Hits[len(page.Hits.Hits)-1].Sort
+		if len(searchAfter) == 0 {
+			q.Log.Error("elasticsearch hit has no sort values, next page can not be requested",
+				slog.String("query", q.Name),
+			)
+			q.Observe(metrics.EventRejected, time.Since(now))
+			return
+		}
+		query["search_after"] = searchAfter
+
+		q.produce(r, now)
+		now = time.Now()
+	}
+}
+
+func (q *Query) body() ([]byte, error) {
+	if q.QueryBodyRaw != "" {
+		return []byte(q.QueryBodyRaw), nil
+	}
+	return json.Marshal(q.QueryBody)
+}
+
+func (q *Query) context() (context.Context, context.CancelFunc) {
+	if q.TimeoutSeconds > 0 {
+		return context.WithTimeout(context.Background(), time.Duration(q.TimeoutSeconds)*time.Second)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// searchPage contains response fields used for pagination
+// sort values are kept raw, because they may be long numbers,
+// which lose precision if decoded as float
+type searchPage struct {
+	PitId string `json:"pit_id"`
+	Hits  struct {
+		Hits []struct {
+			Sort []json.RawMessage `json:"sort"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+func (q *Query) search(index []string, body []byte) (map[string]any, *searchPage, error) {
+	ctx, cancel := q.context()
+	defer cancel()
+
+	req := esapi.SearchRequest{
+		Index: index,
+		Body:  bytes.NewReader(body),
+	}
+
+	res, err := req.Do(ctx, q.client)
+	if err != nil {
+		return nil, nil, err
+	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		q.Log.Error("elasticsearch returned error",
-			slog.String("status", res.Status()),
-			slog.String("query", q.Name),
-		)
-		q.Observe(metrics.EventRejected, time.Since(now))
-		return
+		return nil, nil, fmt.Errorf("elasticsearch returned error: %v", res.Status())
+	}
+
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading response: %w", err)
+	}
+
+	var r map[string]any
+	if err := json.Unmarshal(raw, &r); err != nil {
+		return nil, nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	page := &searchPage{}
+	if err := json.Unmarshal(raw, page); err != nil {
+		return nil, nil, fmt.Errorf("error parsing response: %w", err)
+	}
+
+	return r, page, nil
+}
+
+func (q *Query) openPit() (string, error) {
+	ctx, cancel := q.context()
+	defer cancel()
+
+	req := esapi.OpenPointInTimeRequest{
+		Index:     []string{q.Index},
+		KeepAlive: pitKeepAlive,
 	}
 
-	// Parse the response
-	var r map[string]interface{}
+	res, err := req.Do(ctx, q.client)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return "", fmt.Errorf("elasticsearch returned error: %v", res.Status())
+	}
+
+	var r struct {
+		Id string `json:"id"`
+	}
 	if err := json.NewDecoder(res.Body).Decode(&r); err != nil {
-		q.Log.Error("error parsing response", slog.String("error", err.Error()))
-		q.Observe(metrics.EventRejected, time.Since(now))
-		return
+		return "", fmt.Errorf("error parsing response: %w", err)
 	}
 
+	return r.Id, nil
+}
+
+func (q *Query) closePit(id string) error {
+	ctx, cancel := q.context()
+	defer cancel()
+
+	body, err := json.Marshal(map[string]string{"id": id})
+	if err != nil {
+		return err
+	}
+
+	req := esapi.ClosePointInTimeRequest{
+		Body: bytes.NewReader(body),
+	}
+
+	res, err := req.Do(ctx, q.client)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("elasticsearch returned error: %v", res.Status())
+	}
+
+	return nil
+}
+
+func (q *Query) produce(r map[string]any, now time.Time) {
 	// Create event with search results
 	e := core.NewEvent(fmt.Sprintf("elasticsearch.%s", q.Name))
 	e.SetLabel("index", q.Index)
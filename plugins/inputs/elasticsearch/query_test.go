@@ -2,11 +2,21 @@ package elasticsearch
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"github.com/elastic/go-elasticsearch/v8"
-	"github.com/gekatateam/neptunus/core"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
 )
 
 func TestQuery_Run(t *testing.T) {
@@ -134,3 +144,158 @@ func TestQuery_Run(t *testing.T) {
 
 	q.Run()
 }
+
+// fakeElastic serves point in time and search requests from prepared pages
+// page is selected by search_after value, which is the sort value of the previous page last hit
+type fakeElastic struct {
+	mu         sync.Mutex
+	pages      map[string][]string // search_after -> hits sort values
+	failSearch bool
+	requests   []map[string]any
+	pitClosed  bool
+}
+
+func (f *fakeElastic) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	w.Header().Set("Content-Type", "application/json")
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/logs/_pit":
+		fmt.Fprint(w, `{"id":"pit-1"}`)
+	case r.Method == http.MethodDelete && r.URL.Path == "/_pit":
+		f.pitClosed = true
+		fmt.Fprint(w, `{"succeeded":true,"num_freed":1}`)
+	case r.URL.Path == "/_search":
+		if f.failSearch {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"error":"boom"}`)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		req := map[string]any{}
+		dec := json.NewDecoder(strings.NewReader(string(body)))
+		dec.UseNumber()
+		_ = dec.Decode(&req)
+		f.requests = append(f.requests, req)
+
+		after := ""
+		if sa, ok := req["search_after"].([]any); ok && len(sa) > 0 {
+			after = fmt.Sprint(sa[0])
+		}
+
+		hits := []string{}
+		for _, sort := range f.pages[after] {
+			hits = append(hits, fmt.Sprintf(`{"_id":"%v","sort":[%v]}`, sort, sort))
+		}
+		fmt.Fprintf(w, `{"took":1,"pit_id":"pit-2","hits":{"total":{"value":%v},"hits":[%v]}}`, len(hits), strings.Join(hits, ","))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestQuery_RunPaged(t *testing.T) {
+	tests := map[string]struct {
+		query        *Query
+		pages        map[string][]string
+		failSearch   bool
+		expectEvents []int
+		expectSort   any
+		expectFailed int
+	}{
+		"all-pages-read-with-long-sort-values": {
+			query: &Query{Index: "logs", QueryBody: map[string]any{"size": 2}},
+			pages: map[string][]string{
+				"":                    {"1700000000000000001", "1700000000000000002"},
+				"1700000000000000002": {"1700000000000000003"},
+				"1700000000000000003": {},
+			},
+			expectEvents: []int{2, 1},
+			expectSort:   []any{"_shard_doc"},
+		},
+		"raw-query-sort-kept": {
+			query: &Query{Index: "logs", QueryBodyRaw: `{"sort":[{"timestamp":"asc"}]}`},
+			pages: map[string][]string{
+				"":  {"1"},
+				"1": {},
+			},
+			expectEvents: []int{1},
+			expectSort:   []any{map[string]any{"timestamp": "asc"}},
+		},
+		"no-hits": {
+			query:        &Query{Index: "logs", QueryBody: map[string]any{}},
+			pages:        map[string][]string{},
+			expectEvents: []int{},
+		},
+		"search-failed": {
+			query:        &Query{Index: "logs", QueryBody: map[string]any{}},
+			failSearch:   true,
+			expectEvents: []int{},
+			expectFailed: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			fake := &fakeElastic{pages: test.pages, failSearch: test.failSearch}
+			server := httptest.NewServer(fake)
+			defer server.Close()
+
+			client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{server.URL}})
+			if err != nil {
+				t.Fatalf("client not created: %v", err)
+			}
+
+			var failed int
+			out := make(chan *core.Event, 10)
+			test.query.BaseInput = &core.BaseInput{
+				Log: logger.Mock(),
+				Obs: func(plugin, name, pipeline string, status metrics.EventStatus, t time.Duration) {
+					if status == metrics.EventRejected {
+						failed++
+					}
+				},
+				Out: out,
+			}
+			test.query.client = client
+
+			test.query.RunPaged(make(chan struct{}))
+			close(out)
+
+			var events []int
+			for e := range out {
+				hits, _ := e.Data.(map[string]any)["hits"].([]any)
+				events = append(events, len(hits))
+			}
+
+			if fmt.Sprint(events) != fmt.Sprint(test.expectEvents) {
+				t.Fatalf("unexpected events hits, want: %v, got: %v", test.expectEvents, events)
+			}
+
+			if failed != test.expectFailed {
+				t.Fatalf("unexpected failures, want: %v, got: %v", test.expectFailed, failed)
+			}
+
+			if !fake.pitClosed {
+				t.Fatal("point in time not closed")
+			}
+
+			for i, req := range fake.requests {
+				if pit := req["pit"].(map[string]any)["id"]; (i == 0 && pit != "pit-1") || (i > 0 && pit != "pit-2") {
+					t.Fatalf("request %v: unexpected pit id: %v", i, pit)
+				}
+
+				if test.expectSort != nil && fmt.Sprint(req["sort"]) != fmt.Sprint(test.expectSort) {
+					t.Fatalf("request %v: unexpected sort, want: %v, got: %v", i, test.expectSort, req["sort"])
+				}
+			}
+
+			if len(test.pages) > 0 && len(fake.requests) != len(test.pages) {
+				t.Fatalf("unexpected search requests, want: %v, got: %v", len(test.pages), len(fake.requests))
+			}
+		})
+	}
+}
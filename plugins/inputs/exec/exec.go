@@ -0,0 +1,340 @@
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/robfig/cron/v3"
+	"kythe.io/kythe/go/util/datasize"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+	"github.com/gekatateam/neptunus/plugins/common/ider"
+)
+
+type Exec struct {
+	*core.BaseInput `mapstructure:"-"`
+	Command         string        `mapstructure:"command"`
+	Args            []string      `mapstructure:"args"`
+	Envs            []string      `mapstructure:"envs"`
+	Dir             string        `mapstructure:"dir"`
+	Mode            string        `mapstructure:"mode"`
+	Schedule        string        `mapstructure:"schedule"`
+	Location        string        `mapstructure:"location"`
+	Timeout         time.Duration `mapstructure:"timeout"`
+	StderrTo        string        `mapstructure:"stderr_to"`
+	RestartDelay    time.Duration `mapstructure:"restart_delay"`
+	MaxLineSize     datasize.Size `mapstructure:"max_line_size"`
+	*ider.Ider      `mapstructure:",squash"`
+
+	routingKey string
+	cron       *cron.Cron
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	parser core.Parser
+}
+
+func (i *Exec) Init() error {
+	if len(i.Command) == 0 {
+		return errors.New("command required")
+	}
+
+	if err := i.Ider.Init(); err != nil {
+		return err
+	}
+
+	i.routingKey = "exec." + filepath.Base(i.Command)
+	i.ctx, i.cancel = context.WithCancel(context.Background())
+
+	switch i.Mode {
+	case "schedule":
+		if len(i.Schedule) == 0 {
+			return errors.New("schedule required in schedule mode")
+		}
+
+		if i.Timeout <= 0 {
+			return errors.New("timeout must be greater than zero in schedule mode")
+		}
+
+		loc, err := time.LoadLocation(i.Location)
+		if err != nil {
+			return err
+		}
+
+		i.cron = cron.New(
+			cron.WithLocation(loc),
+			cron.WithSeconds(),
+			cron.WithLogger(cron.DiscardLogger),
+			cron.WithChain(cron.SkipIfStillRunning(cron.DiscardLogger)),
+		)
+
+		if _, err := i.cron.AddFunc(i.Schedule, i.execute); err != nil {
+			return fmt.Errorf("command scheduling failed: %w", err)
+		}
+	case "stream":
+		if i.MaxLineSize <= 0 {
+			return errors.New("max_line_size must be greater than zero")
+		}
+	default:
+		return fmt.Errorf("unknown mode: %v, expected one of: schedule, stream", i.Mode)
+	}
+
+	return nil
+}
+
+func (i *Exec) SetParser(p core.Parser) {
+	i.parser = p
+}
+
+func (i *Exec) Close() error {
+	i.cancel()
+
+	if err := i.parser.Close(); err != nil {
+		i.Log.Error("parser closed with error",
+			"error", err.Error(),
+		)
+	}
+
+	return nil
+}
+
+func (i *Exec) Run() {
+	switch i.Mode {
+	case "schedule":
+		stopped := make(chan context.Context, 1)
+		go func() {
+			<-i.ctx.Done()
+			stopped <- i.cron.Stop()
+		}()
+
+		i.Log.Info(fmt.Sprintf("command %v scheduled as %v", i.Command, i.Schedule))
+		i.cron.Run()
+		// cron.Run() does not wait for running jobs
+		<-(<-stopped).Done()
+	case "stream":
+		i.stream()
+	}
+}
+
+// execute runs command once and produces events from it's stdout
+func (i *Exec) execute() {
+	if i.ctx.Err() != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(i.ctx, i.Timeout)
+	defer cancel()
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd := i.command(ctx)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	now := time.Now()
+	exitCode, err := exitCode(cmd.Run())
+	duration := time.Since(now)
+	if err != nil {
+		i.Log.Error("command execution failed",
+			"error", err,
+		)
+		i.Observe(metrics.EventFailed, time.Since(now))
+		return
+	}
+
+	i.Log.Debug(fmt.Sprintf("command completed with exit code %v in %v", exitCode, duration))
+
+	var events []*core.Event
+	if stdout.Len() > 0 {
+		events, err = i.parser.Parse(stdout.Bytes(), i.routingKey)
+		if err != nil {
+			i.Log.Error("parser error",
+				"error", err,
+			)
+			i.Observe(metrics.EventFailed, time.Since(now))
+			return
+		}
+	}
+
+	// command without output still reports it's status
+	if len(events) == 0 {
+		events = append(events, core.NewEvent(i.routingKey))
+	}
+
+	for _, e := range events {
+		e.SetLabel("exit_code", strconv.Itoa(exitCode))
+		e.SetLabel("duration", duration.String())
+
+		if len(i.StderrTo) > 0 {
+			if err := e.SetField(i.StderrTo, stderr.String()); err != nil {
+				i.Log.Warn("stderr saving failed",
+					"error", err,
+					slog.Group("event",
+						"id", e.Id,
+						"key", e.RoutingKey,
+					),
+				)
+			}
+		}
+
+		i.produce(e, now)
+		now = time.Now()
+	}
+}
+
+// stream runs long-running command and produces events from each line of it's stdout
+// if command exits, it is restarted after delay
+func (i *Exec) stream() {
+	for {
+		i.Log.Info(fmt.Sprintf("starting command %v", i.Command))
+		i.streamOnce()
+
+		select {
+		case <-i.ctx.Done():
+			i.Log.Info(fmt.Sprintf("command %v stopped", i.Command))
+			return
+		case <-time.After(i.RestartDelay):
+		}
+	}
+}
+
+func (i *Exec) streamOnce() {
+	cmd := i.command(i.ctx)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		i.Log.Error("stdout pipe creation failed",
+			"error", err,
+		)
+		return
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		i.Log.Error("stderr pipe creation failed",
+			"error", err,
+		)
+		return
+	}
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		i.Log.Error("command starting failed",
+			"error", err,
+		)
+		return
+	}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			i.Log.Warn("command stderr",
+				"line", scanner.Text(),
+			)
+		}
+	}()
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 4096), int(i.MaxLineSize.Bytes()))
+	for scanner.Scan() {
+		now := time.Now()
+		events, err := i.parser.Parse(scanner.Bytes(), i.routingKey)
+		if err != nil {
+			i.Log.Error("parser error",
+				"error", err,
+			)
+			i.Observe(metrics.EventFailed, time.Since(now))
+			continue
+		}
+
+		for _, e := range events {
+			i.produce(e, now)
+			now = time.Now()
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		i.Log.Error("stdout reading failed, command will be killed",
+			"error", err,
+		)
+		cmd.Process.Kill()
+	}
+
+	wg.Wait()
+	exitCode, err := exitCode(cmd.Wait())
+	if err != nil {
+		i.Log.Error("command execution failed",
+			"error", err,
+		)
+		return
+	}
+
+	if i.ctx.Err() == nil {
+		i.Log.Warn(fmt.Sprintf("command exited with code %v after %v", exitCode, time.Since(start)))
+	}
+}
+
+func (i *Exec) command(ctx context.Context) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, i.Command, i.Args...)
+	cmd.Dir = i.Dir
+	cmd.Env = append(cmd.Env, os.Environ()...)
+	cmd.Env = append(cmd.Env, i.Envs...)
+	return cmd
+}
+
+func (i *Exec) produce(e *core.Event, now time.Time) {
+	i.Ider.Apply(e)
+	i.Out <- e
+	i.Log.Debug("event accepted",
+		slog.Group("event",
+			"id", e.Id,
+			"key", e.RoutingKey,
+		),
+	)
+	i.Observe(metrics.EventAccepted, time.Since(now))
+}
+
+// exitCode extracts exit code from command error
+// non-zero exit is not an error, unlike failed start or kill by signal
+func exitCode(err error) (int, error) {
+	if err == nil {
+		return 0, nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+		return exitErr.ExitCode(), nil
+	}
+
+	return -1, err
+}
+
+func init() {
+	plugins.AddInput("exec", func() core.Input {
+		return &Exec{
+			Mode:         "schedule",
+			Location:     "UTC",
+			Timeout:      10 * time.Second,
+			StderrTo:     "stderr",
+			RestartDelay: 5 * time.Second,
+			MaxLineSize:  datasize.Mebibyte,
+			Ider:         &ider.Ider{},
+		}
+	})
+}
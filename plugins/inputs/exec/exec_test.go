@@ -0,0 +1,233 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins/common/ider"
+)
+
+// mockParser produces event for each line of data
+type mockParser struct{}
+
+func (m *mockParser) Parse(data []byte, routingKey string) ([]*core.Event, error) {
+	var events []*core.Event
+	for _, line := range bytes.Split(bytes.TrimSpace(data), []byte("\n")) {
+		events = append(events, core.NewEventWithData(routingKey, map[string]any{"line": string(line)}))
+	}
+	return events, nil
+}
+
+func (m *mockParser) Close() error {
+	return nil
+}
+
+func (m *mockParser) Init() error {
+	return nil
+}
+
+func TestInit(t *testing.T) {
+	tests := map[string]struct {
+		input *Exec
+		err   string
+	}{
+		"no-command": {
+			input: &Exec{Mode: "schedule", Schedule: "* * * * * *", Timeout: time.Second},
+			err:   "command required",
+		},
+		"no-schedule": {
+			input: &Exec{Command: "/bin/sh", Mode: "schedule", Timeout: time.Second},
+			err:   "schedule required in schedule mode",
+		},
+		"zero-timeout": {
+			input: &Exec{Command: "/bin/sh", Mode: "schedule", Schedule: "* * * * * *"},
+			err:   "timeout must be greater than zero in schedule mode",
+		},
+		"negative-timeout": {
+			input: &Exec{Command: "/bin/sh", Mode: "schedule", Schedule: "* * * * * *", Timeout: -time.Second},
+			err:   "timeout must be greater than zero in schedule mode",
+		},
+		"zero-line-size": {
+			input: &Exec{Command: "/bin/sh", Mode: "stream"},
+			err:   "max_line_size must be greater than zero",
+		},
+		"unknown-mode": {
+			input: &Exec{Command: "/bin/sh", Mode: "daemon"},
+			err:   "unknown mode: daemon",
+		},
+		"schedule-mode": {
+			input: &Exec{Command: "/bin/sh", Mode: "schedule", Schedule: "* * * * * *", Location: "UTC", Timeout: time.Second},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.input.Ider = &ider.Ider{}
+
+			err := test.input.Init()
+			if len(test.err) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Fatalf("unexpected error, want: %v, got: %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestExecute(t *testing.T) {
+	tests := map[string]struct {
+		script       string
+		stderrTo     string
+		timeout      time.Duration
+		expectLines  []string
+		expectCode   string
+		expectStderr any
+		expectFailed bool
+	}{
+		"stdout-lines": {
+			script:       "echo first; echo second",
+			stderrTo:     "stderr",
+			expectLines:  []string{"first", "second"},
+			expectCode:   "0",
+			expectStderr: "",
+		},
+		"non-zero-exit-with-stderr": {
+			script:       "echo out; echo err >&2; exit 3",
+			stderrTo:     "stderr",
+			expectLines:  []string{"out"},
+			expectCode:   "3",
+			expectStderr: "err\n",
+		},
+		"stderr-not-saved": {
+			script:       "echo out; echo err >&2",
+			expectLines:  []string{"out"},
+			expectCode:   "0",
+			expectStderr: nil,
+		},
+		"empty-output": {
+			script:       "echo err >&2; exit 1",
+			stderrTo:     "result.stderr",
+			expectLines:  []string{""},
+			expectCode:   "1",
+			expectStderr: "err\n",
+		},
+		"killed-by-timeout": {
+			script:       "exec sleep 5",
+			timeout:      100 * time.Millisecond,
+			expectFailed: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var failed bool
+			out := make(chan *core.Event, 10)
+			input := &Exec{
+				BaseInput: &core.BaseInput{
+					Log: logger.Mock(),
+					Obs: func(plugin, name, pipeline string, status metrics.EventStatus, t time.Duration) {
+						if status == metrics.EventFailed {
+							failed = true
+						}
+					},
+					Out: out,
+				},
+				Command:  "/bin/sh",
+				Args:     []string{"-c", test.script},
+				Mode:     "schedule",
+				Schedule: "* * * * * *",
+				Location: "UTC",
+				Timeout:  time.Second,
+				StderrTo: test.stderrTo,
+				Ider:     &ider.Ider{},
+				parser:   &mockParser{},
+			}
+
+			if test.timeout > 0 {
+				input.Timeout = test.timeout
+			}
+
+			if err := input.Init(); err != nil {
+				t.Fatalf("input not initialized: %v", err)
+			}
+			defer input.cancel()
+
+			input.execute()
+			close(out)
+
+			if failed != test.expectFailed {
+				t.Fatalf("unexpected failure, want: %v, got: %v", test.expectFailed, failed)
+			}
+
+			var lines []string
+			for e := range out {
+				if e.RoutingKey != "exec.sh" {
+					t.Fatalf("unexpected routing key, want: exec.sh, got: %v", e.RoutingKey)
+				}
+
+				labels := maps.Clone(e.Labels)
+				if _, ok := labels["duration"]; !ok {
+					t.Fatal("duration label not set")
+				}
+				delete(labels, "duration")
+
+				if want := map[string]string{"exit_code": test.expectCode}; !maps.Equal(labels, want) {
+					t.Fatalf("unexpected labels, want: %v, got: %v", want, labels)
+				}
+
+				var stderr any
+				if len(test.stderrTo) > 0 {
+					stderr, _ = e.GetField(test.stderrTo)
+				}
+				if stderr != test.expectStderr {
+					t.Fatalf("unexpected stderr, want: %q, got: %q", test.expectStderr, stderr)
+				}
+
+				line, _ := e.GetField("line")
+				if line == nil {
+					line = ""
+				}
+				lines = append(lines, line.(string))
+			}
+
+			if !slices.Equal(lines, test.expectLines) {
+				t.Fatalf("unexpected events, want: %v, got: %v", test.expectLines, lines)
+			}
+		})
+	}
+}
+
+func TestExecute_Stopped(t *testing.T) {
+	out := make(chan *core.Event, 1)
+	input := &Exec{
+		BaseInput: &core.BaseInput{
+			Log: logger.Mock(),
+			Obs: metrics.ObserveMock,
+			Out: out,
+		},
+		Command: "/bin/sh",
+		Args:    []string{"-c", "echo out"},
+		Timeout: time.Second,
+		parser:  &mockParser{},
+	}
+	input.ctx, input.cancel = context.WithCancel(context.Background())
+	input.cancel()
+
+	input.execute()
+	if len(out) > 0 {
+		t.Fatalf("unexpected events after stop: %v", len(out))
+	}
+}
@@ -0,0 +1,142 @@
+package mqtt
+
+import (
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gekatateam/neptunus/metrics"
+	common "github.com/gekatateam/neptunus/plugins/common/mqtt"
+)
+
+type trackedMessage struct {
+	*common.Message
+	events int
+}
+
+func (i *Mqtt) consume(msg *common.Message) {
+	now := time.Now()
+
+	i.Log.Debug("message consumed",
+		msgLogAttrs(msg)...,
+	)
+
+	// MQTT requires ACKs in the order in which messages were received
+	// so each message goes through ACK queue, even if it has no events
+	tracked := &trackedMessage{
+		Message: msg,
+	}
+
+	events, err := i.parser.Parse(msg.Payload, msg.Topic)
+	if err != nil {
+		i.Log.Error("parser error, message skipped",
+			msgLogAttrs(msg, "error", err)...,
+		)
+
+		i.ackSemaphore <- struct{}{}
+		i.fetchCh <- tracked
+		i.Observe(metrics.EventFailed, time.Since(now))
+		return
+	}
+
+	tracked.events = len(events)
+	i.ackSemaphore <- struct{}{}
+	i.fetchCh <- tracked
+
+	if len(events) == 0 {
+		i.Log.Debug("parser returns zero events, message acked",
+			msgLogAttrs(msg)...,
+		)
+		i.Observe(metrics.EventAccepted, time.Since(now))
+		return
+	}
+
+	for _, e := range events {
+		e.SetLabel("topic", msg.Topic)
+		e.SetLabel("qos", strconv.Itoa(int(msg.Qos)))
+		e.SetLabel("retained", strconv.FormatBool(msg.Retained))
+		e.SetLabel("duplicate", strconv.FormatBool(msg.Duplicate))
+		if len(msg.Subscription) > 0 {
+			e.SetLabel("subscription", msg.Subscription)
+		}
+		for label, property := range i.LabelProperties {
+			if p, ok := msg.Property(property); ok {
+				e.SetLabel(label, p)
+			}
+		}
+
+		e.AddHook(func() {
+			i.ackCh <- tracked
+		})
+
+		i.Ider.Apply(e)
+		i.Out <- e
+		i.Log.Debug("event accepted",
+			slog.Group("event",
+				"id", e.Id,
+				"key", e.RoutingKey,
+			),
+		)
+		i.Observe(metrics.EventAccepted, time.Since(now))
+		now = time.Now()
+	}
+}
+
+type acker struct {
+	queue []*trackedMessage
+
+	ackSemaphore chan struct{}
+	fetchCh      chan *trackedMessage
+	ackCh        chan *trackedMessage
+	doneCh       chan struct{}
+
+	log *slog.Logger
+}
+
+func (a *acker) Run(exitCh <-chan struct{}) {
+	exitIfQueueEmpty := false
+
+	for {
+		select {
+		case msg := <-a.fetchCh: // message consumed
+			a.queue = append(a.queue, msg)
+		case msg := <-a.ackCh: // an event delivered
+			msg.events--
+		case <-exitCh: // consumer exited
+			a.log.Info("left in ack queue: " + strconv.Itoa(len(a.queue)))
+			exitIfQueueEmpty = true
+			exitCh = nil
+		}
+
+		// ACK all delivered messages from the head of the queue
+		for len(a.queue) > 0 && a.queue[0].events == 0 {
+			msg := a.queue[0]
+			if err := msg.Ack(); err != nil {
+				a.log.Error("message ack failed",
+					msgLogAttrs(msg.Message, "error", err)...,
+				)
+			} else {
+				a.log.Debug("message acked",
+					msgLogAttrs(msg.Message)...,
+				)
+			}
+
+			a.queue[0] = nil
+			a.queue = a.queue[1:]
+			_ = <-a.ackSemaphore //lint:ignore S1005 explicitly indicates reading from the channel, not waiting
+		}
+
+		if exitIfQueueEmpty && len(a.queue) == 0 {
+			close(a.doneCh)
+			return
+		}
+	}
+}
+
+func msgLogAttrs(msg *common.Message, with ...any) []any {
+	return append(with,
+		"topic", msg.Topic,
+		"message_id", strconv.FormatUint(uint64(msg.MessageId), 10),
+		"qos", strconv.Itoa(int(msg.Qos)),
+	)
+}
@@ -0,0 +1,197 @@
+package mqtt
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins/common/ider"
+	common "github.com/gekatateam/neptunus/plugins/common/mqtt"
+)
+
+type mockParser struct{}
+
+func (m *mockParser) Parse(data []byte, routingKey string) ([]*core.Event, error) {
+	return []*core.Event{core.NewEventWithData(routingKey, map[string]any{"data": string(data)})}, nil
+}
+
+func (m *mockParser) Close() error {
+	return nil
+}
+
+func (m *mockParser) Init() error {
+	return nil
+}
+
+func testMessage(id uint16, acked *[]uint16, mu *sync.Mutex) *common.Message {
+	return &common.Message{
+		Topic:     "test",
+		Qos:       1,
+		MessageId: id,
+		Ack: func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			*acked = append(*acked, id)
+			return nil
+		},
+	}
+}
+
+func TestAcker(t *testing.T) {
+	tests := map[string]struct {
+		events  []int
+		deliver []int
+		want    []uint16
+	}{
+		"in-order-delivery": {
+			events:  []int{1, 1, 1},
+			deliver: []int{0, 1, 2},
+			want:    []uint16{0, 1, 2},
+		},
+		"reverse-delivery": {
+			events:  []int{1, 1, 1},
+			deliver: []int{2, 1, 0},
+			want:    []uint16{0, 1, 2},
+		},
+		"zero-events-wait-for-head": {
+			events:  []int{2, 0, 1},
+			deliver: []int{2, 0, 0},
+			want:    []uint16{0, 1, 2},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var (
+				acked  []uint16
+				mu     = &sync.Mutex{}
+				exitCh = make(chan struct{})
+			)
+
+			a := &acker{
+				ackSemaphore: make(chan struct{}, len(test.events)),
+				fetchCh:      make(chan *trackedMessage),
+				ackCh:        make(chan *trackedMessage),
+				doneCh:       make(chan struct{}),
+				log:          logger.Mock(),
+			}
+			go a.Run(exitCh)
+
+			msgs := make([]*trackedMessage, 0, len(test.events))
+			for i, events := range test.events {
+				msg := &trackedMessage{
+					Message: testMessage(uint16(i), &acked, mu),
+					events:  events,
+				}
+				msgs = append(msgs, msg)
+				a.ackSemaphore <- struct{}{}
+				a.fetchCh <- msg
+			}
+
+			for _, i := range test.deliver {
+				a.ackCh <- msgs[i]
+			}
+
+			close(exitCh)
+			<-a.doneCh
+
+			if !slices.Equal(acked, test.want) {
+				t.Fatalf("unexpected ack order, want: %v, got: %v", test.want, acked)
+			}
+
+			if len(a.ackSemaphore) != 0 {
+				t.Fatalf("ack semaphore is not empty: %v", len(a.ackSemaphore))
+			}
+		})
+	}
+}
+
+func TestConsume(t *testing.T) {
+	tests := map[string]struct {
+		msg             *common.Message
+		labelProperties map[string]string
+		expectLabels    map[string]string
+	}{
+		"mqtt-3-message": {
+			msg: &common.Message{
+				Topic:        "sensors/1/telemetry",
+				Subscription: "sensors/+/telemetry",
+				Payload:      []byte("21.5"),
+				Qos:          1,
+				Duplicate:    true,
+			},
+			labelProperties: map[string]string{"device": "device-id"},
+			expectLabels: map[string]string{
+				"topic":        "sensors/1/telemetry",
+				"subscription": "sensors/+/telemetry",
+				"qos":          "1",
+				"retained":     "false",
+				"duplicate":    "true",
+			},
+		},
+		"user-properties-to-labels": {
+			msg: &common.Message{
+				Topic:    "sensors/1/telemetry",
+				Payload:  []byte("21.5"),
+				Qos:      2,
+				Retained: true,
+				Properties: []common.Property{
+					{Key: "device-id", Value: "first"},
+					{Key: "device-id", Value: "second"},
+					{Key: "unit", Value: "celsius"},
+					{Key: "unused", Value: "unused"},
+				},
+			},
+			labelProperties: map[string]string{
+				"device":  "device-id",
+				"unit":    "unit",
+				"missing": "missing",
+			},
+			expectLabels: map[string]string{
+				"topic":     "sensors/1/telemetry",
+				"qos":       "2",
+				"retained":  "true",
+				"duplicate": "false",
+				"device":    "first",
+				"unit":      "celsius",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			out := make(chan *core.Event, 1)
+			input := &Mqtt{
+				BaseInput: &core.BaseInput{
+					Log: logger.Mock(),
+					Obs: metrics.ObserveMock,
+					Out: out,
+				},
+				LabelProperties: test.labelProperties,
+				Ider:            &ider.Ider{},
+				ackSemaphore:    make(chan struct{}, 1),
+				fetchCh:         make(chan *trackedMessage, 1),
+				parser:          &mockParser{},
+			}
+
+			input.consume(test.msg)
+
+			e := <-out
+			if e.RoutingKey != test.msg.Topic {
+				t.Fatalf("unexpected routing key, want: %v, got: %v", test.msg.Topic, e.RoutingKey)
+			}
+
+			if !maps.Equal(e.Labels, test.expectLabels) {
+				t.Fatalf("unexpected labels, want: %v, got: %v", test.expectLabels, e.Labels)
+			}
+
+			if tracked := <-input.fetchCh; tracked.events != 1 {
+				t.Fatalf("unexpected tracked events, want: 1, got: %v", tracked.events)
+			}
+		})
+	}
+}
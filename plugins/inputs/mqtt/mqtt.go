@@ -0,0 +1,139 @@
+package mqtt
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/plugins"
+	"github.com/gekatateam/neptunus/plugins/common/ider"
+	common "github.com/gekatateam/neptunus/plugins/common/mqtt"
+	pkgtls "github.com/gekatateam/neptunus/plugins/common/tls"
+)
+
+type Mqtt struct {
+	*core.BaseInput `mapstructure:"-"`
+	Topics          []string          `mapstructure:"topics"`
+	Qos             int               `mapstructure:"qos"`
+	MaxUndelivered  int               `mapstructure:"max_undelivered"`
+	LabelProperties map[string]string `mapstructure:"labelproperties"`
+
+	*common.Client `mapstructure:",squash"`
+	*ider.Ider     `mapstructure:",squash"`
+
+	conn common.Conn
+
+	ackSemaphore chan struct{}
+	msgCh        chan *common.Message
+	fetchCh      chan *trackedMessage
+	ackCh        chan *trackedMessage
+	exitCh       chan struct{}
+	doneCh       chan struct{}
+
+	parser core.Parser
+}
+
+func (i *Mqtt) Init() error {
+	if len(i.Topics) == 0 {
+		return errors.New("at least one topic required")
+	}
+
+	if i.Qos < 0 || i.Qos > 2 {
+		return fmt.Errorf("unknown QoS: %v; expected one of: 0, 1, 2", i.Qos)
+	}
+
+	if i.MaxUndelivered < 1 {
+		i.MaxUndelivered = 1
+	}
+
+	if err := i.Ider.Init(); err != nil {
+		return err
+	}
+
+	i.ackSemaphore = make(chan struct{}, i.MaxUndelivered)
+	i.msgCh = make(chan *common.Message)
+	i.fetchCh = make(chan *trackedMessage)
+	i.ackCh = make(chan *trackedMessage)
+	i.exitCh = make(chan struct{})
+	i.doneCh = make(chan struct{})
+
+	// messages are ACKed when all of it's events are delivered
+	conn, err := i.Client.Connect(i.Log, &common.Subscription{
+		Topics:  i.Topics,
+		Qos:     byte(i.Qos),
+		Handler: i.handle,
+	})
+	if err != nil {
+		return err
+	}
+
+	i.conn = conn
+	return nil
+}
+
+func (i *Mqtt) SetParser(p core.Parser) {
+	i.parser = p
+}
+
+func (i *Mqtt) Close() error {
+	close(i.exitCh)
+	<-i.doneCh
+
+	i.conn.Disconnect(i.ConnectTimeout)
+	i.parser.Close()
+	return nil
+}
+
+func (i *Mqtt) Run() {
+	acker := &acker{
+		ackSemaphore: i.ackSemaphore,
+		fetchCh:      i.fetchCh,
+		ackCh:        i.ackCh,
+		doneCh:       i.doneCh,
+		log:          i.Log,
+	}
+
+	exitCh := make(chan struct{})
+	go acker.Run(exitCh)
+
+CONSUME_LOOP:
+	for {
+		select {
+		case msg := <-i.msgCh:
+			i.consume(msg)
+		case <-i.exitCh:
+			break CONSUME_LOOP
+		}
+	}
+
+	i.Log.Info("consumer done, waiting for events delivery")
+	close(exitCh)
+}
+
+func (i *Mqtt) handle(msg *common.Message) {
+	select {
+	case i.msgCh <- msg:
+	case <-i.exitCh: // message will be redelivered in persistent session
+	}
+}
+
+func init() {
+	plugins.AddInput("mqtt", func() core.Input {
+		return &Mqtt{
+			Qos:            1,
+			MaxUndelivered: 100,
+			Client: &common.Client{
+				Brokers:              []string{"tcp://localhost:1883"},
+				ClientId:             "neptunus.mqtt.input",
+				ProtocolVersion:      "3.1.1",
+				CleanSession:         true,
+				KeepAlive:            30 * time.Second,
+				ConnectTimeout:       10 * time.Second,
+				MaxReconnectInterval: time.Minute,
+				TLSClientConfig:      &pkgtls.TLSClientConfig{},
+			},
+			Ider: &ider.Ider{},
+		}
+	})
+}
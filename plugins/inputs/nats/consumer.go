@@ -0,0 +1,175 @@
+package nats
+
+import (
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+)
+
+func (i *Nats) consumeCore(msg *nats.Msg) {
+	now := time.Now()
+
+	i.Log.Debug("message consumed",
+		"subject", msg.Subject,
+	)
+
+	events, err := i.parser.Parse(msg.Data, msg.Subject)
+	if err != nil {
+		i.Log.Error("parser error, message skipped",
+			"error", err,
+			"subject", msg.Subject,
+		)
+		i.Observe(metrics.EventFailed, time.Since(now))
+		return
+	}
+
+	for _, e := range events {
+		e.SetLabel("subject", msg.Subject)
+		i.labelHeaders(e, msg.Header)
+
+		if i.KeepMessageId {
+			if id := msg.Header.Get(jetstream.MsgIDHeader); len(id) > 0 {
+				e.Id = id
+			}
+		}
+
+		i.Ider.Apply(e)
+		i.Out <- e
+		i.Log.Debug("event accepted",
+			slog.Group("event",
+				"id", e.Id,
+				"key", e.RoutingKey,
+			),
+		)
+		i.Observe(metrics.EventAccepted, time.Since(now))
+		now = time.Now()
+	}
+}
+
+// each JetStream message is ACKed when all of it's events are delivered
+// if parser returns zero events, message is ACKed immediately
+// if parsing failed, message is terminated and will not be redelivered
+func (i *Nats) consumeJetStream(msg jetstream.Msg, wg *sync.WaitGroup, sem chan struct{}) {
+	now := time.Now()
+
+	meta, err := msg.Metadata()
+	if err != nil {
+		i.Log.Error("message metadata reading failed, message skipped",
+			"error", err,
+			"subject", msg.Subject(),
+		)
+		i.Observe(metrics.EventFailed, time.Since(now))
+		return
+	}
+
+	i.Log.Debug("message consumed",
+		msgLogAttrs(msg.Subject(), meta)...,
+	)
+
+	events, err := i.parser.Parse(msg.Data(), msg.Subject())
+	if err != nil {
+		i.Log.Error("parser error, message terminated",
+			msgLogAttrs(msg.Subject(), meta, "error", err)...,
+		)
+
+		if err := msg.Term(); err != nil {
+			i.Log.Error("message termination failed",
+				msgLogAttrs(msg.Subject(), meta, "error", err)...,
+			)
+		}
+		i.Observe(metrics.EventFailed, time.Since(now))
+		return
+	}
+
+	if len(events) == 0 {
+		i.Log.Debug("parser returns zero events, message acked",
+			msgLogAttrs(msg.Subject(), meta)...,
+		)
+
+		i.ack(msg, meta)
+		i.Observe(metrics.EventAccepted, time.Since(now))
+		return
+	}
+
+	sem <- struct{}{}
+	wg.Add(1)
+	undelivered := &atomic.Int32{}
+	undelivered.Store(int32(len(events)))
+
+	for _, e := range events {
+		e.SetLabel("subject", msg.Subject())
+		e.SetLabel("stream", meta.Stream)
+		e.SetLabel("consumer", meta.Consumer)
+		e.SetLabel("sequence", strconv.FormatUint(meta.Sequence.Stream, 10))
+		e.SetLabel("delivered", strconv.FormatUint(meta.NumDelivered, 10))
+		i.labelHeaders(e, msg.Headers())
+
+		if i.KeepTimestamp {
+			e.Timestamp = meta.Timestamp
+		}
+
+		if i.KeepMessageId {
+			if id := msg.Headers().Get(jetstream.MsgIDHeader); len(id) > 0 {
+				e.Id = id
+			}
+		}
+
+		e.AddHook(func() {
+			if undelivered.Add(-1) == 0 {
+				i.ack(msg, meta)
+				<-sem
+				wg.Done()
+			}
+		})
+
+		i.Ider.Apply(e)
+		i.Out <- e
+		i.Log.Debug("event accepted",
+			slog.Group("event",
+				"id", e.Id,
+				"key", e.RoutingKey,
+			),
+		)
+		i.Observe(metrics.EventAccepted, time.Since(now))
+		now = time.Now()
+	}
+}
+
+func (i *Nats) ack(msg jetstream.Msg, meta *jetstream.MsgMetadata) {
+	if err := msg.Ack(); err != nil {
+		i.Log.Error("message ack failed",
+			msgLogAttrs(msg.Subject(), meta, "error", err)...,
+		)
+		return
+	}
+
+	i.Log.Debug("message acked",
+		msgLogAttrs(msg.Subject(), meta)...,
+	)
+}
+
+func (i *Nats) labelHeaders(e *core.Event, headers nats.Header) {
+	for label, header := range i.LabelHeaders {
+		if h := headers.Get(header); len(h) > 0 {
+			e.SetLabel(label, h)
+		}
+	}
+}
+
+func msgLogAttrs(subject string, meta *jetstream.MsgMetadata, with ...any) []any {
+	return append(with,
+		"subject", subject,
+		"stream", meta.Stream,
+		"consumer", meta.Consumer,
+		"sequence", strconv.FormatUint(meta.Sequence.Stream, 10),
+		"delivered", strconv.FormatUint(meta.NumDelivered, 10),
+	)
+}
@@ -0,0 +1,170 @@
+package nats
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins/common/ider"
+)
+
+// mockParser produces configured number of events, or fails if count is negative
+type mockParser struct {
+	events int
+}
+
+func (m *mockParser) Parse(data []byte, routingKey string) ([]*core.Event, error) {
+	if m.events < 0 {
+		return nil, errors.New("parsing failed")
+	}
+
+	events := make([]*core.Event, 0, m.events)
+	for range m.events {
+		events = append(events, core.NewEventWithData(routingKey, string(data)))
+	}
+	return events, nil
+}
+
+func (m *mockParser) Close() error {
+	return nil
+}
+
+func (m *mockParser) Init() error {
+	return nil
+}
+
+// only methods used by consumer are implemented
+type mockMsg struct {
+	jetstream.Msg
+	mu     sync.Mutex
+	acked  int
+	termed int
+}
+
+func (m *mockMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{
+		Sequence:     jetstream.SequencePair{Stream: 42},
+		NumDelivered: 1,
+		Stream:       "events",
+		Consumer:     "neptunus",
+		Timestamp:    time.Now(),
+	}, nil
+}
+
+func (m *mockMsg) Data() []byte         { return []byte("data") }
+func (m *mockMsg) Headers() nats.Header { return nats.Header{} }
+func (m *mockMsg) Subject() string      { return "events.test" }
+
+func (m *mockMsg) Ack() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.acked++
+	return nil
+}
+
+func (m *mockMsg) Term() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.termed++
+	return nil
+}
+
+func TestConsumeJetStream(t *testing.T) {
+	tests := map[string]struct {
+		events      int
+		deliver     []int
+		expectAcks  []int // acks count after each delivery
+		expectTerms int
+	}{
+		"single-event": {
+			events:     1,
+			deliver:    []int{0},
+			expectAcks: []int{1},
+		},
+		"acked-on-last-hook": {
+			events:     3,
+			deliver:    []int{2, 0, 1},
+			expectAcks: []int{0, 0, 1},
+		},
+		"zero-events-acked-immediately": {
+			events: 0,
+		},
+		"parser-error-terminated": {
+			events:      -1,
+			expectTerms: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			out := make(chan *core.Event, max(test.events, 1))
+			input := &Nats{
+				BaseInput: &core.BaseInput{
+					Log: logger.Mock(),
+					Obs: metrics.ObserveMock,
+					Out: out,
+				},
+				Ider:   &ider.Ider{},
+				parser: &mockParser{events: test.events},
+			}
+
+			msg := &mockMsg{}
+			wg := &sync.WaitGroup{}
+			sem := make(chan struct{}, 1)
+
+			input.consumeJetStream(msg, wg, sem)
+			close(out)
+
+			if msg.termed != test.expectTerms {
+				t.Fatalf("unexpected terms, want: %v, got: %v", test.expectTerms, msg.termed)
+			}
+
+			if test.events <= 0 {
+				wantAcks := 0
+				if test.events == 0 {
+					wantAcks = 1
+				}
+
+				if msg.acked != wantAcks {
+					t.Fatalf("unexpected acks, want: %v, got: %v", wantAcks, msg.acked)
+				}
+
+				if len(sem) != 0 {
+					t.Fatalf("semaphore is acquired: %v", len(sem))
+				}
+				return
+			}
+
+			if len(sem) != 1 {
+				t.Fatal("semaphore is not acquired until delivery")
+			}
+
+			var events []*core.Event
+			for e := range out {
+				if seq, _ := e.GetLabel("sequence"); seq != "42" {
+					t.Fatalf("unexpected sequence label, want: 42, got: %v", seq)
+				}
+				events = append(events, e)
+			}
+
+			for n, i := range test.deliver {
+				events[i].Done()
+				if msg.acked != test.expectAcks[n] {
+					t.Fatalf("delivery %v: unexpected acks, want: %v, got: %v", n, test.expectAcks[n], msg.acked)
+				}
+			}
+
+			wg.Wait()
+			if len(sem) != 0 {
+				t.Fatalf("semaphore is not released after delivery: %v", len(sem))
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/plugins"
+	"github.com/gekatateam/neptunus/plugins/common/ider"
+	common "github.com/gekatateam/neptunus/plugins/common/nats"
+	pkgtls "github.com/gekatateam/neptunus/plugins/common/tls"
+)
+
+type Nats struct {
+	*core.BaseInput `mapstructure:"-"`
+	Mode            string            `mapstructure:"mode"`
+	Subjects        []string          `mapstructure:"subjects"`
+	QueueGroup      string            `mapstructure:"queue_group"`
+	MaxPending      int               `mapstructure:"max_pending"`
+	Stream          string            `mapstructure:"stream"`
+	Consumer        string            `mapstructure:"consumer"`
+	DeliverPolicy   string            `mapstructure:"deliver_policy"`
+	AckWait         time.Duration     `mapstructure:"ack_wait"`
+	MaxDeliver      int               `mapstructure:"max_deliver"`
+	MaxUndelivered  int               `mapstructure:"max_undelivered"`
+	KeepTimestamp   bool              `mapstructure:"keep_timestamp"`
+	KeepMessageId   bool              `mapstructure:"keep_message_id"`
+	LabelHeaders    map[string]string `mapstructure:"labelheaders"`
+
+	*common.Connection `mapstructure:",squash"`
+	*ider.Ider         `mapstructure:",squash"`
+
+	conn     *nats.Conn
+	subs     []*nats.Subscription
+	msgCh    chan *nats.Msg
+	messages jetstream.MessagesContext
+
+	stopCh chan struct{}
+	doneCh chan struct{}
+
+	parser core.Parser
+}
+
+func (i *Nats) Init() error {
+	if len(i.Subjects) == 0 {
+		return errors.New("at least one subject required")
+	}
+
+	if err := i.Ider.Init(); err != nil {
+		return err
+	}
+
+	var deliverPolicy jetstream.DeliverPolicy
+	switch i.Mode {
+	case "core":
+		if i.MaxPending < 1 {
+			i.MaxPending = 1
+		}
+	case "jetstream":
+		if len(i.Stream) == 0 {
+			return errors.New("stream required in jetstream mode")
+		}
+
+		if len(i.Consumer) == 0 {
+			return errors.New("consumer required in jetstream mode")
+		}
+
+		switch i.DeliverPolicy {
+		case "all":
+			deliverPolicy = jetstream.DeliverAllPolicy
+		case "new":
+			deliverPolicy = jetstream.DeliverNewPolicy
+		case "last":
+			deliverPolicy = jetstream.DeliverLastPolicy
+		case "last_per_subject":
+			deliverPolicy = jetstream.DeliverLastPerSubjectPolicy
+		default:
+			return fmt.Errorf("unknown deliver policy: %v; expected one of: all, new, last, last_per_subject", i.DeliverPolicy)
+		}
+
+		if i.MaxUndelivered < 1 {
+			i.MaxUndelivered = 1
+		}
+	default:
+		return fmt.Errorf("unknown mode: %v; expected one of: core, jetstream", i.Mode)
+	}
+
+	conn, err := i.Connection.Connect(i.Log)
+	if err != nil {
+		return fmt.Errorf("connection failed: %w", err)
+	}
+	i.conn = conn
+
+	i.stopCh = make(chan struct{})
+	i.doneCh = make(chan struct{})
+
+	if i.Mode == "core" {
+		i.msgCh = make(chan *nats.Msg, i.MaxPending)
+		for _, subject := range i.Subjects {
+			sub, err := i.conn.ChanQueueSubscribe(subject, i.QueueGroup, i.msgCh)
+			if err != nil {
+				i.unsubscribe()
+				i.conn.Close()
+				return fmt.Errorf("subscription to %v failed: %w", subject, err)
+			}
+			i.subs = append(i.subs, sub)
+		}
+		return nil
+	}
+
+	js, err := jetstream.New(i.conn)
+	if err != nil {
+		i.conn.Close()
+		return fmt.Errorf("jetstream context creation failed: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), i.DialTimeout)
+	defer cancel()
+
+	consumerConfig := jetstream.ConsumerConfig{
+		Durable:       i.Consumer,
+		DeliverPolicy: deliverPolicy,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       i.AckWait,
+		MaxDeliver:    i.MaxDeliver,
+		MaxAckPending: i.MaxUndelivered,
+	}
+
+	// multiple filter subjects are supported since nats-server v2.10
+	if len(i.Subjects) == 1 {
+		consumerConfig.FilterSubject = i.Subjects[0]
+	} else {
+		consumerConfig.FilterSubjects = i.Subjects
+	}
+
+	consumer, err := js.CreateOrUpdateConsumer(ctx, i.Stream, consumerConfig)
+	if err != nil {
+		i.conn.Close()
+		return fmt.Errorf("consumer %v creation failed: %w", i.Consumer, err)
+	}
+
+	messages, err := consumer.Messages(jetstream.PullMaxMessages(i.MaxUndelivered))
+	if err != nil {
+		i.conn.Close()
+		return fmt.Errorf("consumer %v messages iterator creation failed: %w", i.Consumer, err)
+	}
+	i.messages = messages
+
+	return nil
+}
+
+func (i *Nats) SetParser(p core.Parser) {
+	i.parser = p
+}
+
+func (i *Nats) Close() error {
+	close(i.stopCh)
+	if i.messages != nil {
+		i.messages.Stop()
+	}
+	<-i.doneCh
+
+	i.unsubscribe()
+	i.parser.Close()
+	i.conn.Close()
+	return nil
+}
+
+func (i *Nats) Run() {
+	defer close(i.doneCh)
+
+	if i.Mode == "core" {
+		i.Log.Info(fmt.Sprintf("subscribed to subjects: %v", i.Subjects))
+		for {
+			select {
+			case <-i.stopCh:
+				i.Log.Info("subscriptions closed")
+				return
+			case msg := <-i.msgCh:
+				i.consumeCore(msg)
+			}
+		}
+	}
+
+	i.Log.Info(fmt.Sprintf("consumer %v for stream %v started", i.Consumer, i.Stream))
+	wg := &sync.WaitGroup{}
+	sem := make(chan struct{}, i.MaxUndelivered)
+
+	for {
+		msg, err := i.messages.Next()
+		if err != nil {
+			if errors.Is(err, jetstream.ErrMsgIteratorClosed) {
+				break
+			}
+
+			i.Log.Error("message fetching failed",
+				"error", err,
+			)
+			continue
+		}
+
+		i.consumeJetStream(msg, wg, sem)
+	}
+
+	i.Log.Info(fmt.Sprintf("consumer %v for stream %v done, waiting for events delivery", i.Consumer, i.Stream))
+	wg.Wait()
+	i.Log.Info(fmt.Sprintf("consumer %v for stream %v closed", i.Consumer, i.Stream))
+}
+
+func (i *Nats) unsubscribe() {
+	for _, sub := range i.subs {
+		if err := sub.Unsubscribe(); err != nil {
+			i.Log.Warn(fmt.Sprintf("unsubscribe from %v failed", sub.Subject),
+				"error", err,
+			)
+		}
+	}
+	i.subs = nil
+}
+
+func init() {
+	plugins.AddInput("nats", func() core.Input {
+		return &Nats{
+			Mode:           "core",
+			MaxPending:     nats.DefaultSubPendingMsgsLimit,
+			DeliverPolicy:  "all",
+			AckWait:        30 * time.Second,
+			MaxDeliver:     -1,
+			MaxUndelivered: 1000,
+			Connection: &common.Connection{
+				Servers:         []string{nats.DefaultURL},
+				ConnectionName:  "neptunus.nats.input",
+				DialTimeout:     10 * time.Second,
+				ReconnectWait:   5 * time.Second,
+				TLSClientConfig: &pkgtls.TLSClientConfig{},
+			},
+			Ider: &ider.Ider{},
+		}
+	})
+}
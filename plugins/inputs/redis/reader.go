@@ -0,0 +1,287 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins/common/convert"
+)
+
+type entryRef struct {
+	stream string
+	id     string
+}
+
+type reader struct {
+	*Redis
+
+	stream    string
+	semaphore chan struct{}
+	ackCh     chan entryRef
+}
+
+// reader reads consumer pending entries first, because they were read, but not acked
+// before previous shutdown, and then switches to new entries
+func (r *reader) Run() {
+	r.Log.Info(fmt.Sprintf("reader for stream %v started", r.stream))
+	lastId := "0"
+
+	for {
+		res, err := r.client.XReadGroup(r.fetchCtx, &redis.XReadGroupArgs{
+			Group:    r.Group,
+			Consumer: r.Consumer,
+			Streams:  []string{r.stream, lastId},
+			Count:    r.BatchSize,
+			Block:    r.Block,
+		}).Result()
+
+		if r.fetchCtx.Err() != nil {
+			break
+		}
+
+		if errors.Is(err, redis.Nil) { // block timeout, no new entries
+			continue
+		}
+
+		if err != nil {
+			r.Log.Error(fmt.Sprintf("reading from stream %v failed", r.stream),
+				"error", err,
+			)
+
+			select {
+			case <-r.fetchCtx.Done():
+			case <-time.After(r.Timeout):
+			}
+			continue
+		}
+
+		for _, s := range res {
+			if lastId != ">" && len(s.Messages) == 0 {
+				r.Log.Info(fmt.Sprintf("stream %v pending entries consumed, switching to new entries", r.stream))
+				lastId = ">"
+				continue
+			}
+
+			for _, msg := range s.Messages {
+				if lastId != ">" {
+					lastId = msg.ID
+				}
+				r.consumeEntry(msg)
+			}
+		}
+	}
+
+	r.Log.Info(fmt.Sprintf("reader for stream %v closed", r.stream))
+}
+
+// an entry is acked when all of it's events are delivered
+// if parser returns zero events or fails, or if entry has no data field, it is acked immediately
+func (r *reader) consumeEntry(msg redis.XMessage) {
+	now := time.Now()
+	ref := entryRef{stream: r.stream, id: msg.ID}
+
+	r.Log.Debug("entry consumed",
+		"stream", r.stream,
+		"entry_id", msg.ID,
+	)
+
+	r.semaphore <- struct{}{}
+
+	// deleted entries are returned from pending list with no fields
+	data, ok := msg.Values[r.DataField]
+	if !ok {
+		r.Log.Warn(fmt.Sprintf("entry has no %v field, skipped", r.DataField),
+			"stream", r.stream,
+			"entry_id", msg.ID,
+		)
+		r.ackCh <- ref
+		r.Observe(metrics.EventFailed, time.Since(now))
+		return
+	}
+
+	rawData, err := convert.AnyToString(data)
+	if err != nil {
+		r.Log.Error("entry data reading failed, entry skipped",
+			"error", err,
+			"stream", r.stream,
+			"entry_id", msg.ID,
+		)
+		r.ackCh <- ref
+		r.Observe(metrics.EventFailed, time.Since(now))
+		return
+	}
+
+	events, err := r.parser.Parse([]byte(rawData), r.stream)
+	if err != nil {
+		r.Log.Error("parser error, entry skipped",
+			"error", err,
+			"stream", r.stream,
+			"entry_id", msg.ID,
+		)
+		r.ackCh <- ref
+		r.Observe(metrics.EventFailed, time.Since(now))
+		return
+	}
+
+	if len(events) == 0 {
+		r.Log.Debug("parser returns zero events, entry acked",
+			"stream", r.stream,
+			"entry_id", msg.ID,
+		)
+		r.ackCh <- ref
+		r.Observe(metrics.EventAccepted, time.Since(now))
+		return
+	}
+
+	undelivered := &atomic.Int32{}
+	undelivered.Store(int32(len(events)))
+
+	for _, e := range events {
+		e.SetLabel("stream", r.stream)
+		e.SetLabel("entry_id", msg.ID)
+		for label, field := range r.LabelFields {
+			if v, ok := msg.Values[field]; ok {
+				val, err := convert.AnyToString(v)
+				if err != nil {
+					r.Log.Warn(fmt.Sprintf("cannot convert field %v:%v to string", field, v),
+						"error", err,
+						"stream", r.stream,
+						"entry_id", msg.ID,
+					)
+					continue
+				}
+				e.SetLabel(label, val)
+			}
+		}
+
+		e.AddHook(func() {
+			if undelivered.Add(-1) == 0 {
+				r.ackCh <- ref
+			}
+		})
+
+		r.Ider.Apply(e)
+		r.Out <- e
+		r.Log.Debug("event accepted",
+			slog.Group("event",
+				"id", e.Id,
+				"key", e.RoutingKey,
+			),
+		)
+		r.Observe(metrics.EventAccepted, time.Since(now))
+		now = time.Now()
+	}
+}
+
+func (i *Redis) consumeMessage(msg *redis.Message) {
+	now := time.Now()
+
+	i.Log.Debug("message consumed",
+		"channel", msg.Channel,
+	)
+
+	events, err := i.parser.Parse([]byte(msg.Payload), msg.Channel)
+	if err != nil {
+		i.Log.Error("parser error, message skipped",
+			"error", err,
+			"channel", msg.Channel,
+		)
+		i.Observe(metrics.EventFailed, time.Since(now))
+		return
+	}
+
+	for _, e := range events {
+		e.SetLabel("channel", msg.Channel)
+		if len(msg.Pattern) > 0 {
+			e.SetLabel("pattern", msg.Pattern)
+		}
+
+		i.Ider.Apply(e)
+		i.Out <- e
+		i.Log.Debug("event accepted",
+			slog.Group("event",
+				"id", e.Id,
+				"key", e.RoutingKey,
+			),
+		)
+		i.Observe(metrics.EventAccepted, time.Since(now))
+		now = time.Now()
+	}
+}
+
+type acker struct {
+	client    redis.UniversalClient
+	group     string
+	timeout   time.Duration
+	batchSize int
+
+	semaphore chan struct{}
+	ackCh     chan entryRef
+	exitCh    chan struct{}
+	doneCh    chan struct{}
+
+	log *slog.Logger
+}
+
+// acker collects delivered entries ids and acks them in batches
+// entries, which ack failed, stay in consumer pending list and will be read again after restart
+func (a *acker) Run() {
+	exitIfEmpty := false
+	exitCh := a.exitCh
+
+	for {
+		select {
+		case ref := <-a.ackCh:
+			batch := map[string][]string{ref.stream: {ref.id}}
+			count := 1
+		COLLECT_LOOP:
+			for count < a.batchSize {
+				select {
+				case ref := <-a.ackCh:
+					batch[ref.stream] = append(batch[ref.stream], ref.id)
+					count++
+				default:
+					break COLLECT_LOOP
+				}
+			}
+			a.ack(batch)
+		case <-exitCh:
+			a.log.Info(fmt.Sprintf("left in ack queue: %v", len(a.semaphore)))
+			exitIfEmpty = true
+			exitCh = nil
+		}
+
+		if exitIfEmpty && len(a.semaphore) == 0 {
+			close(a.doneCh)
+			return
+		}
+	}
+}
+
+func (a *acker) ack(batch map[string][]string) {
+	for stream, ids := range batch {
+		ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+		if err := a.client.XAck(ctx, stream, a.group, ids...).Err(); err != nil {
+			a.log.Error(fmt.Sprintf("entries ack failed on stream %v", stream),
+				"error", err,
+				"entries", ids,
+			)
+		} else {
+			a.log.Debug(fmt.Sprintf("entries acked on stream %v", stream),
+				"entries", ids,
+			)
+		}
+		cancel()
+
+		for range ids {
+			_ = <-a.semaphore //lint:ignore S1005 explicitly indicates reading from the channel, not waiting
+		}
+	}
+}
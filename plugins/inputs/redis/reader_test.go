@@ -0,0 +1,276 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins/common/ider"
+	common "github.com/gekatateam/neptunus/plugins/common/redis"
+)
+
+type mockParser struct{}
+
+func (m *mockParser) Parse(data []byte, routingKey string) ([]*core.Event, error) {
+	return []*core.Event{core.NewEventWithData(routingKey, string(data))}, nil
+}
+
+func (m *mockParser) Close() error {
+	return nil
+}
+
+func (m *mockParser) Init() error {
+	return nil
+}
+
+type readResult struct {
+	ids []string
+	err error
+}
+
+// only stream commands used by reader and acker are implemented
+type mockClient struct {
+	redis.UniversalClient
+
+	mu      sync.Mutex
+	reads   []readResult // results of XREADGROUP calls, in order
+	readIds []string     // ids from which entries were requested
+	cancel  context.CancelFunc
+	ackErr  error
+	acks    []string // stream:ids of XACK calls
+}
+
+func (c *mockClient) XReadGroup(ctx context.Context, a *redis.XReadGroupArgs) *redis.XStreamSliceCmd {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.readIds = append(c.readIds, a.Streams[1])
+	if len(c.reads) == 0 {
+		c.cancel()
+		return redis.NewXStreamSliceCmdResult(nil, context.Canceled)
+	}
+
+	res := c.reads[0]
+	c.reads = c.reads[1:]
+	if res.err != nil {
+		return redis.NewXStreamSliceCmdResult(nil, res.err)
+	}
+
+	stream := redis.XStream{Stream: a.Streams[0], Messages: []redis.XMessage{}}
+	for _, id := range res.ids {
+		stream.Messages = append(stream.Messages, redis.XMessage{
+			ID:     id,
+			Values: map[string]any{"data": "entry " + id},
+		})
+	}
+	return redis.NewXStreamSliceCmdResult([]redis.XStream{stream}, nil)
+}
+
+func (c *mockClient) XAck(ctx context.Context, stream, group string, ids ...string) *redis.IntCmd {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.acks = append(c.acks, fmt.Sprintf("%v:%v", stream, ids))
+	return redis.NewIntResult(int64(len(ids)), c.ackErr)
+}
+
+func TestReader(t *testing.T) {
+	tests := map[string]struct {
+		reads         []readResult
+		expectReadIds []string
+		expectEntries []string
+	}{
+		"pending-then-new-entries": {
+			reads: []readResult{
+				{ids: []string{"1-0", "2-0"}},
+				{ids: []string{}},
+				{ids: []string{"3-0"}},
+				{err: redis.Nil},
+				{ids: []string{"4-0"}},
+			},
+			expectReadIds: []string{"0", "2-0", ">", ">", ">", ">"},
+			expectEntries: []string{"1-0", "2-0", "3-0", "4-0"},
+		},
+		"no-pending-entries": {
+			reads: []readResult{
+				{ids: []string{}},
+				{ids: []string{"1-0"}},
+			},
+			expectReadIds: []string{"0", ">", ">"},
+			expectEntries: []string{"1-0"},
+		},
+		"read-error-retried": {
+			reads: []readResult{
+				{err: errors.New("connection reset")},
+				{ids: []string{"1-0"}},
+				{ids: []string{}},
+			},
+			expectReadIds: []string{"0", "0", "1-0", ">"},
+			expectEntries: []string{"1-0"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			out := make(chan *core.Event, 10)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			client := &mockClient{reads: test.reads, cancel: cancel}
+			r := &reader{
+				Redis: &Redis{
+					BaseInput: &core.BaseInput{
+						Log: logger.Mock(),
+						Obs: metrics.ObserveMock,
+						Out: out,
+					},
+					Group:     "group",
+					Consumer:  "consumer",
+					DataField: "data",
+					BatchSize: 10,
+					Redis:     &common.Redis{Timeout: time.Millisecond},
+					Ider:      &ider.Ider{},
+					client:    client,
+					fetchCtx:  ctx,
+					parser:    &mockParser{},
+				},
+				stream:    "events",
+				semaphore: make(chan struct{}, 10),
+				ackCh:     make(chan entryRef, 10),
+			}
+
+			r.Run()
+			close(out)
+
+			if !slices.Equal(client.readIds, test.expectReadIds) {
+				t.Fatalf("unexpected read ids, want: %v, got: %v", test.expectReadIds, client.readIds)
+			}
+
+			var entries []string
+			for e := range out {
+				id, _ := e.GetLabel("entry_id")
+				entries = append(entries, id)
+				e.Done()
+			}
+
+			if !slices.Equal(entries, test.expectEntries) {
+				t.Fatalf("unexpected entries, want: %v, got: %v", test.expectEntries, entries)
+			}
+
+			if len(r.ackCh) != len(test.expectEntries) || len(r.semaphore) != len(test.expectEntries) {
+				t.Fatalf("unexpected acks queue, want: %v, got: %v (semaphore %v)", len(test.expectEntries), len(r.ackCh), len(r.semaphore))
+			}
+		})
+	}
+}
+
+func TestAcker(t *testing.T) {
+	tests := map[string]struct {
+		refs       []entryRef
+		batchSize  int
+		ackErr     error
+		expectAcks []string
+	}{
+		"batched-by-stream": {
+			refs:       []entryRef{{"a", "1-0"}, {"b", "1-0"}, {"a", "2-0"}},
+			batchSize:  10,
+			expectAcks: []string{"a:[1-0 2-0]", "b:[1-0]"},
+		},
+		"batch-size-limit": {
+			refs:       []entryRef{{"a", "1-0"}, {"a", "2-0"}, {"a", "3-0"}},
+			batchSize:  2,
+			expectAcks: []string{"a:[1-0 2-0]", "a:[3-0]"},
+		},
+		"failed-ack-releases-semaphore": {
+			refs:       []entryRef{{"a", "1-0"}, {"a", "2-0"}},
+			batchSize:  10,
+			ackErr:     errors.New("connection reset"),
+			expectAcks: []string{"a:[1-0 2-0]"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := &mockClient{ackErr: test.ackErr}
+			a := &acker{
+				client:    client,
+				group:     "group",
+				timeout:   time.Second,
+				batchSize: test.batchSize,
+				semaphore: make(chan struct{}, len(test.refs)),
+				ackCh:     make(chan entryRef, len(test.refs)),
+				exitCh:    make(chan struct{}),
+				doneCh:    make(chan struct{}),
+				log:       logger.Mock(),
+			}
+
+			for _, ref := range test.refs {
+				a.semaphore <- struct{}{}
+				a.ackCh <- ref
+			}
+
+			go a.Run()
+			close(a.exitCh)
+
+			select {
+			case <-a.doneCh:
+			case <-time.After(5 * time.Second):
+				t.Fatal("acker not stopped")
+			}
+
+			slices.Sort(client.acks)
+			if !slices.Equal(client.acks, test.expectAcks) {
+				t.Fatalf("unexpected acks, want: %v, got: %v", test.expectAcks, client.acks)
+			}
+
+			if len(a.semaphore) != 0 {
+				t.Fatalf("semaphore is not empty: %v", len(a.semaphore))
+			}
+		})
+	}
+}
+
+func TestAcker_WaitsUndelivered(t *testing.T) {
+	client := &mockClient{}
+	a := &acker{
+		client:    client,
+		group:     "group",
+		timeout:   time.Second,
+		batchSize: 10,
+		semaphore: make(chan struct{}, 1),
+		ackCh:     make(chan entryRef),
+		exitCh:    make(chan struct{}),
+		doneCh:    make(chan struct{}),
+		log:       logger.Mock(),
+	}
+
+	a.semaphore <- struct{}{}
+	go a.Run()
+	close(a.exitCh)
+
+	select {
+	case <-a.doneCh:
+		t.Fatal("acker stopped with undelivered entry")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	a.ackCh <- entryRef{"a", "1-0"}
+	select {
+	case <-a.doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("acker not stopped after last entry ack")
+	}
+
+	if want := []string{"a:[1-0]"}; !slices.Equal(client.acks, want) {
+		t.Fatalf("unexpected acks, want: %v, got: %v", want, client.acks)
+	}
+}
@@ -0,0 +1,236 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/plugins"
+	"github.com/gekatateam/neptunus/plugins/common/ider"
+	common "github.com/gekatateam/neptunus/plugins/common/redis"
+	"github.com/gekatateam/neptunus/plugins/common/tls"
+)
+
+type Redis struct {
+	*core.BaseInput `mapstructure:"-"`
+	Mode            string            `mapstructure:"mode"`
+	Streams         []string          `mapstructure:"streams"`
+	Group           string            `mapstructure:"group"`
+	Consumer        string            `mapstructure:"consumer"`
+	CreateGroup     bool              `mapstructure:"create_group"`
+	StartId         string            `mapstructure:"start_id"`
+	DataField       string            `mapstructure:"data_field"`
+	BatchSize       int64             `mapstructure:"batch_size"`
+	Block           time.Duration     `mapstructure:"block"`
+	MaxUndelivered  int               `mapstructure:"max_undelivered"`
+	Channels        []string          `mapstructure:"channels"`
+	Patterns        []string          `mapstructure:"patterns"`
+	LabelFields     map[string]string `mapstructure:"labelfields"`
+
+	*common.Redis `mapstructure:",squash"`
+	*ider.Ider    `mapstructure:",squash"`
+
+	client redis.UniversalClient
+	pubsub *redis.PubSub
+
+	fetchCtx   context.Context
+	cancelFunc context.CancelFunc
+	doneCh     chan struct{}
+
+	parser core.Parser
+}
+
+func (i *Redis) Init() error {
+	if err := i.Ider.Init(); err != nil {
+		return err
+	}
+
+	switch i.Mode {
+	case "streams":
+		if len(i.Streams) == 0 {
+			return errors.New("at least one stream required")
+		}
+
+		if len(i.Group) == 0 {
+			return errors.New("group required")
+		}
+
+		if len(i.Consumer) == 0 {
+			return errors.New("consumer required")
+		}
+
+		if len(i.DataField) == 0 {
+			return errors.New("data_field required")
+		}
+
+		if i.BatchSize < 1 {
+			i.BatchSize = 1
+		}
+
+		if i.MaxUndelivered < 1 {
+			i.MaxUndelivered = 1
+		}
+
+		// each stream reader holds a connection while blocked in XREADGROUP
+		// and one more connection is needed for XACKs
+		if i.ConnsMaxOpen < len(i.Streams)+1 {
+			i.ConnsMaxOpen = len(i.Streams) + 1
+		}
+	case "pubsub":
+		if len(i.Channels) == 0 && len(i.Patterns) == 0 {
+			return errors.New("at least one channel or pattern required")
+		}
+	default:
+		return fmt.Errorf("unknown mode: %v; expected one of: streams, pubsub", i.Mode)
+	}
+
+	client, err := i.Redis.Client()
+	if err != nil {
+		return err
+	}
+	i.client = client
+
+	ctx, cancel := context.WithTimeout(context.Background(), i.Timeout)
+	defer cancel()
+
+	if err := i.client.Ping(ctx).Err(); err != nil {
+		i.client.Close()
+		return err
+	}
+
+	if i.Mode == "streams" && i.CreateGroup {
+		for _, stream := range i.Streams {
+			err := i.client.XGroupCreateMkStream(ctx, stream, i.Group, i.StartId).Err()
+			if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+				i.client.Close()
+				return fmt.Errorf("group %v creation on stream %v failed: %w", i.Group, stream, err)
+			}
+		}
+	}
+
+	i.fetchCtx, i.cancelFunc = context.WithCancel(context.Background())
+	i.doneCh = make(chan struct{})
+
+	if i.Mode == "pubsub" {
+		i.pubsub = i.client.Subscribe(i.fetchCtx)
+		if len(i.Channels) > 0 {
+			if err := i.pubsub.Subscribe(ctx, i.Channels...); err != nil {
+				i.pubsub.Close()
+				i.client.Close()
+				return fmt.Errorf("subscription failed: %w", err)
+			}
+		}
+
+		if len(i.Patterns) > 0 {
+			if err := i.pubsub.PSubscribe(ctx, i.Patterns...); err != nil {
+				i.pubsub.Close()
+				i.client.Close()
+				return fmt.Errorf("pattern subscription failed: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (i *Redis) SetParser(p core.Parser) {
+	i.parser = p
+}
+
+func (i *Redis) Close() error {
+	i.cancelFunc()
+	if i.pubsub != nil {
+		i.pubsub.Close()
+	}
+	<-i.doneCh
+
+	i.parser.Close()
+	return i.client.Close()
+}
+
+func (i *Redis) Run() {
+	defer close(i.doneCh)
+
+	if i.Mode == "pubsub" {
+		i.Log.Info(fmt.Sprintf("subscribed to channels: %v, patterns: %v", i.Channels, i.Patterns))
+		for msg := range i.pubsub.Channel() {
+			i.consumeMessage(msg)
+		}
+		i.Log.Info("subscriptions closed")
+		return
+	}
+
+	var (
+		wg        = &sync.WaitGroup{}
+		ackCh     = make(chan entryRef)
+		exitCh    = make(chan struct{})
+		ackDoneCh = make(chan struct{})
+		semaphore = make(chan struct{}, i.MaxUndelivered)
+	)
+
+	acker := &acker{
+		client:    i.client,
+		group:     i.Group,
+		timeout:   i.Timeout,
+		batchSize: i.MaxUndelivered,
+		semaphore: semaphore,
+		ackCh:     ackCh,
+		exitCh:    exitCh,
+		doneCh:    ackDoneCh,
+		log:       i.Log,
+	}
+	go acker.Run()
+
+	for _, stream := range i.Streams {
+		r := &reader{
+			Redis:     i,
+			stream:    stream,
+			semaphore: semaphore,
+			ackCh:     ackCh,
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Run()
+		}()
+	}
+
+	wg.Wait()
+	i.Log.Info("readers done, waiting for events delivery")
+
+	close(exitCh)
+	<-ackDoneCh
+	i.Log.Info("all entries acked")
+}
+
+func init() {
+	plugins.AddInput("redis", func() core.Input {
+		return &Redis{
+			Mode:           "streams",
+			Group:          "neptunus",
+			Consumer:       "neptunus.redis.input",
+			CreateGroup:    true,
+			StartId:        "$",
+			DataField:      "data",
+			BatchSize:      100,
+			Block:          5 * time.Second,
+			MaxUndelivered: 1000,
+			Redis: &common.Redis{
+				ConnsMaxIdleTime: 10 * time.Minute,
+				ConnsMaxLifetime: 10 * time.Minute,
+				ConnsMaxOpen:     2,
+				ConnsMaxIdle:     1,
+				Timeout:          30 * time.Second,
+				TLSClientConfig:  &tls.TLSClientConfig{},
+			},
+			Ider: &ider.Ider{},
+		}
+	})
+}
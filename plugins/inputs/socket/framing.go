@@ -0,0 +1,93 @@
+package socket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// newSplitFunc returns scanner split function for configured framing
+// returned tokens never include framing bytes
+func newSplitFunc(framing string, delimiter []byte, lengthSize, maxSize int) (bufio.SplitFunc, error) {
+	switch framing {
+	case "newline":
+		return bufio.ScanLines, nil
+	case "delimiter":
+		if len(delimiter) == 0 {
+			return nil, errors.New("delimiter required in delimiter framing mode")
+		}
+		return splitDelimiter(delimiter), nil
+	case "length":
+		switch lengthSize {
+		case 1, 2, 4, 8:
+		default:
+			return nil, fmt.Errorf("unsupported length size: %v, expected one of: 1, 2, 4, 8", lengthSize)
+		}
+		return splitLength(lengthSize, maxSize), nil
+	default:
+		return nil, fmt.Errorf("unknown framing: %v, expected one of: newline, delimiter, length", framing)
+	}
+}
+
+func splitDelimiter(delimiter []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+
+		if i := bytes.Index(data, delimiter); i >= 0 {
+			return i + len(delimiter), data[:i], nil
+		}
+
+		// last frame may be not terminated
+		if atEOF {
+			return len(data), data, nil
+		}
+
+		return 0, nil, nil
+	}
+}
+
+// splitLength splits frames with big-endian unsigned length prefix
+func splitLength(size, maxSize int) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (int, []byte, error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+
+		if len(data) < size {
+			if atEOF {
+				return 0, nil, errors.New("frame length prefix is incomplete")
+			}
+			return 0, nil, nil
+		}
+
+		var length uint64
+		switch size {
+		case 1:
+			length = uint64(data[0])
+		case 2:
+			length = uint64(binary.BigEndian.Uint16(data))
+		case 4:
+			length = uint64(binary.BigEndian.Uint32(data))
+		case 8:
+			length = binary.BigEndian.Uint64(data)
+		}
+
+		if length > uint64(maxSize) {
+			return 0, nil, fmt.Errorf("frame length %v exceeds max message size", length)
+		}
+
+		end := size + int(length)
+		if len(data) < end {
+			if atEOF {
+				return 0, nil, errors.New("frame is incomplete")
+			}
+			return 0, nil, nil
+		}
+
+		return end, data[size:end], nil
+	}
+}
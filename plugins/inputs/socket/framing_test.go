@@ -0,0 +1,90 @@
+package socket
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSplitFunc(t *testing.T) {
+	tests := map[string]struct {
+		framing    string
+		delimiter  string
+		lengthSize int
+		input      []byte
+		want       []string
+		wantErr    bool
+	}{
+		"newline with crlf and unterminated tail": {
+			framing: "newline",
+			input:   []byte("first\r\nsecond\nthird"),
+			want:    []string{"first", "second", "third"},
+		},
+		"multibyte delimiter": {
+			framing:   "delimiter",
+			delimiter: "||",
+			input:     []byte("a|b||c||"),
+			want:      []string{"a|b", "c"},
+		},
+		"length prefix 2 bytes": {
+			framing:    "length",
+			lengthSize: 2,
+			input:      []byte{0, 3, 'a', 'b', 'c', 0, 1, 'd'},
+			want:       []string{"abc", "d"},
+		},
+		"length prefix incomplete frame": {
+			framing:    "length",
+			lengthSize: 4,
+			input:      []byte{0, 0, 0, 5, 'a', 'b'},
+			want:       []string{},
+			wantErr:    true,
+		},
+		"length prefix exceeds max size": {
+			framing:    "length",
+			lengthSize: 4,
+			input:      []byte{0, 1, 0, 0, 'a'},
+			want:       []string{},
+			wantErr:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			split, err := newSplitFunc(test.framing, []byte(test.delimiter), test.lengthSize, 1024)
+			if err != nil {
+				t.Fatalf("unexpected init error: %v", err)
+			}
+
+			scanner := bufio.NewScanner(bytes.NewReader(test.input))
+			scanner.Split(split)
+
+			got := []string{}
+			for scanner.Scan() {
+				got = append(got, scanner.Text())
+			}
+
+			if test.wantErr != (scanner.Err() != nil) {
+				t.Fatalf("unexpected scanner error: %v", scanner.Err())
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("unexpected frames, want: %q, got: %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestNewSplitFuncErrors(t *testing.T) {
+	if _, err := newSplitFunc("delimiter", nil, 0, 1024); err == nil {
+		t.Fatal("expected error on empty delimiter")
+	}
+
+	if _, err := newSplitFunc("length", nil, 3, 1024); err == nil {
+		t.Fatal("expected error on unsupported length size")
+	}
+
+	if _, err := newSplitFunc("unknown", nil, 0, 1024); err == nil {
+		t.Fatal("expected error on unknown framing")
+	}
+}
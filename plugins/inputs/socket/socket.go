@@ -0,0 +1,339 @@
+package socket
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/net/netutil"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+	"github.com/gekatateam/neptunus/plugins/common/ider"
+	pkgtls "github.com/gekatateam/neptunus/plugins/common/tls"
+)
+
+type Socket struct {
+	*core.BaseInput `mapstructure:"-"`
+	Address         string        `mapstructure:"address"`
+	Network         string        `mapstructure:"network"`
+	Framing         string        `mapstructure:"framing"`
+	Delimiter       string        `mapstructure:"delimiter"`
+	LengthSize      int           `mapstructure:"length_size"`
+	MaxConnections  int           `mapstructure:"max_connections"`
+	MaxMessageSize  int           `mapstructure:"max_message_size"`
+	ReadTimeout     time.Duration `mapstructure:"read_timeout"`
+	WaitForDelivery bool          `mapstructure:"wait_for_delivery"`
+
+	*ider.Ider              `mapstructure:",squash"`
+	*pkgtls.TLSServerConfig `mapstructure:",squash"`
+
+	listener   net.Listener
+	packetConn net.PacketConn
+	split      bufio.SplitFunc
+	routingKey string
+
+	conns map[net.Conn]struct{}
+	mu    *sync.Mutex
+	wg    *sync.WaitGroup
+
+	parser core.Parser
+}
+
+func (i *Socket) Init() error {
+	if len(i.Address) == 0 {
+		return errors.New("address required")
+	}
+
+	if i.MaxMessageSize <= 0 {
+		return errors.New("max_message_size must be greater than zero")
+	}
+
+	if err := i.Ider.Init(); err != nil {
+		return err
+	}
+
+	i.routingKey = "socket." + i.Network
+	i.conns = make(map[net.Conn]struct{})
+	i.mu = &sync.Mutex{}
+	i.wg = &sync.WaitGroup{}
+
+	switch i.Network {
+	case "udp", "unixgram":
+		if i.TLSServerConfig.Enable {
+			return fmt.Errorf("TLS is not supported over %v", i.Network)
+		}
+
+		if i.Network == "unixgram" {
+			if err := removeStaleSocket(i.Address); err != nil {
+				return err
+			}
+		}
+
+		conn, err := net.ListenPacket(i.Network, i.Address)
+		if err != nil {
+			return fmt.Errorf("error creating listener: %v", err)
+		}
+		i.packetConn = conn
+	case "tcp", "unix":
+		split, err := newSplitFunc(i.Framing, []byte(i.Delimiter), i.LengthSize, i.MaxMessageSize)
+		if err != nil {
+			return err
+		}
+		i.split = split
+
+		tlsConfig, err := i.TLSServerConfig.Config()
+		if err != nil {
+			return err
+		}
+
+		if i.Network == "unix" {
+			if err := removeStaleSocket(i.Address); err != nil {
+				return err
+			}
+		}
+
+		var listener net.Listener
+		if i.TLSServerConfig.Enable {
+			l, err := tls.Listen(i.Network, i.Address, tlsConfig)
+			if err != nil {
+				return fmt.Errorf("error creating TLS listener: %v", err)
+			}
+			listener = l
+		} else {
+			l, err := net.Listen(i.Network, i.Address)
+			if err != nil {
+				return fmt.Errorf("error creating listener: %v", err)
+			}
+			listener = l
+		}
+
+		if i.MaxConnections > 0 {
+			listener = netutil.LimitListener(listener, i.MaxConnections)
+			i.Log.Debug(fmt.Sprintf("listener is limited to %v simultaneous connections", i.MaxConnections))
+		}
+		i.listener = listener
+	default:
+		return fmt.Errorf("unknown network: %v, expected one of: tcp, udp, unix, unixgram", i.Network)
+	}
+
+	return nil
+}
+
+func (i *Socket) SetParser(p core.Parser) {
+	i.parser = p
+}
+
+func (i *Socket) Close() error {
+	var err error
+	if i.packetConn != nil {
+		err = i.packetConn.Close()
+		if i.Network == "unixgram" {
+			os.Remove(i.Address)
+		}
+	} else {
+		err = i.listener.Close()
+
+		i.mu.Lock()
+		for conn := range i.conns {
+			conn.Close()
+		}
+		i.mu.Unlock()
+	}
+
+	if err := i.parser.Close(); err != nil {
+		i.Log.Error("parser closed with error",
+			"error", err.Error(),
+		)
+	}
+
+	return err
+}
+
+func (i *Socket) Run() {
+	i.Log.Info(fmt.Sprintf("starting %v socket server on %v", i.Network, i.Address))
+
+	if i.packetConn != nil {
+		i.servePacket()
+	} else {
+		i.serveStream()
+	}
+
+	i.Log.Info("stopping socket server")
+}
+
+func (i *Socket) servePacket() {
+	buf := make([]byte, i.MaxMessageSize)
+	for {
+		n, addr, err := i.packetConn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			i.Log.Error("packet reading failed",
+				"error", err,
+			)
+			continue
+		}
+
+		// datagrams are not waited for delivery
+		// because there is no one to respond to
+		i.produce(buf[:n], addrString(addr), nil)
+	}
+}
+
+func (i *Socket) serveStream() {
+	for {
+		conn, err := i.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				break
+			}
+			i.Log.Error("connection accepting failed",
+				"error", err,
+			)
+			continue
+		}
+
+		i.mu.Lock()
+		i.conns[conn] = struct{}{}
+		i.mu.Unlock()
+
+		i.wg.Add(1)
+		go func() {
+			defer i.wg.Done()
+			i.handleConn(conn)
+
+			i.mu.Lock()
+			delete(i.conns, conn)
+			i.mu.Unlock()
+			conn.Close()
+		}()
+	}
+
+	i.wg.Wait()
+}
+
+func (i *Socket) handleConn(conn net.Conn) {
+	sender := addrString(conn.RemoteAddr())
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, min(4096, i.MaxMessageSize)), i.MaxMessageSize+i.LengthSize+len(i.Delimiter)+2)
+	scanner.Split(i.split)
+
+	i.Log.Debug("connection accepted",
+		"sender", sender,
+	)
+
+	var wg *sync.WaitGroup
+	if i.WaitForDelivery {
+		wg = &sync.WaitGroup{}
+	}
+
+	for {
+		if i.ReadTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(i.ReadTimeout))
+		}
+
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil && !errors.Is(err, net.ErrClosed) {
+				i.Log.Warn("connection closed",
+					"error", err,
+					"sender", sender,
+				)
+			}
+			return
+		}
+
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		i.produce(scanner.Bytes(), sender, wg)
+
+		// next frame is not read until all events
+		// of the previous frame are delivered
+		if wg != nil {
+			wg.Wait()
+		}
+	}
+}
+
+func (i *Socket) produce(frame []byte, sender string, wg *sync.WaitGroup) {
+	now := time.Now()
+
+	e, err := i.parser.Parse(frame, i.routingKey)
+	if err != nil {
+		i.Log.Error("parser error",
+			"error", err,
+			"sender", sender,
+		)
+		i.Observe(metrics.EventFailed, time.Since(now))
+		return
+	}
+
+	for _, event := range e {
+		event.SetLabel("server", i.Address)
+		event.SetLabel("sender", sender)
+
+		if wg != nil {
+			wg.Add(1)
+			event.AddHook(wg.Done)
+		}
+
+		i.Ider.Apply(event)
+		i.Out <- event
+		i.Log.Debug("event accepted",
+			slog.Group("event",
+				"id", event.Id,
+				"key", event.RoutingKey,
+			),
+		)
+		i.Observe(metrics.EventAccepted, time.Since(now))
+		now = time.Now()
+	}
+}
+
+// removeStaleSocket removes socket file left by previous run
+// any other existing file is not touched
+func removeStaleSocket(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%v exists and it is not a socket", path)
+	}
+
+	return os.Remove(path)
+}
+
+func addrString(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	return addr.String()
+}
+
+func init() {
+	plugins.AddInput("socket", func() core.Input {
+		return &Socket{
+			Address:         ":9700",
+			Network:         "tcp",
+			Framing:         "newline",
+			Delimiter:       "\x00",
+			LengthSize:      4,
+			MaxConnections:  0,
+			MaxMessageSize:  65536,
+			Ider:            &ider.Ider{},
+			TLSServerConfig: &pkgtls.TLSServerConfig{},
+		}
+	})
+}
@@ -66,6 +66,7 @@ type Sql struct {
 	keepIndex  map[string]int
 	keepValues map[string]any
 
+	finite bool
 	stopCh chan struct{}
 	doneCh chan struct{}
 
@@ -172,18 +173,48 @@ func (i *Sql) Init() error {
 	return nil
 }
 
+// in finite mode, input polls until onPoll query returns no rows, or until any stage fails
+func (i *Sql) SetFinite() {
+	i.finite = true
+}
+
 func (i *Sql) Close() error {
-	i.stopCh <- struct{}{}
+	close(i.stopCh)
 	<-i.doneCh
 	return i.db.Close()
 }
 
 func (i *Sql) Run() {
+	defer close(i.doneCh)
+
 	if i.EnableMetrics {
 		dbstats.RegisterDB(i.Pipeline, i.Alias, i.Driver, i.db)
 		defer dbstats.UnregisterDB(i.Pipeline, i.Alias, i.Driver)
 	}
 
+	if i.finite {
+		for {
+			more, err := i.poll()
+			if err != nil {
+				i.Log.Error("polling failed, input is stopped",
+					"error", err,
+				)
+				return
+			}
+
+			if !more {
+				i.Log.Info("polling completed, input is exhausted")
+				return
+			}
+
+			select {
+			case <-i.stopCh:
+				return
+			case <-time.After(i.Interval):
+			}
+		}
+	}
+
 	if i.Interval > 0 {
 		ticker := time.NewTicker(i.Interval)
 		for {
@@ -192,7 +223,6 @@ func (i *Sql) Run() {
 				i.poll()
 			case <-i.stopCh:
 				ticker.Stop()
-				i.doneCh <- struct{}{}
 				return
 			}
 		}
@@ -200,7 +230,6 @@ func (i *Sql) Run() {
 		for {
 			select {
 			case <-i.stopCh:
-				i.doneCh <- struct{}{}
 				return
 			default:
 				i.poll()
@@ -236,7 +265,9 @@ func (i *Sql) init() error {
 	return nil
 }
 
-func (i *Sql) poll() {
+// poll returns true if onPoll query returns rows and all stages passed successfully
+// any stage failure is observed as failed event and returned
+func (i *Sql) poll() (bool, error) {
 	now := time.Now()
 	ctx, cancel := context.WithTimeout(context.Background(), i.Timeout)
 	defer cancel()
@@ -249,7 +280,7 @@ func (i *Sql) poll() {
 				"error", err,
 			)
 			i.Observe(metrics.EventFailed, time.Since(now))
-			return
+			return false, err
 		}
 		defer tx.Rollback()
 		querier = tx
@@ -261,7 +292,7 @@ func (i *Sql) poll() {
 			"error", err,
 		)
 		i.Observe(metrics.EventFailed, time.Since(now))
-		return
+		return false, err
 	}
 
 	rows, err := querier.QueryContext(ctx, query, args...)
@@ -270,7 +301,7 @@ func (i *Sql) poll() {
 			"error", err,
 		)
 		i.Observe(metrics.EventFailed, time.Since(now))
-		return
+		return false, err
 	}
 	defer rows.Close()
 
@@ -285,7 +316,7 @@ func (i *Sql) poll() {
 				"error", err,
 			)
 			i.Observe(metrics.EventFailed, time.Since(now))
-			return
+			return false, err
 		}
 
 		hasRows = rows.Next()
@@ -320,7 +351,7 @@ func (i *Sql) poll() {
 
 	if first { // if at least one row returns, flag will be set to false
 		i.Log.Debug("onPoll query returns no rows")
-		return
+		return false, nil
 	}
 
 	// copy keys from init/previous query for usage in done stage
@@ -338,7 +369,8 @@ func (i *Sql) poll() {
 			i.Log.Error("onDone query binding failed",
 				"error", err,
 			)
-			return
+			i.Observe(metrics.EventFailed, time.Since(now))
+			return false, err
 		}
 
 		_, err = querier.ExecContext(ctx, query, args...)
@@ -346,7 +378,8 @@ func (i *Sql) poll() {
 			i.Log.Error("onDone query exec failed",
 				"error", err,
 			)
-			return
+			i.Observe(metrics.EventFailed, time.Since(now))
+			return false, err
 		}
 	}
 
@@ -355,13 +388,15 @@ func (i *Sql) poll() {
 			i.Log.Error("tx commit failed",
 				"error", err,
 			)
-			return
+			i.Observe(metrics.EventFailed, time.Since(now))
+			return false, err
 		}
 	}
 
 	// if all stages passed successfully
 	// replace previously keeped values with actual data
 	i.keepValues = keepValues
+	return true, nil
 }
 
 func (i *Sql) keepColumns(from, to map[string]any, first, last bool) {
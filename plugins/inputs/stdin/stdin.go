@@ -0,0 +1,169 @@
+package stdin
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"kythe.io/kythe/go/util/datasize"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+	"github.com/gekatateam/neptunus/plugins/common/ider"
+)
+
+type Stdin struct {
+	*core.BaseInput `mapstructure:"-"`
+	SplitLines      bool          `mapstructure:"split_lines"`
+	MaxLineSize     datasize.Size `mapstructure:"max_line_size"`
+	*ider.Ider      `mapstructure:",squash"`
+
+	reader io.Reader
+	done   chan struct{}
+
+	parser core.Parser
+}
+
+func (i *Stdin) Init() error {
+	if i.SplitLines && i.MaxLineSize <= 0 {
+		return errors.New("max_line_size must be greater than zero")
+	}
+
+	if err := i.Ider.Init(); err != nil {
+		return err
+	}
+
+	i.reader = os.Stdin
+	i.done = make(chan struct{})
+
+	return nil
+}
+
+// stdin is finite by itself, so there is nothing to do
+func (i *Stdin) SetFinite() {}
+
+func (i *Stdin) SetParser(p core.Parser) {
+	i.parser = p
+}
+
+func (i *Stdin) Close() error {
+	close(i.done)
+
+	if err := i.parser.Close(); err != nil {
+		i.Log.Error("parser closed with error",
+			"error", err.Error(),
+		)
+	}
+
+	return nil
+}
+
+// Run returns when stdin is closed or plugin is stopped
+// reading from stdin can not be interrupted, so it is done in a separate goroutine,
+// which is left blocked if plugin is stopped before stdin is closed
+func (i *Stdin) Run() {
+	i.Log.Info("starting stdin reading")
+
+	messages := make(chan []byte)
+	go i.read(messages)
+
+	for {
+		select {
+		case <-i.done:
+			i.Log.Info("stdin reading stopped")
+			return
+		case msg, ok := <-messages:
+			if !ok {
+				i.Log.Info("stdin closed, reading completed")
+				return
+			}
+			i.produce(msg)
+		}
+	}
+}
+
+func (i *Stdin) read(messages chan<- []byte) {
+	defer close(messages)
+
+	send := func(msg []byte) bool {
+		select {
+		case messages <- msg:
+			return true
+		case <-i.done:
+			return false
+		}
+	}
+
+	if !i.SplitLines {
+		msg, err := io.ReadAll(i.reader)
+		if err != nil {
+			i.Log.Error("stdin reading failed",
+				"error", err,
+			)
+		}
+
+		if len(msg) > 0 {
+			send(msg)
+		}
+		return
+	}
+
+	scanner := bufio.NewScanner(i.reader)
+	scanner.Buffer(make([]byte, 0, 4096), int(i.MaxLineSize.Bytes()))
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		if !send(bytes.Clone(scanner.Bytes())) {
+			return
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		i.Log.Error("stdin reading failed",
+			"error", err,
+		)
+	}
+}
+
+func (i *Stdin) produce(msg []byte) {
+	now := time.Now()
+
+	events, err := i.parser.Parse(msg, "stdin")
+	if err != nil {
+		i.Log.Error("parser error",
+			"error", err,
+		)
+		i.Observe(metrics.EventFailed, time.Since(now))
+		return
+	}
+
+	for _, e := range events {
+		i.Ider.Apply(e)
+		i.Out <- e
+		i.Log.Debug("event accepted",
+			slog.Group("event",
+				"id", e.Id,
+				"key", e.RoutingKey,
+			),
+		)
+		i.Observe(metrics.EventAccepted, time.Since(now))
+		now = time.Now()
+	}
+}
+
+func init() {
+	plugins.AddInput("stdin", func() core.Input {
+		return &Stdin{
+			SplitLines:  true,
+			MaxLineSize: datasize.Mebibyte,
+			Ider:        &ider.Ider{},
+		}
+	})
+}
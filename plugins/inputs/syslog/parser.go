@@ -0,0 +1,302 @@
+package syslog
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const nilValue = "-"
+
+var (
+	facilities = []string{
+		"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+		"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+		"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7",
+	}
+	severities = []string{
+		"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug",
+	}
+)
+
+type message struct {
+	facility  int
+	severity  int
+	version   int // zero for rfc3164
+	timestamp time.Time
+	hostname  string
+	appName   string
+	procId    string
+	msgId     string
+	sd        map[string]any
+	msg       string
+}
+
+func (m *message) data() map[string]any {
+	data := map[string]any{
+		"facility":      m.facility,
+		"facility_name": facilities[m.facility],
+		"severity":      m.severity,
+		"severity_name": severities[m.severity],
+	}
+
+	if m.version > 0 {
+		data["version"] = m.version
+	}
+
+	if !m.timestamp.IsZero() {
+		data["timestamp"] = m.timestamp.Format(time.RFC3339Nano)
+	}
+
+	setString := func(k, v string) {
+		if len(v) > 0 {
+			data[k] = v
+		}
+	}
+
+	setString("hostname", m.hostname)
+	setString("app_name", m.appName)
+	setString("proc_id", m.procId)
+	setString("msg_id", m.msgId)
+	setString("message", m.msg)
+
+	if len(m.sd) > 0 {
+		data["structured_data"] = m.sd
+	}
+
+	return data
+}
+
+// parse detects message format by version after PRI part
+// if format is not "auto", only configured format is used
+func parse(raw []byte, format string, location *time.Location) (*message, error) {
+	s := strings.TrimRight(string(raw), "\r\n\x00")
+
+	m := &message{}
+	rest, err := parsePri(s, m)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case "rfc5424":
+		return m, parse5424(rest, m)
+	case "rfc3164":
+		return m, parse3164(rest, m, location)
+	}
+
+	// rfc5424 version is a non-zero digit followed by space
+	if len(rest) > 1 && rest[0] >= '1' && rest[0] <= '9' && (rest[1] == ' ' || (rest[1] >= '0' && rest[1] <= '9')) {
+		if err := parse5424(rest, m); err == nil {
+			return m, nil
+		}
+		*m = message{facility: m.facility, severity: m.severity}
+	}
+
+	return m, parse3164(rest, m, location)
+}
+
+func parsePri(s string, m *message) (string, error) {
+	if len(s) < 3 || s[0] != '<' {
+		return "", errors.New("message has no PRI part")
+	}
+
+	end := strings.IndexByte(s[:min(len(s), 5)], '>')
+	if end < 2 {
+		return "", errors.New("message has invalid PRI part")
+	}
+
+	pri, err := strconv.Atoi(s[1:end])
+	if err != nil || pri > 191 {
+		return "", fmt.Errorf("message has invalid PRI value: %v", s[1:end])
+	}
+
+	m.facility, m.severity = pri/8, pri%8
+	return s[end+1:], nil
+}
+
+// <PRI>VERSION SP TIMESTAMP SP HOSTNAME SP APP-NAME SP PROCID SP MSGID SP STRUCTURED-DATA [SP MSG]
+func parse5424(s string, m *message) error {
+	fields := make([]string, 0, 6)
+	for range 6 {
+		field, rest, found := strings.Cut(s, " ")
+		if !found {
+			return errors.New("rfc5424 message header is incomplete")
+		}
+		fields = append(fields, field)
+		s = rest
+	}
+
+	version, err := strconv.Atoi(fields[0])
+	if err != nil || version < 1 {
+		return fmt.Errorf("invalid rfc5424 version: %v", fields[0])
+	}
+	m.version = version
+
+	if fields[1] != nilValue {
+		ts, err := time.Parse(time.RFC3339Nano, fields[1])
+		if err != nil {
+			return fmt.Errorf("invalid rfc5424 timestamp: %w", err)
+		}
+		m.timestamp = ts
+	}
+
+	m.hostname = nilOr(fields[2])
+	m.appName = nilOr(fields[3])
+	m.procId = nilOr(fields[4])
+	m.msgId = nilOr(fields[5])
+
+	if strings.HasPrefix(s, nilValue) {
+		s = s[len(nilValue):]
+	} else {
+		sd, rest, err := parseStructuredData(s)
+		if err != nil {
+			return err
+		}
+		m.sd, s = sd, rest
+	}
+
+	if len(s) > 0 {
+		if s[0] != ' ' {
+			return errors.New("rfc5424 structured data must be followed by space")
+		}
+		m.msg = strings.TrimPrefix(s[1:], "\ufeff")
+	}
+
+	return nil
+}
+
+// [id param="value" ...][id ...]
+func parseStructuredData(s string) (map[string]any, string, error) {
+	sd := make(map[string]any)
+
+	for len(s) > 0 && s[0] == '[' {
+		s = s[1:]
+
+		end := strings.IndexAny(s, " ]")
+		if end < 1 {
+			return nil, "", errors.New("rfc5424 structured data element has no id")
+		}
+
+		id := s[:end]
+		params := make(map[string]any)
+		s = s[end:]
+
+		for len(s) > 0 && s[0] == ' ' {
+			s = s[1:]
+
+			eq := strings.Index(s, `="`)
+			if eq < 1 {
+				return nil, "", fmt.Errorf("rfc5424 structured data element %v has invalid param", id)
+			}
+			name := s[:eq]
+			s = s[eq+2:]
+
+			value := strings.Builder{}
+			closed := false
+			for i := 0; i < len(s); i++ {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`"\]`, s[i+1]) >= 0 {
+					value.WriteByte(s[i+1])
+					i++
+					continue
+				}
+
+				if s[i] == '"' {
+					s, closed = s[i+1:], true
+					break
+				}
+				value.WriteByte(s[i])
+			}
+
+			if !closed {
+				return nil, "", fmt.Errorf("rfc5424 structured data element %v has unterminated param %v", id, name)
+			}
+			params[name] = value.String()
+		}
+
+		if len(s) == 0 || s[0] != ']' {
+			return nil, "", fmt.Errorf("rfc5424 structured data element %v is not closed", id)
+		}
+		s = s[1:]
+
+		sd[id] = params
+	}
+
+	return sd, s, nil
+}
+
+// <PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG
+// rfc3164 is a description of existing practice, not a strict format,
+// so parsing is lenient - timestamp, hostname and tag are optional
+func parse3164(s string, m *message, location *time.Location) error {
+	if ts, rest, ok := parse3164Timestamp(s, location); ok {
+		m.timestamp, s = ts, rest
+
+		// hostname is expected only after timestamp
+		if host, rest, found := strings.Cut(s, " "); found && len(host) > 0 && !strings.HasSuffix(host, ":") && !strings.Contains(host, "[") {
+			m.hostname, s = host, rest
+		}
+	}
+
+	// tag is alphanumeric, up to 32 chars, terminated by "[" or ":"
+	end := strings.IndexAny(s, "[: ")
+	if end > 0 && end <= 48 {
+		tag := s[:end]
+		rest := s[end:]
+
+		if rest[0] == '[' {
+			if pidEnd := strings.IndexByte(rest, ']'); pidEnd > 0 {
+				m.procId = rest[1:pidEnd]
+				rest = rest[pidEnd+1:]
+			}
+		}
+
+		if strings.HasPrefix(rest, ":") {
+			m.appName = tag
+			s = strings.TrimPrefix(rest[1:], " ")
+		} else {
+			m.procId = ""
+		}
+	}
+
+	m.msg = s
+	return nil
+}
+
+func parse3164Timestamp(s string, location *time.Location) (time.Time, string, bool) {
+	// some devices send rfc3339 timestamps in rfc3164 messages
+	if field, rest, found := strings.Cut(s, " "); found && len(field) > 0 && field[0] >= '0' && field[0] <= '9' {
+		if ts, err := time.Parse(time.RFC3339Nano, field); err == nil {
+			return ts, rest, true
+		}
+	}
+
+	const layout = time.Stamp // Jan _2 15:04:05
+	if len(s) < len(layout)+1 || s[len(layout)] != ' ' {
+		return time.Time{}, s, false
+	}
+
+	ts, err := time.ParseInLocation(layout, s[:len(layout)], location)
+	if err != nil {
+		return time.Time{}, s, false
+	}
+
+	// rfc3164 timestamp has no year, so current is used
+	// but if timestamp is too far in future, it is from previous year
+	now := time.Now().In(location)
+	ts = ts.AddDate(now.Year(), 0, 0)
+	if ts.After(now.AddDate(0, 1, 0)) {
+		ts = ts.AddDate(-1, 0, 0)
+	}
+
+	return ts, s[len(layout)+1:], true
+}
+
+func nilOr(s string) string {
+	if s == nilValue {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,112 @@
+package syslog
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		raw     string
+		format  string
+		want    map[string]any
+		wantErr bool
+	}{
+		"rfc5424 full message": {
+			raw:    `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog 10 ID47 [exampleSDID@32473 iut="3" eventSource="Application"] An application event`,
+			format: "auto",
+			want: map[string]any{
+				"facility":      20,
+				"facility_name": "local4",
+				"severity":      5,
+				"severity_name": "notice",
+				"version":       1,
+				"timestamp":     "2003-10-11T22:14:15.003Z",
+				"hostname":      "mymachine.example.com",
+				"app_name":      "evntslog",
+				"proc_id":       "10",
+				"msg_id":        "ID47",
+				"message":       "An application event",
+				"structured_data": map[string]any{
+					"exampleSDID@32473": map[string]any{
+						"iut":         "3",
+						"eventSource": "Application",
+					},
+				},
+			},
+		},
+		"rfc5424 nil values and escaped sd": {
+			raw:    `<34>1 - - su - - [meta note="a \"quoted\" \] value"]`,
+			format: "rfc5424",
+			want: map[string]any{
+				"facility":      4,
+				"facility_name": "auth",
+				"severity":      2,
+				"severity_name": "crit",
+				"version":       1,
+				"app_name":      "su",
+				"structured_data": map[string]any{
+					"meta": map[string]any{
+						"note": `a "quoted" ] value`,
+					},
+				},
+			},
+		},
+		"rfc3164 with pid": {
+			raw:    `<13>Oct 11 22:14:15 mymachine sshd[1234]: Accepted password`,
+			format: "auto",
+			want: map[string]any{
+				"facility":      1,
+				"facility_name": "user",
+				"severity":      5,
+				"severity_name": "notice",
+				"hostname":      "mymachine",
+				"app_name":      "sshd",
+				"proc_id":       "1234",
+				"message":       "Accepted password",
+			},
+		},
+		"rfc3164 in strict rfc5424 mode": {
+			raw:     `<13>Oct 11 22:14:15 mymachine sshd[1234]: Accepted password`,
+			format:  "rfc5424",
+			wantErr: true,
+		},
+		"no pri": {
+			raw:     `just a line`,
+			format:  "auto",
+			wantErr: true,
+		},
+		"pri out of range": {
+			raw:     `<192>1 - - - - - -`,
+			format:  "auto",
+			wantErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			m, err := parse([]byte(test.raw), test.format, time.UTC)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := m.data()
+			// rfc3164 timestamp has no year, so it is not compared
+			if _, ok := test.want["version"]; !ok {
+				delete(got, "timestamp")
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("unexpected data\nwant: %#v\ngot:  %#v", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,351 @@
+package syslog
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/net/netutil"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+	"github.com/gekatateam/neptunus/plugins/common/ider"
+	pkgtls "github.com/gekatateam/neptunus/plugins/common/tls"
+)
+
+const maxUdpMessageSize = 65536
+
+type Syslog struct {
+	*core.BaseInput `mapstructure:"-"`
+	Address         string        `mapstructure:"address"`
+	Network         string        `mapstructure:"network"`
+	Framing         string        `mapstructure:"framing"`
+	Format          string        `mapstructure:"format"`
+	Timezone        string        `mapstructure:"timezone"`
+	KeepTimestamp   bool          `mapstructure:"keep_timestamp"`
+	MaxConnections  int           `mapstructure:"max_connections"`
+	MaxMessageSize  int           `mapstructure:"max_message_size"`
+	ReadTimeout     time.Duration `mapstructure:"read_timeout"`
+
+	*ider.Ider              `mapstructure:",squash"`
+	*pkgtls.TLSServerConfig `mapstructure:",squash"`
+
+	location   *time.Location
+	listener   net.Listener
+	packetConn net.PacketConn
+
+	conns map[net.Conn]struct{}
+	mu    *sync.Mutex
+	wg    *sync.WaitGroup
+}
+
+func (i *Syslog) Init() error {
+	if len(i.Address) == 0 {
+		return errors.New("address required")
+	}
+
+	switch i.Format {
+	case "auto", "rfc5424", "rfc3164":
+	default:
+		return fmt.Errorf("unknown format: %v, expected one of: auto, rfc5424, rfc3164", i.Format)
+	}
+
+	switch i.Framing {
+	case "auto", "octet_counting", "newline":
+	default:
+		return fmt.Errorf("unknown framing: %v, expected one of: auto, octet_counting, newline", i.Framing)
+	}
+
+	if i.MaxMessageSize <= 0 {
+		i.MaxMessageSize = maxUdpMessageSize
+	}
+
+	location, err := time.LoadLocation(i.Timezone)
+	if err != nil {
+		return fmt.Errorf("timezone loading failed: %w", err)
+	}
+	i.location = location
+
+	if err := i.Ider.Init(); err != nil {
+		return err
+	}
+
+	i.conns = make(map[net.Conn]struct{})
+	i.mu = &sync.Mutex{}
+	i.wg = &sync.WaitGroup{}
+
+	switch i.Network {
+	case "udp":
+		if i.TLSServerConfig.Enable {
+			return errors.New("TLS is not supported over udp")
+		}
+
+		conn, err := net.ListenPacket("udp", i.Address)
+		if err != nil {
+			return fmt.Errorf("error creating listener: %v", err)
+		}
+		i.packetConn = conn
+	case "tcp":
+		tlsConfig, err := i.TLSServerConfig.Config()
+		if err != nil {
+			return err
+		}
+
+		var listener net.Listener
+		if i.TLSServerConfig.Enable {
+			l, err := tls.Listen("tcp", i.Address, tlsConfig)
+			if err != nil {
+				return fmt.Errorf("error creating TLS listener: %v", err)
+			}
+			listener = l
+		} else {
+			l, err := net.Listen("tcp", i.Address)
+			if err != nil {
+				return fmt.Errorf("error creating listener: %v", err)
+			}
+			listener = l
+		}
+
+		if i.MaxConnections > 0 {
+			listener = netutil.LimitListener(listener, i.MaxConnections)
+			i.Log.Debug(fmt.Sprintf("listener is limited to %v simultaneous connections", i.MaxConnections))
+		}
+		i.listener = listener
+	default:
+		return fmt.Errorf("unknown network: %v, expected one of: udp, tcp", i.Network)
+	}
+
+	return nil
+}
+
+func (i *Syslog) Close() error {
+	if i.packetConn != nil {
+		return i.packetConn.Close()
+	}
+
+	err := i.listener.Close()
+
+	i.mu.Lock()
+	for conn := range i.conns {
+		conn.Close()
+	}
+	i.mu.Unlock()
+
+	return err
+}
+
+func (i *Syslog) Run() {
+	i.Log.Info(fmt.Sprintf("starting syslog %v server on %v", i.Network, i.Address))
+
+	if i.packetConn != nil {
+		i.serveUdp()
+	} else {
+		i.serveTcp()
+	}
+
+	i.Log.Info("stopping syslog server")
+}
+
+func (i *Syslog) serveUdp() {
+	buf := make([]byte, maxUdpMessageSize)
+	for {
+		n, addr, err := i.packetConn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			i.Log.Error("packet reading failed",
+				"error", err,
+			)
+			continue
+		}
+
+		i.produce(buf[:min(n, i.MaxMessageSize)], addr.String())
+	}
+}
+
+func (i *Syslog) serveTcp() {
+	for {
+		conn, err := i.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				break
+			}
+			i.Log.Error("connection accepting failed",
+				"error", err,
+			)
+			continue
+		}
+
+		i.mu.Lock()
+		i.conns[conn] = struct{}{}
+		i.mu.Unlock()
+
+		i.wg.Add(1)
+		go func() {
+			defer i.wg.Done()
+			i.handleConn(conn)
+
+			i.mu.Lock()
+			delete(i.conns, conn)
+			i.mu.Unlock()
+			conn.Close()
+		}()
+	}
+
+	i.wg.Wait()
+}
+
+func (i *Syslog) handleConn(conn net.Conn) {
+	sender := conn.RemoteAddr().String()
+	reader := bufio.NewReader(conn)
+
+	i.Log.Debug("connection accepted",
+		"sender", sender,
+	)
+
+	for {
+		if i.ReadTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(i.ReadTimeout))
+		}
+
+		frame, err := i.readFrame(reader)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				i.Log.Warn("connection closed",
+					"error", err,
+					"sender", sender,
+				)
+			}
+			return
+		}
+
+		if len(frame) > 0 {
+			i.produce(frame, sender)
+		}
+	}
+}
+
+// readFrame reads one message from stream
+// octet counting framing is "LEN SP MSG", rfc6587
+// in auto mode framing is detected by first byte of each frame
+func (i *Syslog) readFrame(r *bufio.Reader) ([]byte, error) {
+	framing := i.Framing
+	if framing == "auto" {
+		b, err := r.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+
+		if b[0] >= '1' && b[0] <= '9' {
+			framing = "octet_counting"
+		} else {
+			framing = "newline"
+		}
+	}
+
+	if framing == "octet_counting" {
+		rawLen, err := r.ReadString(' ')
+		if err != nil {
+			return nil, err
+		}
+
+		length, err := strconv.Atoi(rawLen[:len(rawLen)-1])
+		if err != nil || length <= 0 {
+			return nil, fmt.Errorf("invalid frame length: %q", rawLen)
+		}
+
+		if length > i.MaxMessageSize {
+			return nil, fmt.Errorf("frame length %v exceeds max message size", length)
+		}
+
+		frame := make([]byte, length)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return nil, err
+		}
+		return frame, nil
+	}
+
+	frame := []byte{}
+	for {
+		line, isPrefix, err := r.ReadLine()
+		if err != nil {
+			if errors.Is(err, io.EOF) && len(frame) > 0 {
+				return frame, nil
+			}
+			return nil, err
+		}
+
+		frame = append(frame, line...)
+		if len(frame) > i.MaxMessageSize {
+			return nil, errors.New("frame exceeds max message size")
+		}
+
+		if !isPrefix {
+			return frame, nil
+		}
+	}
+}
+
+func (i *Syslog) produce(frame []byte, sender string) {
+	now := time.Now()
+
+	m, err := parse(frame, i.Format, i.location)
+	if err != nil {
+		i.Log.Error("message parsing failed",
+			"error", err,
+			"sender", sender,
+		)
+		i.Observe(metrics.EventFailed, time.Since(now))
+		return
+	}
+
+	routingKey := "syslog"
+	if len(m.appName) > 0 {
+		routingKey = "syslog." + m.appName
+	}
+
+	event := core.NewEventWithData(routingKey, m.data())
+	if i.KeepTimestamp && !m.timestamp.IsZero() {
+		event.Timestamp = m.timestamp
+	}
+
+	event.SetLabel("server", i.Address)
+	event.SetLabel("sender", sender)
+	event.SetLabel("network", i.Network)
+
+	i.Ider.Apply(event)
+	i.Out <- event
+	i.Log.Debug("event accepted",
+		slog.Group("event",
+			"id", event.Id,
+			"key", event.RoutingKey,
+		),
+	)
+	i.Observe(metrics.EventAccepted, time.Since(now))
+}
+
+func init() {
+	plugins.AddInput("syslog", func() core.Input {
+		return &Syslog{
+			Address:         ":5514",
+			Network:         "udp",
+			Framing:         "auto",
+			Format:          "auto",
+			Timezone:        "Local",
+			KeepTimestamp:   true,
+			MaxConnections:  0,
+			MaxMessageSize:  maxUdpMessageSize,
+			Ider:            &ider.Ider{},
+			TLSServerConfig: &pkgtls.TLSServerConfig{},
+		}
+	})
+}
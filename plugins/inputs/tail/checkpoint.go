@@ -0,0 +1,215 @@
+package tail
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc64"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	orderedmap "github.com/wk8/go-ordered-map/v2"
+)
+
+// fingerprintSize is a number of first file bytes
+// used to check that checkpointed file is the same file after restart
+const fingerprintSize = 1024
+
+var crcTable = crc64.MakeTable(crc64.ISO)
+
+func fingerprint(head []byte) uint64 {
+	return crc64.Checksum(head, crcTable)
+}
+
+type checkpoint struct {
+	Offset      int64  `json:"offset"`
+	Fingerprint uint64 `json:"fingerprint"`
+
+	fileId uint64
+}
+
+func loadCheckpoints(path string) (map[string]checkpoint, error) {
+	checkpoints := make(map[string]checkpoint)
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return checkpoints, nil
+		}
+		return nil, fmt.Errorf("checkpoint file reading failed: %w", err)
+	}
+
+	if err := json.Unmarshal(raw, &checkpoints); err != nil {
+		return nil, fmt.Errorf("checkpoint file decoding failed: %w", err)
+	}
+
+	return checkpoints, nil
+}
+
+// saveCheckpoints writes checkpoints to temporary file and renames it,
+// so checkpoint file is never partially written
+func saveCheckpoints(path string, checkpoints map[string]checkpoint) error {
+	raw, err := json.Marshal(checkpoints)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+type trackedMessage struct {
+	fileId      uint64
+	path        string
+	offset      int64 // message end offset
+	fingerprint uint64
+	events      int
+	delivered   bool
+}
+
+type commitMessage struct {
+	fileId uint64
+	offset int64
+}
+
+// commitController tracks read messages and writes offsets of delivered ones to checkpoint file
+// like in kafka input, only a continuous sequence of delivered messages
+// from the queue beginning is committed
+type commitController struct {
+	checkpointFile   string
+	checkpoints      map[string]checkpoint
+	commitQueues     map[uint64]*orderedmap.OrderedMap[int64, *trackedMessage]
+	commitSemaphore  chan struct{} // max uncommitted control
+	exitIfQueueEmpty bool
+	commitInterval   time.Duration
+
+	fetchCh  chan *trackedMessage // for new messages to push in commitQueue
+	commitCh chan commitMessage   // for offsets that ready to be committed
+	exitCh   chan struct{}        // for exit preparation signal
+	doneCh   chan struct{}        // done signal, channel will be closed just before Run() returns
+
+	log *slog.Logger
+}
+
+func (c *commitController) Run() {
+	ticker := time.NewTicker(c.commitInterval)
+
+	for {
+		select {
+		case msg := <-c.fetchCh: // new message read
+			q, ok := c.commitQueues[msg.fileId]
+			if !ok {
+				q = orderedmap.New[int64, *trackedMessage]()
+				c.commitQueues[msg.fileId] = q
+			}
+			q.Store(msg.offset, msg)
+		case msg := <-c.commitCh: // an event delivered
+			q, ok := c.commitQueues[msg.fileId]
+			if !ok {
+				c.log.Error("unexpected case, delivered file is not in queue; please, report this issue")
+				continue
+			}
+
+			if m, ok := q.Get(msg.offset); ok {
+				m.events--
+				if m.events <= 0 {
+					m.delivered = true
+				}
+			} else { // normally it is never happens
+				c.log.Error("unexpected case, delivered offset is not in queue; please, report this issue")
+			}
+		case <-ticker.C: // it is time to commit offsets
+			c.commit()
+
+			if c.exitIfQueueEmpty && len(c.commitSemaphore) == 0 {
+				ticker.Stop()
+				close(c.doneCh)
+				return
+			}
+		case <-c.exitCh:
+			c.log.Info(fmt.Sprintf("left in queue: %v", len(c.commitSemaphore)))
+			c.exitIfQueueEmpty = true
+			if len(c.commitSemaphore) == 0 {
+				ticker.Stop()
+				c.commit()
+				close(c.doneCh)
+				return
+			}
+		}
+	}
+}
+
+func (c *commitController) commit() {
+	var changed bool
+	for fileId, q := range c.commitQueues {
+		var candidate *trackedMessage
+		for pair := q.Oldest(); pair != nil; pair = q.Oldest() {
+			if !pair.Value.delivered {
+				break
+			}
+			candidate = pair.Value
+			q.Delete(pair.Key)
+			//lint:ignore S1005 explicitly indicates reading from the channel, not waiting
+			_ = <-c.commitSemaphore
+		}
+
+		if q.Len() == 0 {
+			delete(c.commitQueues, fileId)
+		}
+
+		if candidate == nil {
+			continue
+		}
+
+		// rotated file may be committed after a new one with the same path
+		// newer files always have greater ids
+		if cp, ok := c.checkpoints[candidate.path]; ok && cp.fileId > candidate.fileId {
+			continue
+		}
+
+		c.checkpoints[candidate.path] = checkpoint{
+			Offset:      candidate.offset,
+			Fingerprint: candidate.fingerprint,
+			fileId:      candidate.fileId,
+		}
+		changed = true
+	}
+
+	if !changed {
+		return
+	}
+
+	// checkpoints of removed files are not needed anymore
+	for path := range c.checkpoints {
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			delete(c.checkpoints, path)
+		}
+	}
+
+	if err := saveCheckpoints(c.checkpointFile, c.checkpoints); err != nil {
+		c.log.Error("checkpoint saving failed",
+			"error", err,
+		)
+		return
+	}
+
+	c.log.Debug(fmt.Sprintf("checkpoint saved, left in queue: %v", len(c.commitSemaphore)))
+}
@@ -0,0 +1,120 @@
+package tail
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+type Multiline struct {
+	Pattern  string        `mapstructure:"pattern"`
+	Negate   bool          `mapstructure:"negate"`
+	Match    string        `mapstructure:"match"`
+	MaxLines int           `mapstructure:"max_lines"`
+	Timeout  time.Duration `mapstructure:"timeout"`
+}
+
+func (m *Multiline) Init() (*regexp.Regexp, error) {
+	switch m.Match {
+	case "after", "before":
+	default:
+		return nil, fmt.Errorf("unknown multiline match: %v, expected one of: after, before", m.Match)
+	}
+
+	if m.Timeout <= 0 {
+		return nil, errors.New("multiline timeout must be greater than zero")
+	}
+
+	re, err := regexp.Compile(m.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("multiline pattern compilation failed: %w", err)
+	}
+
+	return re, nil
+}
+
+// aggregator joins lines into multiline messages
+//
+// a line is a "continuation" if it matches pattern, or, if negate is true, if it does not
+// in "after" mode, continuations are appended to previous line
+// in "before" mode, continuations are prepended to next line
+type aggregator struct {
+	re       *regexp.Regexp
+	negate   bool
+	before   bool
+	maxLines int
+
+	buf     []byte
+	lines   int
+	offset  int64
+	updated time.Time
+}
+
+func newAggregator(re *regexp.Regexp, c Multiline) *aggregator {
+	return &aggregator{
+		re:       re,
+		negate:   c.Negate,
+		before:   c.Match == "before",
+		maxLines: c.MaxLines,
+	}
+}
+
+// add consumes a line that ends at offset
+// if a message is completed, it is returned with it's end offset
+// too long message is completed as is, even if next line is a continuation
+func (a *aggregator) add(line []byte, offset int64) ([]byte, int64, bool) {
+	continuation := a.re.Match(line) != a.negate
+
+	if a.before {
+		a.append(line, offset)
+		if !continuation || a.full() {
+			return a.flush()
+		}
+		return nil, 0, false
+	}
+
+	var (
+		msg []byte
+		end int64
+		ok  bool
+	)
+
+	if !continuation || a.full() {
+		msg, end, ok = a.flush()
+	}
+	a.append(line, offset)
+
+	return msg, end, ok
+}
+
+func (a *aggregator) append(line []byte, offset int64) {
+	if a.lines > 0 {
+		a.buf = append(a.buf, '\n')
+	}
+	a.buf = append(a.buf, line...)
+	a.lines++
+	a.offset = offset
+	a.updated = time.Now()
+}
+
+func (a *aggregator) full() bool {
+	return a.maxLines > 0 && a.lines >= a.maxLines
+}
+
+// flush returns buffered message, if any
+func (a *aggregator) flush() ([]byte, int64, bool) {
+	if a.lines == 0 {
+		return nil, 0, false
+	}
+
+	msg := a.buf
+	a.buf = nil
+	a.lines = 0
+	return msg, a.offset, true
+}
+
+// expired reports whether buffered message is waiting for longer than timeout
+func (a *aggregator) expired(timeout time.Duration) bool {
+	return a.lines > 0 && time.Since(a.updated) >= timeout
+}
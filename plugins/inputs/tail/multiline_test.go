@@ -0,0 +1,74 @@
+package tail
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAggregator(t *testing.T) {
+	tests := map[string]struct {
+		config Multiline
+		lines  []string
+		want   []string
+	}{
+		"indented continuations after": {
+			config: Multiline{Pattern: `^\s`, Match: "after"},
+			lines:  []string{"panic: oops", "  at main.go:1", "  at main.go:2", "next", "last"},
+			want:   []string{"panic: oops\n  at main.go:1\n  at main.go:2", "next", "last"},
+		},
+		"negated timestamp start after": {
+			config: Multiline{Pattern: `^\d{4}-`, Negate: true, Match: "after"},
+			lines:  []string{"2024-01-01 first", "trace 1", "trace 2", "2024-01-02 second"},
+			want:   []string{"2024-01-01 first\ntrace 1\ntrace 2", "2024-01-02 second"},
+		},
+		"backslash continuations before": {
+			config: Multiline{Pattern: `\\$`, Match: "before"},
+			lines:  []string{`a \`, `b \`, "c", "d"},
+			want:   []string{"a \\\nb \\\nc", "d"},
+		},
+		"max lines after": {
+			config: Multiline{Pattern: `^\s`, Match: "after", MaxLines: 2},
+			lines:  []string{"head", " 1", " 2", " 3"},
+			want:   []string{"head\n 1", " 2\n 3"},
+		},
+		"max lines before": {
+			config: Multiline{Pattern: `\\$`, Match: "before", MaxLines: 2},
+			lines:  []string{`a \`, `b \`, `c \`, "d"},
+			want:   []string{"a \\\nb \\", "c \\\nd"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.config.Timeout = 1
+			re, err := test.config.Init()
+			if err != nil {
+				t.Fatalf("unexpected init error: %v", err)
+			}
+
+			a := newAggregator(re, test.config)
+			got := []string{}
+			var lastOffset int64
+			for i, line := range test.lines {
+				if msg, end, ok := a.add([]byte(line), int64(i+1)); ok {
+					if end <= lastOffset {
+						t.Fatalf("message offset %v is not greater than previous %v", end, lastOffset)
+					}
+					lastOffset = end
+					got = append(got, string(msg))
+				}
+			}
+
+			if msg, end, ok := a.flush(); ok {
+				if end != int64(len(test.lines)) {
+					t.Fatalf("last message offset must be %v, got %v", len(test.lines), end)
+				}
+				got = append(got, string(msg))
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("unexpected messages\nwant: %q\ngot:  %q", test.want, got)
+			}
+		})
+	}
+}
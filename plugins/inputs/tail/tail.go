@@ -0,0 +1,299 @@
+package tail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	orderedmap "github.com/wk8/go-ordered-map/v2"
+	"kythe.io/kythe/go/util/datasize"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/plugins"
+	"github.com/gekatateam/neptunus/plugins/common/ider"
+)
+
+type Tail struct {
+	*core.BaseInput    `mapstructure:"-"`
+	Paths              []string      `mapstructure:"paths"`
+	StartPosition      string        `mapstructure:"start_position"`
+	CheckpointFile     string        `mapstructure:"checkpoint_file"`
+	CheckpointInterval time.Duration `mapstructure:"checkpoint_interval"`
+	ScanInterval       time.Duration `mapstructure:"scan_interval"`
+	PollInterval       time.Duration `mapstructure:"poll_interval"`
+	MaxLineSize        datasize.Size `mapstructure:"max_line_size"`
+	MaxUncommitted     int           `mapstructure:"max_uncommitted"`
+	Multiline          *Multiline    `mapstructure:"multiline"`
+	*ider.Ider         `mapstructure:",squash"`
+
+	multilineRe *regexp.Regexp
+	checkpoints map[string]checkpoint
+	fileId      *atomic.Uint64
+
+	tailers map[string]struct{}
+	mu      *sync.Mutex
+	wg      *sync.WaitGroup
+
+	commitSemaphore chan struct{}
+	fetchCh         chan *trackedMessage
+	commitCh        chan commitMessage
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	parser core.Parser
+}
+
+func (i *Tail) Init() error {
+	if len(i.Paths) == 0 {
+		return errors.New("at least one path required")
+	}
+
+	for _, p := range i.Paths {
+		if _, err := filepath.Match(p, ""); err != nil {
+			return fmt.Errorf("bad path pattern %v: %w", p, err)
+		}
+	}
+
+	if len(i.CheckpointFile) == 0 {
+		return errors.New("checkpoint_file required")
+	}
+
+	switch i.StartPosition {
+	case "beginning", "end":
+	default:
+		return fmt.Errorf("unknown start position: %v, expected one of: beginning, end", i.StartPosition)
+	}
+
+	if i.MaxLineSize <= 0 {
+		return errors.New("max_line_size must be greater than zero")
+	}
+
+	if i.MaxUncommitted <= 0 {
+		i.MaxUncommitted = 10_000
+	}
+
+	if i.ScanInterval <= 0 {
+		i.ScanInterval = 10 * time.Second
+	}
+
+	if i.PollInterval <= 0 {
+		i.PollInterval = 250 * time.Millisecond
+	}
+
+	if i.CheckpointInterval <= 0 {
+		i.CheckpointInterval = time.Second
+	}
+
+	if i.Multiline != nil && len(i.Multiline.Pattern) > 0 {
+		re, err := i.Multiline.Init()
+		if err != nil {
+			return err
+		}
+		i.multilineRe = re
+	}
+
+	if err := i.Ider.Init(); err != nil {
+		return err
+	}
+
+	checkpoints, err := loadCheckpoints(i.CheckpointFile)
+	if err != nil {
+		return err
+	}
+
+	i.Paths = slices.Compact(i.Paths)
+	i.checkpoints = checkpoints
+	i.fileId = &atomic.Uint64{}
+	i.tailers = make(map[string]struct{})
+	i.mu = &sync.Mutex{}
+	i.wg = &sync.WaitGroup{}
+
+	i.commitSemaphore = make(chan struct{}, i.MaxUncommitted)
+	i.fetchCh = make(chan *trackedMessage)
+	i.commitCh = make(chan commitMessage)
+
+	i.ctx, i.cancel = context.WithCancel(context.Background())
+
+	return nil
+}
+
+func (i *Tail) SetParser(p core.Parser) {
+	i.parser = p
+}
+
+func (i *Tail) Close() error {
+	i.cancel()
+
+	if err := i.parser.Close(); err != nil {
+		i.Log.Error("parser closed with error",
+			"error", err.Error(),
+		)
+	}
+
+	return nil
+}
+
+func (i *Tail) Run() {
+	exitCh, doneCh := make(chan struct{}), make(chan struct{})
+
+	// controller owns a copy of loaded checkpoints,
+	// and tailers read the original one only on files discovery
+	checkpoints := make(map[string]checkpoint, len(i.checkpoints))
+	for k, v := range i.checkpoints {
+		checkpoints[k] = v
+	}
+
+	commiter := &commitController{
+		checkpointFile:  i.CheckpointFile,
+		checkpoints:     checkpoints,
+		commitQueues:    make(map[uint64]*orderedmap.OrderedMap[int64, *trackedMessage]),
+		commitSemaphore: i.commitSemaphore,
+		commitInterval:  i.CheckpointInterval,
+
+		fetchCh:  i.fetchCh,
+		commitCh: i.commitCh,
+		exitCh:   exitCh,
+		doneCh:   doneCh,
+		log:      i.Log,
+	}
+	go commiter.Run()
+
+	i.Log.Info("starting files tailing")
+	i.scan(true)
+
+	ticker := time.NewTicker(i.ScanInterval)
+SCAN_LOOP:
+	for {
+		select {
+		case <-i.ctx.Done():
+			ticker.Stop()
+			break SCAN_LOOP
+		case <-ticker.C:
+			i.scan(false)
+		}
+	}
+
+	i.wg.Wait()
+	i.Log.Info("tailing stopped, waiting for events delivery")
+
+	exitCh <- struct{}{}
+	<-doneCh
+	i.Log.Info("commit queue is empty now, checkpoint saved")
+}
+
+// scan looks for new files that matches configured patterns
+// on first scan, files without checkpoint are read from configured start position
+// files that appears later are always read from beginning
+func (i *Tail) scan(first bool) {
+	for _, pattern := range i.Paths {
+		// pattern is validated on init, so there is no error possible
+		matches, _ := filepath.Glob(pattern)
+		for _, path := range matches {
+			if abs, err := filepath.Abs(path); err == nil {
+				path = abs
+			}
+
+			i.mu.Lock()
+			_, ok := i.tailers[path]
+			i.mu.Unlock()
+			if ok {
+				continue
+			}
+
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+
+			i.follow(path, first)
+		}
+	}
+}
+
+func (i *Tail) follow(path string, first bool) {
+	t := &tailer{
+		BaseInput:    i.BaseInput,
+		path:         path,
+		pollInterval: i.PollInterval,
+		maxLineSize:  int(i.MaxLineSize.Bytes()),
+		multilineRe:  i.multilineRe,
+		nextId:       func() uint64 { return i.fileId.Add(1) },
+		parser:       i.parser,
+		ider:         i.Ider,
+
+		commitSemaphore: i.commitSemaphore,
+		fetchCh:         i.fetchCh,
+		commitCh:        i.commitCh,
+	}
+
+	if i.multilineRe != nil {
+		t.multiline = *i.Multiline
+	}
+
+	if err := t.open(0); err != nil {
+		i.Log.Error(fmt.Sprintf("file %v opening failed", path),
+			"error", err,
+		)
+		return
+	}
+
+	var offset int64
+	if cp, ok := i.checkpoints[path]; ok && t.info.Size() >= cp.Offset && t.fingerprint(cp.Offset) == cp.Fingerprint {
+		offset = cp.Offset
+	} else if first && i.StartPosition == "end" {
+		offset = t.info.Size()
+	}
+
+	if offset > 0 {
+		if _, err := t.file.Seek(offset, io.SeekStart); err != nil {
+			t.file.Close()
+			i.Log.Error(fmt.Sprintf("file %v seeking failed", path),
+				"error", err,
+			)
+			return
+		}
+		t.offset = offset
+	}
+
+	i.mu.Lock()
+	i.tailers[path] = struct{}{}
+	i.mu.Unlock()
+
+	i.wg.Add(1)
+	go func() {
+		defer i.wg.Done()
+		t.Run(i.ctx)
+
+		i.mu.Lock()
+		delete(i.tailers, path)
+		i.mu.Unlock()
+	}()
+}
+
+func init() {
+	plugins.AddInput("tail", func() core.Input {
+		return &Tail{
+			StartPosition:      "end",
+			CheckpointInterval: time.Second,
+			ScanInterval:       10 * time.Second,
+			PollInterval:       250 * time.Millisecond,
+			MaxLineSize:        datasize.Mebibyte,
+			MaxUncommitted:     10_000,
+			Multiline: &Multiline{
+				Match:    "after",
+				MaxLines: 500,
+				Timeout:  5 * time.Second,
+			},
+			Ider: &ider.Ider{},
+		}
+	})
+}
@@ -0,0 +1,344 @@
+package tail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins/common/ider"
+)
+
+const readChunkSize = 64 * 1024
+
+// tailer follows one file path
+//
+// every opened file gets a new id, so rotated and truncated files
+// are tracked separately in commit queues
+type tailer struct {
+	*core.BaseInput
+
+	path         string
+	pollInterval time.Duration
+	maxLineSize  int
+	multiline    Multiline
+	multilineRe  *regexp.Regexp
+
+	nextId func() uint64
+	parser core.Parser
+	ider   *ider.Ider
+
+	commitSemaphore chan struct{}
+	fetchCh         chan *trackedMessage
+	commitCh        chan commitMessage
+
+	id         uint64
+	file       *os.File
+	info       os.FileInfo
+	offset     int64  // end offset of last consumed line
+	buf        []byte // read, but not consumed bytes
+	head       []byte // first file bytes for fingerprint
+	skipping   bool   // if true, too long line is skipped until newline
+	aggregator *aggregator
+	chunk      []byte
+}
+
+// open opens file and seeks it to offset
+func (t *tailer) open(offset int64) error {
+	file, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return err
+	}
+
+	t.id = t.nextId()
+	t.file = file
+	t.info = info
+	t.offset = offset
+	t.buf = t.buf[:0]
+	t.head = nil
+	t.skipping = false
+	if t.multilineRe != nil {
+		t.aggregator = newAggregator(t.multilineRe, t.multiline)
+	}
+
+	return nil
+}
+
+func (t *tailer) Run(ctx context.Context) {
+	t.Log.Info(fmt.Sprintf("tailing file %v from offset %v", t.path, t.offset))
+	defer t.file.Close()
+
+	t.chunk = make([]byte, readChunkSize)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			t.flush()
+			t.Log.Info(fmt.Sprintf("tailing file %v stopped", t.path))
+			return
+		case <-timer.C:
+		}
+
+		if t.read(ctx) {
+			timer.Reset(0)
+			continue
+		}
+
+		if t.aggregator != nil && t.aggregator.expired(t.multiline.Timeout) {
+			t.flush()
+		}
+
+		if !t.follow(ctx) {
+			t.Log.Info(fmt.Sprintf("file %v removed, tailing stopped", t.path))
+			return
+		}
+
+		timer.Reset(t.pollInterval)
+	}
+}
+
+// read reads next chunk and consumes all complete lines from it
+// it returns false if there is no new data
+func (t *tailer) read(ctx context.Context) bool {
+	n, err := t.file.Read(t.chunk)
+	if n == 0 {
+		if err != nil && !errors.Is(err, io.EOF) {
+			t.Log.Error(fmt.Sprintf("file %v reading failed", t.path),
+				"error", err,
+			)
+		}
+		return false
+	}
+
+	t.buf = append(t.buf, t.chunk[:n]...)
+
+	var consumed int
+	for ctx.Err() == nil {
+		i := bytes.IndexByte(t.buf[consumed:], '\n')
+		if i < 0 {
+			break
+		}
+
+		line := t.buf[consumed : consumed+i]
+		consumed += i + 1
+		t.offset += int64(i + 1)
+
+		if t.skipping {
+			t.skipping = false
+			continue
+		}
+
+		t.line(bytes.TrimSuffix(line, []byte{'\r'}))
+	}
+
+	// too long line is cut, and the rest of it is skipped
+	if rest := len(t.buf) - consumed; rest > t.maxLineSize {
+		if !t.skipping {
+			t.Log.Warn(fmt.Sprintf("line in file %v at offset %v exceeds max line size, it will be truncated", t.path, t.offset))
+			t.offset += int64(rest)
+			t.line(t.buf[consumed : consumed+t.maxLineSize])
+		} else {
+			t.offset += int64(rest)
+		}
+		consumed = len(t.buf)
+		t.skipping = true
+	}
+
+	t.buf = t.buf[:copy(t.buf, t.buf[consumed:])]
+	return true
+}
+
+// follow checks file rotation
+// if file is renamed, new file at the same path is opened from beginning
+// if file is truncated, it is read from beginning
+// it returns false if file path does not exist anymore
+func (t *tailer) follow(ctx context.Context) bool {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			t.Log.Error(fmt.Sprintf("file %v stat failed", t.path),
+				"error", err,
+			)
+			return true
+		}
+		t.drain(ctx)
+		return false
+	}
+
+	if !os.SameFile(info, t.info) {
+		t.drain(ctx)
+		t.file.Close()
+		if err := t.open(0); err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				t.Log.Error(fmt.Sprintf("rotated file %v opening failed", t.path),
+					"error", err,
+				)
+			}
+			return false
+		}
+		t.Log.Info(fmt.Sprintf("file %v rotated, reading new file from beginning", t.path))
+		return true
+	}
+
+	if info.Size() < t.offset+int64(len(t.buf)) {
+		t.flush()
+		if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+			t.Log.Error(fmt.Sprintf("truncated file %v seeking failed", t.path),
+				"error", err,
+			)
+			return true
+		}
+
+		t.id = t.nextId()
+		t.offset = 0
+		t.buf = t.buf[:0]
+		t.head = nil
+		t.skipping = false
+		t.Log.Info(fmt.Sprintf("file %v truncated, reading from beginning", t.path))
+	}
+
+	return true
+}
+
+// drain reads the rest of file, that is not followed anymore
+// last line is produced even if it is not terminated
+func (t *tailer) drain(ctx context.Context) {
+	for ctx.Err() == nil && t.read(ctx) {
+	}
+
+	if len(t.buf) > 0 && !t.skipping && ctx.Err() == nil {
+		t.offset += int64(len(t.buf))
+		t.line(bytes.TrimSuffix(t.buf, []byte{'\r'}))
+		t.buf = t.buf[:0]
+	}
+
+	t.flush()
+}
+
+func (t *tailer) line(line []byte) {
+	if t.aggregator == nil {
+		t.produce(line, t.offset)
+		return
+	}
+
+	if msg, end, ok := t.aggregator.add(line, t.offset); ok {
+		t.produce(msg, end)
+	}
+}
+
+// flush produces buffered multiline message, if any
+func (t *tailer) flush() {
+	if t.aggregator == nil {
+		return
+	}
+
+	if msg, end, ok := t.aggregator.flush(); ok {
+		t.produce(msg, end)
+	}
+}
+
+func (t *tailer) produce(data []byte, offset int64) {
+	now := time.Now()
+	fingerprint := t.fingerprint(offset)
+
+	events, err := t.parser.Parse(data, t.path)
+	if err != nil {
+		t.Log.Error("parser error, message marked as ready to be committed",
+			"error", err,
+			"path", t.path,
+			"offset", offset,
+		)
+
+		t.commitSemaphore <- struct{}{}
+		t.fetchCh <- &trackedMessage{
+			fileId:      t.id,
+			path:        t.path,
+			offset:      offset,
+			fingerprint: fingerprint,
+			events:      0,
+			delivered:   true, // if parser fails, commit message
+		}
+
+		t.Observe(metrics.EventFailed, time.Since(now))
+		return
+	}
+
+	t.commitSemaphore <- struct{}{}
+	t.fetchCh <- &trackedMessage{
+		fileId:      t.id,
+		path:        t.path,
+		offset:      offset,
+		fingerprint: fingerprint,
+		events:      len(events),
+		delivered:   len(events) == 0, // if parser returns zero events, commit message
+	}
+
+	fileId := t.id
+	for _, e := range events {
+		e.SetLabel("path", t.path)
+
+		e.AddHook(func() {
+			t.commitCh <- commitMessage{
+				fileId: fileId,
+				offset: offset,
+			}
+		})
+
+		t.ider.Apply(e)
+		t.Out <- e
+		t.Log.Debug("event accepted",
+			slog.Group("event",
+				"id", e.Id,
+				"key", e.RoutingKey,
+			),
+		)
+		t.Observe(metrics.EventAccepted, time.Since(now))
+		now = time.Now()
+	}
+}
+
+// fingerprint returns checksum of first file bytes, up to offset
+// head is re-read until it reaches fingerprint size
+func (t *tailer) fingerprint(offset int64) uint64 {
+	if len(t.head) < fingerprintSize && int64(len(t.head)) < offset {
+		head, err := readHead(t.file)
+		if err != nil {
+			t.Log.Warn(fmt.Sprintf("file %v head reading failed", t.path),
+				"error", err,
+			)
+		}
+		t.head = head
+	}
+
+	return fingerprint(t.head[:min(int64(len(t.head)), offset)])
+}
+
+func readHead(file *os.File) ([]byte, error) {
+	head := make([]byte, fingerprintSize)
+	n, err := file.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return head[:n], err
+	}
+	return head[:n], nil
+}
@@ -3,5 +3,6 @@ package keykeepers
 import (
 	_ "github.com/gekatateam/neptunus/plugins/keykeepers/env"
 	_ "github.com/gekatateam/neptunus/plugins/keykeepers/fs"
+	_ "github.com/gekatateam/neptunus/plugins/keykeepers/sops"
 	_ "github.com/gekatateam/neptunus/plugins/keykeepers/vault"
 )
@@ -2,11 +2,16 @@ package env
 
 import (
 	"os"
+	"regexp"
+	"strings"
 
 	"github.com/gekatateam/neptunus/core"
 	"github.com/gekatateam/neptunus/plugins"
+	"github.com/gekatateam/neptunus/plugins/common/document"
 )
 
+var docPathPattern = regexp.MustCompile(`^[a-zA-Z0-9_\-]+(\.[a-zA-Z0-9_\-]+)*$`)
+
 type Fs struct {
 	*core.BaseKeykeeper `mapstructure:"-"`
 }
@@ -15,19 +20,62 @@ func (k *Fs) Init() error {
 	return nil
 }
 
-func (k *Fs) Get(path string) (any, error) {
+// key request is a file path, optionally followed by a dotted path in a structured document,
+// e.g. "/etc/secrets.yaml#kafka.password"
+func (k *Fs) Get(key string) (any, error) {
+	path, docPath, structured := cutDocPath(key)
+
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	return string(content), nil
+	if !structured {
+		return string(content), nil
+	}
+
+	format, err := document.Format(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := document.Unmarshal(content, format)
+	if err != nil {
+		return nil, err
+	}
+
+	return document.Get(doc, docPath)
 }
 
 func (k *Fs) Close() error {
 	return nil
 }
 
+// cutDocPath splits key request by the last "#"
+// key is used as a plain file path if such file exists,
+// or if suffix is not a dotted path, or if file is not a known document
+func cutDocPath(key string) (string, string, bool) {
+	i := strings.LastIndex(key, "#")
+	if i < 0 {
+		return key, "", false
+	}
+
+	if _, err := os.Stat(key); err == nil {
+		return key, "", false
+	}
+
+	path, docPath := key[:i], key[i+1:]
+	if !docPathPattern.MatchString(docPath) {
+		return key, "", false
+	}
+
+	if _, err := document.Format(path); err != nil {
+		return key, "", false
+	}
+
+	return path, docPath, true
+}
+
 func init() {
 	plugins.AddKeykeeper("fs", func() core.Keykeeper {
 		return &Fs{}
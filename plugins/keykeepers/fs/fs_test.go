@@ -0,0 +1,87 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFs_Get(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"secret":           "plain-secret",
+		"secret#1":         "hash-in-name",
+		"secrets.yaml":     "kafka:\n  password: yaml-secret\nbrokers:\n  - broker-1\n",
+		"secrets.json":     `{"kafka":{"password":"json-secret"}}`,
+		"secrets.env":      "KAFKA_PASSWORD=env-secret\n",
+		"secrets.yaml#raw": "raw-with-doc-ext",
+		"key#not a path":   "space-after-hash",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[string]struct {
+		key     string
+		want    any
+		wantErr bool
+	}{
+		"plain-file": {
+			key:  "secret",
+			want: "plain-secret",
+		},
+		"plain-file-with-hash": {
+			key:  "secret#1",
+			want: "hash-in-name",
+		},
+		"existing-file-wins": {
+			key:  "secrets.yaml#raw",
+			want: "raw-with-doc-ext",
+		},
+		"suffix-is-not-doc-path": {
+			key:  "key#not a path",
+			want: "space-after-hash",
+		},
+		"yaml-lookup": {
+			key:  "secrets.yaml#kafka.password",
+			want: "yaml-secret",
+		},
+		"yaml-list-lookup": {
+			key:  "secrets.yaml#brokers.0",
+			want: "broker-1",
+		},
+		"json-lookup": {
+			key:  "secrets.json#kafka.password",
+			want: "json-secret",
+		},
+		"dotenv-lookup": {
+			key:  "secrets.env#KAFKA_PASSWORD",
+			want: "env-secret",
+		},
+		"missing-doc-key": {
+			key:     "secrets.json#kafka.username",
+			wantErr: true,
+		},
+		"missing-file": {
+			key:     "missing#1",
+			wantErr: true,
+		},
+	}
+
+	k := &Fs{}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := k.Get(filepath.Join(dir, test.key))
+			if (err != nil) != test.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !test.wantErr && got != test.want {
+				t.Fatalf("unexpected value, want: %v, got: %v", test.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,471 @@
+package sops
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"github.com/goccy/go-yaml"
+
+	"github.com/gekatateam/neptunus/plugins/common/document"
+)
+
+var (
+	encValuePattern   = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.*),tag:(.*),type:(.*)\]$`)
+	encCommentPattern = regexp.MustCompile(`(?m)^\s*#\s*ENC\[`)
+)
+
+// sops stores encryption metadata in "sops" key of a document,
+// or in "sops_" prefixed keys in dotenv documents
+const (
+	sopsMetadataKey         = "sops"
+	sopsDotenvPrefix        = "sops_"
+	sopsDotenvAgeKey        = "sops_age__list_%v__map_enc"
+	sopsDotenvMac           = "sops_mac"
+	sopsDotenvLastModified  = "sops_lastmodified"
+	sopsDotenvMacOnlyEncKey = "sops_mac_only_encrypted"
+)
+
+// item is a key-value pair of a document map
+// maps are decoded into ordered lists of items, because sops MAC
+// is calculated over values in the order they appear in a document
+type item struct {
+	key   string
+	value any
+}
+
+type branch []item
+
+type metadata struct {
+	encKeys          []string
+	mac              string
+	lastModified     string
+	macOnlyEncrypted bool
+}
+
+// decryptSops decrypts values of a document encrypted by sops
+// data key is decrypted using age identities
+//
+// each value is encrypted with AES256-GCM, and value path in a document
+// is used as additional data, so values can not be moved between keys
+//
+// then document MAC is verified, so values can not be added, removed or changed,
+// including unencrypted ones; documents without MAC are rejected
+func decryptSops(content []byte, format string, identities []age.Identity) (any, error) {
+	// sops encrypts comments too, but they are lost on decoding
+	// so MAC of such documents can not be verified
+	if format != document.FormatJson && encCommentPattern.Match(content) {
+		return nil, errors.New("encrypted comments are not supported")
+	}
+
+	root, err := unmarshalOrdered(content, format)
+	if err != nil {
+		return nil, err
+	}
+
+	root, meta, err := sopsMetadata(root, format)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := sopsDataKey(meta.encKeys, identities)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &decryptor{
+		key:              dataKey,
+		hash:             sha512.New(),
+		macOnlyEncrypted: meta.macOnlyEncrypted,
+	}
+
+	if _, err := d.walk(root, nil); err != nil {
+		return nil, err
+	}
+
+	if err := d.verify(meta); err != nil {
+		return nil, err
+	}
+
+	return plain(root), nil
+}
+
+func sopsMetadata(root branch, format string) (branch, *metadata, error) {
+	meta := &metadata{}
+	data := make(branch, 0, len(root))
+
+	if format == document.FormatDotenv {
+		vars := make(map[string]any)
+		for _, i := range root {
+			if strings.HasPrefix(i.key, sopsDotenvPrefix) {
+				vars[i.key] = i.value
+				continue
+			}
+			data = append(data, i)
+		}
+
+		for i := 0; ; i++ {
+			enc, ok := vars[fmt.Sprintf(sopsDotenvAgeKey, i)].(string)
+			if !ok {
+				break
+			}
+			meta.encKeys = append(meta.encKeys, strings.ReplaceAll(enc, `\n`, "\n"))
+		}
+
+		meta.mac, _ = vars[sopsDotenvMac].(string)
+		meta.lastModified, _ = vars[sopsDotenvLastModified].(string)
+		meta.macOnlyEncrypted = vars[sopsDotenvMacOnlyEncKey] == "true"
+	} else {
+		var found bool
+		for _, i := range root {
+			if i.key != sopsMetadataKey {
+				data = append(data, i)
+				continue
+			}
+
+			m, ok := plain(i.value).(map[string]any)
+			if !ok {
+				return nil, nil, errors.New("sops metadata is not a map")
+			}
+			found = true
+
+			recipients, _ := m["age"].([]any)
+			for _, r := range recipients {
+				recipient, ok := r.(map[string]any)
+				if !ok {
+					continue
+				}
+
+				if enc, ok := recipient["enc"].(string); ok {
+					meta.encKeys = append(meta.encKeys, enc)
+				}
+			}
+
+			meta.mac, _ = m["mac"].(string)
+			switch t := m["lastmodified"].(type) {
+			case string:
+				meta.lastModified = t
+			case time.Time:
+				meta.lastModified = t.UTC().Format(time.RFC3339)
+			}
+			meta.macOnlyEncrypted, _ = m["mac_only_encrypted"].(bool)
+		}
+
+		if !found {
+			return nil, nil, errors.New("sops metadata not found")
+		}
+	}
+
+	if len(meta.encKeys) == 0 {
+		return nil, nil, errors.New("no age recipients found in sops metadata")
+	}
+
+	return data, meta, nil
+}
+
+func sopsDataKey(encKeys []string, identities []age.Identity) ([]byte, error) {
+	var lastErr error
+	for _, enc := range encKeys {
+		r, err := age.Decrypt(armor.NewReader(strings.NewReader(strings.TrimSpace(enc))), identities...)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		return io.ReadAll(r)
+	}
+
+	return nil, fmt.Errorf("data key decryption failed: %w", lastErr)
+}
+
+type decryptor struct {
+	key              []byte
+	hash             hash.Hash
+	macOnlyEncrypted bool
+}
+
+// walk decrypts values in place and feeds them to MAC hash in document order
+func (d *decryptor) walk(node any, path []string) (any, error) {
+	switch n := node.(type) {
+	case branch:
+		for i := range n {
+			value, err := d.walk(n[i].value, append(path, n[i].key))
+			if err != nil {
+				return nil, err
+			}
+			n[i].value = value
+		}
+		return n, nil
+	case []any:
+		// sops does not add list indexes to value path
+		for i := range n {
+			value, err := d.walk(n[i], path)
+			if err != nil {
+				return nil, err
+			}
+			n[i] = value
+		}
+		return n, nil
+	default:
+		value, encrypted := any(n), false
+		if s, ok := n.(string); ok && strings.HasPrefix(s, "ENC[") {
+			decrypted, err := decryptValue(s, strings.Join(path, ":")+":", d.key)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %w", strings.Join(path, "."), err)
+			}
+			value, encrypted = decrypted, true
+		}
+
+		if !d.macOnlyEncrypted || encrypted {
+			b, err := macBytes(value)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %w", strings.Join(path, "."), err)
+			}
+			d.hash.Write(b)
+		}
+
+		return value, nil
+	}
+}
+
+// document MAC is an uppercase hex SHA512 of all values,
+// encrypted with last modification time as additional data
+func (d *decryptor) verify(meta *metadata) error {
+	if len(meta.mac) == 0 {
+		return errors.New("document MAC not found in sops metadata")
+	}
+
+	mac, err := decryptValue(meta.mac, meta.lastModified, d.key)
+	if err != nil {
+		return fmt.Errorf("document MAC decryption failed: %w", err)
+	}
+
+	if mac != strings.ToUpper(hex.EncodeToString(d.hash.Sum(nil))) {
+		return errors.New("document MAC mismatch")
+	}
+
+	return nil
+}
+
+// macBytes returns value representation used by sops for MAC calculation
+func macBytes(value any) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	case int:
+		return []byte(strconv.Itoa(v)), nil
+	case float64:
+		return []byte(strconv.FormatFloat(v, 'f', -1, 64)), nil
+	case bool:
+		if v {
+			return []byte("True"), nil
+		}
+		return []byte("False"), nil
+	case nil:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported value type: %T", v)
+	}
+}
+
+func decryptValue(value, additionalData string, key []byte) (any, error) {
+	match := encValuePattern.FindStringSubmatch(value)
+	if len(match) != 5 {
+		return nil, errors.New("encrypted value does not match the pattern")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(match[1])
+	if err != nil {
+		return nil, fmt.Errorf("data decoding failed: %w", err)
+	}
+
+	iv, err := base64.StdEncoding.DecodeString(match[2])
+	if err != nil {
+		return nil, fmt.Errorf("iv decoding failed: %w", err)
+	}
+
+	tag, err := base64.StdEncoding.DecodeString(match[3])
+	if err != nil {
+		return nil, fmt.Errorf("tag decoding failed: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, err
+	}
+
+	plain, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return nil, fmt.Errorf("value decryption failed: %w", err)
+	}
+
+	switch t := match[4]; t {
+	case "str":
+		return string(plain), nil
+	case "int":
+		return strconv.Atoi(string(plain))
+	case "float":
+		return strconv.ParseFloat(string(plain), 64)
+	case "bool":
+		return strconv.ParseBool(string(plain))
+	case "bytes":
+		return plain, nil
+	default:
+		return nil, fmt.Errorf("unknown value type: %v", t)
+	}
+}
+
+// unmarshalOrdered decodes document keeping maps keys order
+func unmarshalOrdered(data []byte, format string) (branch, error) {
+	var root any
+
+	switch format {
+	case document.FormatJson:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+
+		var err error
+		if root, err = jsonValue(dec); err != nil {
+			return nil, err
+		}
+	case document.FormatYaml:
+		var doc any
+		if err := yaml.UnmarshalWithOptions(data, &doc, yaml.UseOrderedMap()); err != nil {
+			return nil, err
+		}
+		root = yamlValue(doc)
+	case document.FormatDotenv:
+		vars, err := document.UnmarshalDotenvVars(data)
+		if err != nil {
+			return nil, err
+		}
+
+		b := make(branch, 0, len(vars))
+		for _, v := range vars {
+			b = append(b, item{key: v.Key, value: v.Value})
+		}
+		root = b
+	default:
+		return nil, fmt.Errorf("unknown document format: %v, expected one of: json, yaml, dotenv", format)
+	}
+
+	b, ok := root.(branch)
+	if !ok {
+		return nil, errors.New("document root is not a map")
+	}
+
+	return b, nil
+}
+
+func jsonValue(dec *json.Decoder) (any, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			b := branch{}
+			for dec.More() {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				value, err := jsonValue(dec)
+				if err != nil {
+					return nil, err
+				}
+
+				b = append(b, item{key: key.(string), value: value})
+			}
+			_, err := dec.Token() // closing delimiter
+			return b, err
+		case '[':
+			l := []any{}
+			for dec.More() {
+				value, err := jsonValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				l = append(l, value)
+			}
+			_, err := dec.Token() // closing delimiter
+			return l, err
+		default:
+			return nil, fmt.Errorf("unexpected delimiter: %v", t)
+		}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return int(i), nil
+		}
+		return t.Float64()
+	default:
+		return t, nil
+	}
+}
+
+func yamlValue(node any) any {
+	switch n := node.(type) {
+	case yaml.MapSlice:
+		b := make(branch, 0, len(n))
+		for _, i := range n {
+			b = append(b, item{key: fmt.Sprint(i.Key), value: yamlValue(i.Value)})
+		}
+		return b
+	case []any:
+		for i := range n {
+			n[i] = yamlValue(n[i])
+		}
+		return n
+	case uint64:
+		return int(n)
+	case int64:
+		return int(n)
+	default:
+		return n
+	}
+}
+
+// plain converts ordered maps into regular ones
+func plain(node any) any {
+	switch n := node.(type) {
+	case branch:
+		m := make(map[string]any, len(n))
+		for _, i := range n {
+			m[i.key] = plain(i.value)
+		}
+		return m
+	case []any:
+		l := make([]any, len(n))
+		for i := range n {
+			l[i] = plain(n[i])
+		}
+		return l
+	default:
+		return n
+	}
+}
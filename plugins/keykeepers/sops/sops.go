@@ -0,0 +1,116 @@
+package sops
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/plugins"
+	"github.com/gekatateam/neptunus/plugins/common/document"
+)
+
+type Sops struct {
+	*core.BaseKeykeeper `mapstructure:"-"`
+	File                string `mapstructure:"file"`
+	Format              string `mapstructure:"format"` // json, yaml, dotenv
+	Mode                string `mapstructure:"mode"`   // sops, age
+	KeyFile             string `mapstructure:"key_file"`
+
+	doc any
+}
+
+func (k *Sops) Init() error {
+	if len(k.File) == 0 {
+		return errors.New("file required")
+	}
+
+	if len(k.KeyFile) == 0 {
+		return errors.New("key_file required")
+	}
+
+	if len(k.Format) == 0 {
+		format, err := document.Format(k.File)
+		if err != nil {
+			return fmt.Errorf("format not set and can not be detected: %w", err)
+		}
+		k.Format = format
+	}
+
+	keys, err := os.Open(k.KeyFile)
+	if err != nil {
+		return fmt.Errorf("key file reading failed: %w", err)
+	}
+	defer keys.Close()
+
+	identities, err := age.ParseIdentities(keys)
+	if err != nil {
+		return fmt.Errorf("key file parsing failed: %w", err)
+	}
+
+	content, err := os.ReadFile(k.File)
+	if err != nil {
+		return fmt.Errorf("file reading failed: %w", err)
+	}
+
+	switch k.Mode {
+	case "sops":
+		doc, err := decryptSops(content, k.Format, identities)
+		if err != nil {
+			return fmt.Errorf("file decryption failed: %w", err)
+		}
+		k.doc = doc
+	case "age":
+		plain, err := decryptAge(content, identities)
+		if err != nil {
+			return fmt.Errorf("file decryption failed: %w", err)
+		}
+
+		doc, err := document.Unmarshal(plain, k.Format)
+		if err != nil {
+			return fmt.Errorf("file unmarshaling failed: %w", err)
+		}
+		k.doc = doc
+	default:
+		return fmt.Errorf("unknown mode: %v, expected one of: sops, age", k.Mode)
+	}
+
+	return nil
+}
+
+func (k *Sops) Get(key string) (any, error) {
+	return document.Get(k.doc, key)
+}
+
+func (k *Sops) Close() error {
+	k.doc = nil
+	return nil
+}
+
+// decryptAge decrypts whole file encrypted with age, armored or binary
+func decryptAge(content []byte, identities []age.Identity) ([]byte, error) {
+	var src io.Reader = bytes.NewReader(content)
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte(armor.Header)) {
+		src = armor.NewReader(bytes.NewReader(bytes.TrimSpace(content)))
+	}
+
+	r, err := age.Decrypt(src, identities...)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(r)
+}
+
+func init() {
+	plugins.AddKeykeeper("sops", func() core.Keykeeper {
+		return &Sops{
+			Mode: "sops",
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package sops
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+)
+
+// testdata files are encrypted for the age identity from testdata/keys.txt
+func TestSops_Get(t *testing.T) {
+	tests := map[string]struct {
+		file string
+		mode string
+		keys map[string]any
+	}{
+		"sops-yaml": {
+			file: "testdata/secrets.enc.yaml",
+			mode: "sops",
+			keys: map[string]any{
+				"kafka.sasl.username": "neptunus",
+				"kafka.sasl.password": "s3cr3t",
+				"kafka.brokers.1":     "broker-2:9092",
+				"retries":             3,
+				"ratio":               0.5,
+				"enabled":             true,
+				"owner_unencrypted":   "platform",
+			},
+		},
+		"sops-json": {
+			file: "testdata/secrets.enc.json",
+			mode: "sops",
+			keys: map[string]any{
+				"kafka.sasl.username": "neptunus",
+				"kafka.sasl.password": "s3cr3t",
+				"kafka.brokers.0":     "broker-1:9092",
+				"retries":             3,
+				"ratio":               0.5,
+				"enabled":             true,
+				"owner_unencrypted":   "platform",
+			},
+		},
+		"sops-dotenv": {
+			file: "testdata/secrets.enc.env",
+			mode: "sops",
+			keys: map[string]any{
+				"KAFKA_USERNAME":    "neptunus",
+				"KAFKA_PASSWORD":    "s3cr3t",
+				"OWNER_unencrypted": "platform",
+			},
+		},
+		"age-json": {
+			file: "testdata/secrets.json.age",
+			mode: "age",
+			keys: map[string]any{
+				"kafka.sasl.password": "s3cr3t",
+				"kafka.brokers.1":     "broker-2:9092",
+				"retries":             float64(3),
+				"enabled":             true,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			k := newSops(test.file, test.mode, "testdata/keys.txt")
+			if err := k.Init(); err != nil {
+				t.Fatalf("init failed: %v", err)
+			}
+			defer k.Close()
+
+			for key, want := range test.keys {
+				got, err := k.Get(key)
+				if err != nil {
+					t.Fatalf("%v: get failed: %v", key, err)
+				}
+
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("%v: unexpected value, want: %#v, got: %#v", key, want, got)
+				}
+			}
+
+			if _, err := k.Get("sops"); err == nil {
+				t.Fatal("sops metadata must not be accessible")
+			}
+		})
+	}
+}
+
+func TestSops_InitFailed(t *testing.T) {
+	tests := map[string]struct {
+		file    string
+		tamper  func(string) string
+		keyFile bool
+		wantErr string
+	}{
+		"wrong-identity": {
+			file:    "testdata/secrets.enc.yaml",
+			tamper:  func(s string) string { return s },
+			keyFile: true,
+			wantErr: "data key decryption failed",
+		},
+		"unencrypted-value-changed": {
+			file: "testdata/secrets.enc.yaml",
+			tamper: func(s string) string {
+				return strings.Replace(s, `owner_unencrypted: "platform"`, `owner_unencrypted: "intruder"`, 1)
+			},
+			wantErr: "document MAC mismatch",
+		},
+		"value-removed": {
+			file: "testdata/secrets.enc.json",
+			tamper: func(s string) string {
+				return regexp.MustCompile(`(?m)^\s*"ratio": .*\n`).ReplaceAllString(s, "")
+			},
+			wantErr: "document MAC mismatch",
+		},
+		"values-swapped": {
+			file: "testdata/secrets.enc.env",
+			tamper: func(s string) string {
+				user := regexp.MustCompile(`(?m)^KAFKA_USERNAME=(.*)$`).FindStringSubmatch(s)[1]
+				pass := regexp.MustCompile(`(?m)^KAFKA_PASSWORD=(.*)$`).FindStringSubmatch(s)[1]
+				s = strings.Replace(s, "KAFKA_USERNAME="+user, "KAFKA_USERNAME="+pass, 1)
+				return strings.Replace(s, "KAFKA_PASSWORD="+pass, "KAFKA_PASSWORD="+user, 1)
+			},
+			wantErr: "value decryption failed",
+		},
+		"mac-removed": {
+			file: "testdata/secrets.enc.env",
+			tamper: func(s string) string {
+				return regexp.MustCompile(`(?m)^sops_mac=.*\n`).ReplaceAllString(s, "")
+			},
+			wantErr: "document MAC not found",
+		},
+		"encrypted-comment": {
+			file: "testdata/secrets.enc.yaml",
+			tamper: func(s string) string {
+				return "#ENC[AES256_GCM,data:AAAA,iv:AAAA,tag:AAAA,type:comment]\n" + s
+			},
+			wantErr: "encrypted comments are not supported",
+		},
+		"no-metadata": {
+			file: "testdata/secrets.enc.yaml",
+			tamper: func(s string) string {
+				return s[:strings.Index(s, "sops:")]
+			},
+			wantErr: "sops metadata not found",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			content, err := os.ReadFile(test.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			file := filepath.Join(dir, filepath.Base(test.file))
+			if err := os.WriteFile(file, []byte(test.tamper(string(content))), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			keyFile := "testdata/keys.txt"
+			if test.keyFile {
+				id, err := age.GenerateX25519Identity()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				keyFile = filepath.Join(dir, "keys.txt")
+				if err := os.WriteFile(keyFile, []byte(id.String()+"\n"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err = newSops(file, "sops", keyFile).Init()
+			if err == nil {
+				t.Fatal("init succeeded, but error expected")
+			}
+
+			if !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("unexpected error, want: %v, got: %v", test.wantErr, err)
+			}
+		})
+	}
+}
+
+func newSops(file, mode, keyFile string) *Sops {
+	return &Sops{
+		BaseKeykeeper: &core.BaseKeykeeper{Log: logger.Mock()},
+		File:          file,
+		Mode:          mode,
+		KeyFile:       keyFile,
+	}
+}
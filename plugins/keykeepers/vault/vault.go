@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"os"
 	"regexp"
+	"sync"
+	"time"
 
 	vault "github.com/hashicorp/vault-client-go"
 	"github.com/hashicorp/vault-client-go/schema"
@@ -16,22 +18,40 @@ import (
 	pkgtls "github.com/gekatateam/neptunus/plugins/common/tls"
 )
 
+const revokeTimeout = 10 * time.Second
+
 var secretKeyPattern = regexp.MustCompile(`([a-zA-Z0-9_\-\./]+)#([a-zA-Z0-9_\-\.]+)`)
 
 type Vault struct {
 	*core.BaseKeykeeper `mapstructure:"-"`
-	Address             string  `mapstructure:"address"`
-	MountPath           string  `mapstructure:"mount_path"`
-	PathPrefix          string  `mapstructure:"path_prefix"` // e.g. dev/, test/, prod/
-	KvVersion           string  `mapstructure:"kv_version"`  // v1, v2
-	Namespace           string  `mapstructure:"namespace"`
-	Approle             Approle `mapstructure:"approle"`
-	K8s                 K8s     `mapstructure:"k8s"`
+	Address             string        `mapstructure:"address"`
+	MountPath           string        `mapstructure:"mount_path"`
+	PathPrefix          string        `mapstructure:"path_prefix"` // e.g. dev/, test/, prod/
+	Engine              string        `mapstructure:"engine"`      // kv, dynamic
+	KvVersion           string        `mapstructure:"kv_version"`  // v1, v2
+	RefreshInterval     time.Duration `mapstructure:"refresh_interval"`
+	Namespace           string        `mapstructure:"namespace"`
+	Approle             Approle       `mapstructure:"approle"`
+	K8s                 K8s           `mapstructure:"k8s"`
 
 	*pkgtls.TLSClientConfig `mapstructure:",squash"`
 
 	client     *vault.Client
-	secretFunc func(path, secret string) (any, error)
+	secretFunc func(path, secret string) (any, time.Duration, error)
+
+	leases map[string]*lease
+	mu     *sync.Mutex
+}
+
+// lease is a dynamic secret read result
+// all keys of one secret path shares one lease
+// because each read of dynamic secret generates new credentials
+type lease struct {
+	id        string
+	data      map[string]any
+	renewable bool
+	ttl       time.Duration // initially granted lease duration
+	expiresAt time.Time
 }
 
 type Approle struct {
@@ -55,15 +75,29 @@ func (k *Vault) Init() error {
 		return errors.New("mount_path required")
 	}
 
-	switch k.KvVersion {
-	case "v1":
-		k.secretFunc = k.readV1
-	case "v2":
-		k.secretFunc = k.readV2
+	if k.RefreshInterval < 0 {
+		k.RefreshInterval = 0
+	}
+
+	switch k.Engine {
+	case "kv":
+		switch k.KvVersion {
+		case "v1":
+			k.secretFunc = k.readV1
+		case "v2":
+			k.secretFunc = k.readV2
+		default:
+			return fmt.Errorf("unknown kv engine version: %v", k.KvVersion)
+		}
+	case "dynamic":
+		k.secretFunc = k.readDynamic
 	default:
-		return fmt.Errorf("unknown kv engine version: %v", k.KvVersion)
+		return fmt.Errorf("unknown engine: %v, expected one of: kv, dynamic", k.Engine)
 	}
 
+	k.leases = make(map[string]*lease)
+	k.mu = &sync.Mutex{}
+
 	tlscfg, err := k.TLSClientConfig.Config()
 	if err != nil {
 		return err
@@ -128,9 +162,14 @@ CLIENT_AUTH_SUCCESS:
 }
 
 func (k *Vault) Get(key string) (any, error) {
+	val, _, err := k.Lease(key)
+	return val, err
+}
+
+func (k *Vault) Lease(key string) (any, time.Duration, error) {
 	match := secretKeyPattern.FindStringSubmatch(key)
 	if len(match) != 3 {
-		return nil, errors.New("key request does not match the pattern")
+		return nil, 0, errors.New("key request does not match the pattern")
 	}
 
 	path, secret := match[1], match[2]
@@ -141,45 +180,138 @@ func (k *Vault) Get(key string) (any, error) {
 	return k.secretFunc(path, secret)
 }
 
-func (k *Vault) readV2(path, secret string) (any, error) {
+func (k *Vault) readV2(path, secret string) (any, time.Duration, error) {
 	response, err := k.client.Secrets.KvV2Read(context.Background(), path,
 		vault.WithMountPath(k.MountPath),
 		vault.WithNamespace(k.Namespace),
 	)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	if _, ok := response.Data.Data[secret]; !ok {
-		return nil, fmt.Errorf("secret not found: %v#%v", path, secret)
+		return nil, 0, fmt.Errorf("secret not found: %v#%v", path, secret)
 	}
 
-	return response.Data.Data[secret], nil
+	return response.Data.Data[secret], k.RefreshInterval, nil
 }
 
-func (k *Vault) readV1(path, secret string) (any, error) {
+func (k *Vault) readV1(path, secret string) (any, time.Duration, error) {
 	response, err := k.client.Secrets.KvV1Read(context.Background(), path,
 		vault.WithMountPath(k.MountPath),
 		vault.WithNamespace(k.Namespace),
 	)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	if _, ok := response.Data[secret]; !ok {
-		return nil, fmt.Errorf("secret not found: %v#%v", path, secret)
+		return nil, 0, fmt.Errorf("secret not found: %v#%v", path, secret)
+	}
+
+	return response.Data[secret], k.RefreshInterval, nil
+}
+
+// dynamic secrets are cached by path and renewed while it is possible
+// new credentials are requested when lease can not be renewed anymore
+// and less than a third of it's duration left
+func (k *Vault) readDynamic(path, secret string) (any, time.Duration, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	l, ok := k.leases[path]
+	if ok {
+		k.renew(l)
+	}
+
+	if !ok || time.Until(l.expiresAt) < l.ttl/3 {
+		response, err := k.client.Read(context.Background(), k.MountPath+"/"+path,
+			vault.WithNamespace(k.Namespace),
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		l = &lease{
+			id:        response.LeaseID,
+			data:      response.Data,
+			renewable: response.Renewable,
+			ttl:       time.Duration(response.LeaseDuration) * time.Second,
+			expiresAt: time.Now().Add(time.Duration(response.LeaseDuration) * time.Second),
+		}
+
+		// secrets without lease are not cached
+		if len(l.id) > 0 && l.ttl > 0 {
+			k.leases[path] = l
+		}
+	}
+
+	if _, ok := l.data[secret]; !ok {
+		return nil, 0, fmt.Errorf("secret not found: %v#%v", path, secret)
+	}
+
+	return l.data[secret], max(time.Until(l.expiresAt), 0), nil
+}
+
+// lease renews when less than two thirds of it's duration left
+func (k *Vault) renew(l *lease) {
+	if !l.renewable || time.Until(l.expiresAt) > l.ttl*2/3 {
+		return
+	}
+
+	response, err := k.client.System.LeasesRenewLease(context.Background(), schema.LeasesRenewLeaseRequest{
+		LeaseId: l.id,
+	}, vault.WithNamespace(k.Namespace))
+	if err != nil {
+		k.Log.Warn("lease renewal failed",
+			"error", err,
+			"lease_id", l.id,
+		)
+		return
 	}
 
-	return response.Data[secret], nil
+	l.renewable = response.Renewable
+	l.expiresAt = time.Now().Add(time.Duration(response.LeaseDuration) * time.Second)
+	k.Log.Debug("lease renewed",
+		"lease_id", l.id,
+		"lease_duration", response.LeaseDuration,
+	)
 }
 
+// all cached leases are revoked on close, because keykeeper is closed
+// only after all plugins that use it's secrets are closed
 func (k *Vault) Close() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for path, l := range k.leases {
+		ctx, cancel := context.WithTimeout(context.Background(), revokeTimeout)
+		_, err := k.client.System.LeasesRevokeLease(ctx, schema.LeasesRevokeLeaseRequest{
+			LeaseId: l.id,
+		}, vault.WithNamespace(k.Namespace))
+		cancel()
+
+		if err != nil {
+			k.Log.Warn("lease revocation failed",
+				"error", err,
+				"lease_id", l.id,
+			)
+		} else {
+			k.Log.Debug("lease revoked",
+				"lease_id", l.id,
+			)
+		}
+
+		delete(k.leases, path)
+	}
+
 	return nil
 }
 
 func init() {
 	plugins.AddKeykeeper("vault", func() core.Keykeeper {
 		return &Vault{
+			Engine:    "kv",
 			KvVersion: "v2",
 			Approle: Approle{
 				MountPath: "approle",
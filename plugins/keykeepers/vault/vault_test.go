@@ -0,0 +1,124 @@
+package vault
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	pkgtls "github.com/gekatateam/neptunus/plugins/common/tls"
+)
+
+type fakeVault struct {
+	mu      sync.Mutex
+	reads   int
+	revoked []string
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/v1/auth/approle/login":
+		json.NewEncoder(w).Encode(map[string]any{
+			"data": nil,
+			"auth": map[string]any{"client_token": "token"},
+		})
+	case "/v1/database/creds/reader", "/v1/database/creds/writer":
+		f.reads++
+		json.NewEncoder(w).Encode(map[string]any{
+			"lease_id":       r.URL.Path[4:],
+			"renewable":      true,
+			"lease_duration": 3600,
+			"data":           map[string]any{"username": "user", "password": "pass"},
+		})
+	case "/v1/sys/leases/revoke":
+		body := map[string]any{}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.revoked = append(f.revoked, body["lease_id"].(string))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestVault_Close(t *testing.T) {
+	tests := map[string]struct {
+		keys        []string
+		wantReads   int
+		wantRevoked []string
+	}{
+		"no-leases": {
+			keys:        nil,
+			wantReads:   0,
+			wantRevoked: nil,
+		},
+		"one-path-shares-lease": {
+			keys:        []string{"creds/reader#username", "creds/reader#password"},
+			wantReads:   1,
+			wantRevoked: []string{"database/creds/reader"},
+		},
+		"each-path-revoked": {
+			keys:        []string{"creds/reader#username", "creds/writer#username"},
+			wantReads:   2,
+			wantRevoked: []string{"database/creds/reader", "database/creds/writer"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := &fakeVault{}
+			s := httptest.NewServer(f)
+			defer s.Close()
+
+			k := &Vault{
+				BaseKeykeeper:   &core.BaseKeykeeper{Log: logger.Mock()},
+				Address:         s.URL,
+				MountPath:       "database",
+				Engine:          "dynamic",
+				Approle:         Approle{MountPath: "approle", RoleId: "role", SecretId: "secret"},
+				TLSClientConfig: &pkgtls.TLSClientConfig{},
+			}
+
+			if err := k.Init(); err != nil {
+				t.Fatalf("init failed: %v", err)
+			}
+
+			for _, key := range test.keys {
+				val, ttl, err := k.Lease(key)
+				if err != nil {
+					t.Fatalf("lease failed: %v", err)
+				}
+
+				if val != "user" && val != "pass" {
+					t.Fatalf("unexpected value: %v", val)
+				}
+
+				if ttl <= 0 {
+					t.Fatalf("unexpected ttl: %v", ttl)
+				}
+			}
+
+			k.Close()
+
+			slices.Sort(f.revoked)
+			if f.reads != test.wantReads {
+				t.Fatalf("unexpected reads, want: %v, got: %v", test.wantReads, f.reads)
+			}
+
+			if !slices.Equal(f.revoked, test.wantRevoked) {
+				t.Fatalf("unexpected revoked leases, want: %v, got: %v", test.wantRevoked, f.revoked)
+			}
+
+			if len(k.leases) != 0 {
+				t.Fatalf("leases cache is not empty: %v", len(k.leases))
+			}
+		})
+	}
+}
@@ -9,8 +9,13 @@ import (
 	_ "github.com/gekatateam/neptunus/plugins/outputs/http"
 	_ "github.com/gekatateam/neptunus/plugins/outputs/kafka"
 	_ "github.com/gekatateam/neptunus/plugins/outputs/log"
+	_ "github.com/gekatateam/neptunus/plugins/outputs/mqtt"
+	_ "github.com/gekatateam/neptunus/plugins/outputs/nats"
 	_ "github.com/gekatateam/neptunus/plugins/outputs/opensearch"
 	_ "github.com/gekatateam/neptunus/plugins/outputs/rabbitmq"
+	_ "github.com/gekatateam/neptunus/plugins/outputs/redis"
+	_ "github.com/gekatateam/neptunus/plugins/outputs/s3"
 	_ "github.com/gekatateam/neptunus/plugins/outputs/sql"
+	_ "github.com/gekatateam/neptunus/plugins/outputs/stdout"
 	_ "github.com/gekatateam/neptunus/plugins/outputs/telegram"
 )
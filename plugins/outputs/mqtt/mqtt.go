@@ -0,0 +1,210 @@
+package mqtt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+	"github.com/gekatateam/neptunus/plugins/common/batcher"
+	common "github.com/gekatateam/neptunus/plugins/common/mqtt"
+	"github.com/gekatateam/neptunus/plugins/common/retryer"
+	pkgtls "github.com/gekatateam/neptunus/plugins/common/tls"
+)
+
+var ErrNotPublished = errors.New("not published")
+
+type Mqtt struct {
+	*core.BaseOutput `mapstructure:"-"`
+	Qos              int               `mapstructure:"qos"`
+	Retain           bool              `mapstructure:"retain"`
+	PublishTimeout   time.Duration     `mapstructure:"publish_timeout"`
+	PropertyLabels   map[string]string `mapstructure:"propertylabels"`
+
+	*common.Client                `mapstructure:",squash"`
+	*batcher.Batcher[*core.Event] `mapstructure:",squash"`
+	*retryer.Retryer              `mapstructure:",squash"`
+
+	conn common.Conn
+	ser  core.Serializer
+}
+
+type eventPublishing struct {
+	event *core.Event
+	msg   *common.Message
+	err   error
+	dur   time.Duration
+}
+
+func (o *Mqtt) Init() error {
+	if o.Qos < 0 || o.Qos > 2 {
+		return fmt.Errorf("unknown QoS: %v; expected one of: 0, 1, 2", o.Qos)
+	}
+
+	if o.Batcher.Buffer < 1 {
+		o.Batcher.Buffer = 1
+	}
+
+	conn, err := o.Client.Connect(o.Log, nil)
+	if err != nil {
+		return err
+	}
+
+	o.conn = conn
+	return nil
+}
+
+func (o *Mqtt) SetSerializer(s core.Serializer) {
+	o.ser = s
+}
+
+func (o *Mqtt) Close() error {
+	o.ser.Close()
+	o.conn.Disconnect(o.ConnectTimeout)
+	return nil
+}
+
+func (o *Mqtt) Run() {
+	o.Batcher.Run(o.In, func(buf []*core.Event) {
+		if len(buf) == 0 {
+			return
+		}
+
+		pubs := make([]eventPublishing, 0, len(buf))
+		for _, e := range buf {
+			now := time.Now()
+			event, err := o.ser.Serialize(e)
+			if err != nil {
+				o.Log.Error("serialization failed, event skipped",
+					"error", err,
+					slog.Group("event",
+						"id", e.Id,
+						"key", e.RoutingKey,
+					),
+				)
+				o.Done <- e
+				o.Observe(metrics.EventFailed, time.Since(now))
+				continue
+			}
+
+			msg := &common.Message{
+				Topic:    e.RoutingKey,
+				Payload:  event,
+				Qos:      byte(o.Qos),
+				Retained: o.Retain,
+			}
+
+			for property, label := range o.PropertyLabels {
+				if v, ok := e.GetLabel(label); ok {
+					msg.Properties = append(msg.Properties, common.Property{Key: property, Value: v})
+				}
+			}
+
+			pubs = append(pubs, eventPublishing{
+				event: e,
+				msg:   msg,
+				err:   ErrNotPublished,
+				dur:   time.Since(now),
+			})
+		}
+
+		if len(pubs) == 0 {
+			o.Log.Warn("nothing to produce")
+			return
+		}
+
+		now := time.Now()
+		o.Retryer.Do("publish", o.Log, func() error {
+			return o.publish(pubs)
+		})
+		dur := durationPerEvent(time.Since(now), len(pubs))
+
+		for _, pub := range pubs {
+			o.Done <- pub.event
+			if pub.err != nil {
+				o.Log.Error("event produce failed",
+					"error", pub.err,
+					slog.Group("event",
+						"id", pub.event.Id,
+						"key", pub.event.RoutingKey,
+					),
+				)
+				o.Observe(metrics.EventFailed, pub.dur+dur)
+			} else {
+				o.Log.Debug("event produced",
+					slog.Group("event",
+						"id", pub.event.Id,
+						"key", pub.event.RoutingKey,
+					),
+				)
+				o.Observe(metrics.EventAccepted, pub.dur+dur)
+			}
+		}
+	})
+}
+
+// publish sends all not yet published messages and waits for their completion
+// with QoS 1 and 2 publishing is completed when broker acknowledges a message
+// only failed messages will be published again
+func (o *Mqtt) publish(pubs []eventPublishing) error {
+	ctx, cancel := context.WithTimeout(context.Background(), o.PublishTimeout)
+	defer cancel()
+
+	waits := make(map[int]func() error, len(pubs))
+	for i, pub := range pubs {
+		if pub.err == nil {
+			continue
+		}
+		waits[i] = o.conn.Publish(ctx, pub.msg)
+	}
+
+	hasErrors := false
+	for i, wait := range waits {
+		pubs[i].err = wait()
+		if pubs[i].err != nil {
+			hasErrors = true
+		}
+	}
+
+	if hasErrors {
+		return errors.New("not all messages published successfully")
+	}
+
+	return nil
+}
+
+func durationPerEvent(totalTime time.Duration, batchSize int) time.Duration {
+	return time.Duration(int64(totalTime) / int64(batchSize))
+}
+
+func init() {
+	plugins.AddOutput("mqtt", func() core.Output {
+		return &Mqtt{
+			Qos:            1,
+			Retain:         false,
+			PublishTimeout: 10 * time.Second,
+			Client: &common.Client{
+				Brokers:              []string{"tcp://localhost:1883"},
+				ClientId:             "neptunus.mqtt.output",
+				ProtocolVersion:      "3.1.1",
+				CleanSession:         true,
+				KeepAlive:            30 * time.Second,
+				ConnectTimeout:       10 * time.Second,
+				MaxReconnectInterval: time.Minute,
+				TLSClientConfig:      &pkgtls.TLSClientConfig{},
+			},
+			Batcher: &batcher.Batcher[*core.Event]{
+				Buffer:   100,
+				Interval: 5 * time.Second,
+			},
+			Retryer: &retryer.Retryer{
+				RetryAttempts: 0,
+				RetryAfter:    5 * time.Second,
+			},
+		}
+	})
+}
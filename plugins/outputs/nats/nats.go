@@ -0,0 +1,181 @@
+package nats
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+	"github.com/gekatateam/neptunus/plugins/common/batcher"
+	common "github.com/gekatateam/neptunus/plugins/common/nats"
+	"github.com/gekatateam/neptunus/plugins/common/retryer"
+	pkgtls "github.com/gekatateam/neptunus/plugins/common/tls"
+)
+
+type Nats struct {
+	*core.BaseOutput `mapstructure:"-"`
+	Mode             string            `mapstructure:"mode"`
+	PublishTimeout   time.Duration     `mapstructure:"publish_timeout"`
+	KeepMessageId    bool              `mapstructure:"keep_message_id"`
+	HeaderLabels     map[string]string `mapstructure:"headerlabels"`
+
+	*common.Connection            `mapstructure:",squash"`
+	*batcher.Batcher[*core.Event] `mapstructure:",squash"`
+	*retryer.Retryer              `mapstructure:",squash"`
+
+	conn natsConn
+	js   jetstream.JetStream
+	ser  core.Serializer
+}
+
+func (o *Nats) Init() error {
+	switch o.Mode {
+	case "core", "jetstream":
+	default:
+		return fmt.Errorf("unknown mode: %v; expected one of: core, jetstream", o.Mode)
+	}
+
+	if o.Batcher.Buffer < 1 {
+		o.Batcher.Buffer = 1
+	}
+
+	conn, err := o.Connection.Connect(o.Log)
+	if err != nil {
+		return fmt.Errorf("connection failed: %w", err)
+	}
+	o.conn = conn
+
+	if o.Mode == "jetstream" {
+		js, err := jetstream.New(conn, jetstream.WithPublishAsyncMaxPending(o.Batcher.Buffer))
+		if err != nil {
+			o.conn.Close()
+			return fmt.Errorf("jetstream context creation failed: %w", err)
+		}
+		o.js = js
+	}
+
+	return nil
+}
+
+func (o *Nats) SetSerializer(s core.Serializer) {
+	o.ser = s
+}
+
+func (o *Nats) Close() error {
+	o.ser.Close()
+	o.conn.Close()
+	return nil
+}
+
+func (o *Nats) Run() {
+	o.Batcher.Run(o.In, func(buf []*core.Event) {
+		if len(buf) == 0 {
+			return
+		}
+
+		pubs := make([]eventPublishing, 0, len(buf))
+		for _, e := range buf {
+			now := time.Now()
+			event, err := o.ser.Serialize(e)
+			if err != nil {
+				o.Log.Error("serialization failed, event skipped",
+					"error", err,
+					slog.Group("event",
+						"id", e.Id,
+						"key", e.RoutingKey,
+					),
+				)
+				o.Done <- e
+				o.Observe(metrics.EventFailed, time.Since(now))
+				continue
+			}
+
+			msg := nats.NewMsg(e.RoutingKey)
+			msg.Data = event
+			for header, label := range o.HeaderLabels {
+				if v, ok := e.GetLabel(label); ok {
+					msg.Header.Set(header, v)
+				}
+			}
+
+			if o.KeepMessageId {
+				msg.Header.Set(jetstream.MsgIDHeader, e.Id)
+			}
+
+			pubs = append(pubs, eventPublishing{
+				msg:   msg,
+				event: e,
+				err:   ErrNotPublished,
+				dur:   time.Since(now),
+			})
+		}
+
+		if len(pubs) == 0 {
+			o.Log.Warn("nothing to produce")
+			return
+		}
+
+		now := time.Now()
+		if o.Mode == "jetstream" {
+			o.Retryer.Do("publish to jetstream", o.Log, func() error {
+				return o.publishJetStream(pubs)
+			})
+		} else {
+			o.Retryer.Do("publish and flush", o.Log, func() error {
+				return o.publishCore(pubs)
+			})
+		}
+		dur := durationPerEvent(time.Since(now), len(pubs))
+
+		for _, pub := range pubs {
+			o.Done <- pub.event
+			if pub.err != nil {
+				o.Log.Error("event produce failed",
+					"error", pub.err,
+					slog.Group("event",
+						"id", pub.event.Id,
+						"key", pub.event.RoutingKey,
+					),
+				)
+				o.Observe(metrics.EventFailed, pub.dur+dur)
+			} else {
+				o.Log.Debug("event produced",
+					slog.Group("event",
+						"id", pub.event.Id,
+						"key", pub.event.RoutingKey,
+					),
+				)
+				o.Observe(metrics.EventAccepted, pub.dur+dur)
+			}
+		}
+	})
+}
+
+func init() {
+	plugins.AddOutput("nats", func() core.Output {
+		return &Nats{
+			Mode:           "core",
+			PublishTimeout: 10 * time.Second,
+			Connection: &common.Connection{
+				Servers:         []string{nats.DefaultURL},
+				ConnectionName:  "neptunus.nats.output",
+				DialTimeout:     10 * time.Second,
+				ReconnectWait:   5 * time.Second,
+				TLSClientConfig: &pkgtls.TLSClientConfig{},
+			},
+			Batcher: &batcher.Batcher[*core.Event]{
+				Buffer:   100,
+				Interval: 5 * time.Second,
+			},
+			Retryer: &retryer.Retryer{
+				RetryAttempts: 0,
+				RetryAfter:    5 * time.Second,
+			},
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/gekatateam/neptunus/core"
+)
+
+var (
+	ErrNotPublished = errors.New("not published")
+	ErrNotAcked     = errors.New("publish ack not received in time")
+)
+
+// natsConn is a part of *nats.Conn used by core publisher
+type natsConn interface {
+	PublishMsg(m *nats.Msg) error
+	FlushTimeout(timeout time.Duration) error
+	Close()
+}
+
+type eventPublishing struct {
+	msg   *nats.Msg
+	event *core.Event
+	err   error
+	dur   time.Duration
+}
+
+// core NATS has no publish acknowledgements, so publishing is considered successful
+// if connection buffer is flushed to server
+// if flush failed, all messages published in this attempt will be published again
+func (o *Nats) publishCore(pubs []eventPublishing) error {
+	var published []int
+	for i, pub := range pubs {
+		if pub.err == nil {
+			continue
+		}
+
+		if err := o.conn.PublishMsg(pub.msg); err != nil {
+			pubs[i].err = err
+			continue
+		}
+
+		pubs[i].err = nil
+		published = append(published, i)
+	}
+
+	if err := o.conn.FlushTimeout(o.PublishTimeout); err != nil {
+		for _, i := range published {
+			pubs[i].err = fmt.Errorf("flush failed: %w", err)
+		}
+	}
+
+	return failedOrNil(pubs)
+}
+
+// in JetStream mode, each message must be acked by server
+// only failed and unacked messages will be published again
+func (o *Nats) publishJetStream(pubs []eventPublishing) error {
+	ctx, cancel := context.WithTimeout(context.Background(), o.PublishTimeout)
+	defer cancel()
+
+	futures := make(map[int]jetstream.PubAckFuture, len(pubs))
+	for i, pub := range pubs {
+		if pub.err == nil {
+			continue
+		}
+
+		future, err := o.js.PublishMsgAsync(pub.msg)
+		if err != nil {
+			pubs[i].err = err
+			continue
+		}
+		futures[i] = future
+	}
+
+	for i, future := range futures {
+		select {
+		case <-future.Ok():
+			pubs[i].err = nil
+		case err := <-future.Err():
+			pubs[i].err = err
+		case <-ctx.Done():
+			pubs[i].err = ErrNotAcked
+		}
+	}
+
+	return failedOrNil(pubs)
+}
+
+func failedOrNil(pubs []eventPublishing) error {
+	for _, pub := range pubs {
+		if pub.err != nil {
+			return errors.New("not all messages published successfully")
+		}
+	}
+	return nil
+}
+
+func durationPerEvent(totalTime time.Duration, batchSize int) time.Duration {
+	return time.Duration(int64(totalTime) / int64(batchSize))
+}
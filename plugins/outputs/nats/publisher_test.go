@@ -0,0 +1,196 @@
+package nats
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	"github.com/gekatateam/neptunus/core"
+)
+
+type attempt struct {
+	fail          map[string]string // subject -> failure kind
+	failFlush     bool
+	wantPublished []string
+	wantFailed    []string
+}
+
+type mockConn struct {
+	attempt   *attempt
+	published []string
+}
+
+func (c *mockConn) PublishMsg(m *nats.Msg) error {
+	c.published = append(c.published, m.Subject)
+	if c.attempt.fail[m.Subject] == "publish" {
+		return errors.New("publish failed")
+	}
+	return nil
+}
+
+func (c *mockConn) FlushTimeout(time.Duration) error {
+	if c.attempt.failFlush {
+		return errors.New("flush failed")
+	}
+	return nil
+}
+
+func (c *mockConn) Close() {}
+
+type mockFuture struct {
+	msg *nats.Msg
+	ok  chan *jetstream.PubAck
+	err chan error
+}
+
+func (f *mockFuture) Ok() <-chan *jetstream.PubAck { return f.ok }
+func (f *mockFuture) Err() <-chan error            { return f.err }
+func (f *mockFuture) Msg() *nats.Msg               { return f.msg }
+
+// only async publishing is used by output
+type mockJetStream struct {
+	jetstream.JetStream
+	attempt   *attempt
+	published []string
+}
+
+func (js *mockJetStream) PublishMsgAsync(m *nats.Msg, _ ...jetstream.PublishOpt) (jetstream.PubAckFuture, error) {
+	js.published = append(js.published, m.Subject)
+	f := &mockFuture{msg: m, ok: make(chan *jetstream.PubAck, 1), err: make(chan error, 1)}
+
+	switch js.attempt.fail[m.Subject] {
+	case "publish":
+		return nil, errors.New("publish failed")
+	case "nack":
+		f.err <- errors.New("nacked by server")
+	case "timeout":
+	default:
+		f.ok <- &jetstream.PubAck{}
+	}
+
+	return f, nil
+}
+
+func testPublishings(subjects ...string) []eventPublishing {
+	pubs := make([]eventPublishing, 0, len(subjects))
+	for _, s := range subjects {
+		pubs = append(pubs, eventPublishing{
+			msg:   nats.NewMsg(s),
+			event: core.NewEvent(s),
+			err:   ErrNotPublished,
+		})
+	}
+	return pubs
+}
+
+func failedSubjects(pubs []eventPublishing) []string {
+	var failed []string
+	for _, pub := range pubs {
+		if pub.err != nil {
+			failed = append(failed, pub.msg.Subject)
+		}
+	}
+	return failed
+}
+
+func TestPublishCore(t *testing.T) {
+	tests := map[string]struct {
+		attempts []*attempt
+	}{
+		"all-published": {
+			attempts: []*attempt{
+				{wantPublished: []string{"a", "b", "c"}},
+			},
+		},
+		"failed-publish-retried-alone": {
+			attempts: []*attempt{
+				{fail: map[string]string{"b": "publish"}, wantPublished: []string{"a", "b", "c"}, wantFailed: []string{"b"}},
+				{wantPublished: []string{"b"}},
+			},
+		},
+		"failed-flush-retries-attempt-messages": {
+			attempts: []*attempt{
+				{fail: map[string]string{"a": "publish"}, failFlush: true, wantPublished: []string{"a", "b", "c"}, wantFailed: []string{"a", "b", "c"}},
+				{fail: map[string]string{"a": "publish"}, wantPublished: []string{"a", "b", "c"}, wantFailed: []string{"a"}},
+				{wantPublished: []string{"a"}},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			conn := &mockConn{}
+			o := &Nats{conn: conn, PublishTimeout: time.Second}
+			pubs := testPublishings("a", "b", "c")
+
+			for n, a := range test.attempts {
+				conn.attempt, conn.published = a, nil
+
+				err := o.publishCore(pubs)
+				if (err != nil) != (len(a.wantFailed) > 0) {
+					t.Fatalf("attempt %v: unexpected error: %v", n, err)
+				}
+
+				if !slices.Equal(conn.published, a.wantPublished) {
+					t.Fatalf("attempt %v: unexpected published, want: %v, got: %v", n, a.wantPublished, conn.published)
+				}
+
+				if failed := failedSubjects(pubs); !slices.Equal(failed, a.wantFailed) {
+					t.Fatalf("attempt %v: unexpected failed, want: %v, got: %v", n, a.wantFailed, failed)
+				}
+			}
+		})
+	}
+}
+
+func TestPublishJetStream(t *testing.T) {
+	tests := map[string]struct {
+		attempts []*attempt
+	}{
+		"all-acked": {
+			attempts: []*attempt{
+				{wantPublished: []string{"a", "b", "c"}},
+			},
+		},
+		"failed-and-unacked-retried": {
+			attempts: []*attempt{
+				{fail: map[string]string{"a": "publish", "b": "nack", "c": "timeout"}, wantPublished: []string{"a", "b", "c"}, wantFailed: []string{"a", "b", "c"}},
+				{fail: map[string]string{"c": "timeout"}, wantPublished: []string{"a", "b", "c"}, wantFailed: []string{"c"}},
+				{wantPublished: []string{"c"}},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			js := &mockJetStream{}
+			o := &Nats{js: js, PublishTimeout: 50 * time.Millisecond}
+			pubs := testPublishings("a", "b", "c")
+
+			for n, a := range test.attempts {
+				js.attempt, js.published = a, nil
+
+				err := o.publishJetStream(pubs)
+				if (err != nil) != (len(a.wantFailed) > 0) {
+					t.Fatalf("attempt %v: unexpected error: %v", n, err)
+				}
+
+				if !slices.Equal(js.published, a.wantPublished) {
+					t.Fatalf("attempt %v: unexpected published, want: %v, got: %v", n, a.wantPublished, js.published)
+				}
+
+				if failed := failedSubjects(pubs); !slices.Equal(failed, a.wantFailed) {
+					t.Fatalf("attempt %v: unexpected failed, want: %v, got: %v", n, a.wantFailed, failed)
+				}
+
+				if a.fail["c"] == "timeout" && !errors.Is(pubs[2].err, ErrNotAcked) {
+					t.Fatalf("attempt %v: unexpected unacked message error: %v", n, pubs[2].err)
+				}
+			}
+		})
+	}
+}
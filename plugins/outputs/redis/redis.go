@@ -0,0 +1,238 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+	"github.com/gekatateam/neptunus/plugins/common/batcher"
+	common "github.com/gekatateam/neptunus/plugins/common/redis"
+	"github.com/gekatateam/neptunus/plugins/common/retryer"
+	"github.com/gekatateam/neptunus/plugins/common/tls"
+)
+
+var ErrNotPublished = errors.New("not published")
+
+type Redis struct {
+	*core.BaseOutput `mapstructure:"-"`
+	Mode             string            `mapstructure:"mode"`
+	DataField        string            `mapstructure:"data_field"`
+	MaxLen           int64             `mapstructure:"max_len"`
+	Approximate      bool              `mapstructure:"approximate"`
+	FieldLabels      map[string]string `mapstructure:"fieldlabels"`
+
+	*common.Redis                 `mapstructure:",squash"`
+	*batcher.Batcher[*core.Event] `mapstructure:",squash"`
+	*retryer.Retryer              `mapstructure:",squash"`
+
+	client redis.UniversalClient
+	ser    core.Serializer
+}
+
+type eventPublishing struct {
+	event *core.Event
+	data  []byte
+	err   error
+	dur   time.Duration
+}
+
+func (o *Redis) Init() error {
+	switch o.Mode {
+	case "streams":
+		if len(o.DataField) == 0 {
+			return errors.New("data_field required")
+		}
+
+		if o.MaxLen < 0 {
+			return errors.New("max_len must not be negative")
+		}
+	case "pubsub":
+	default:
+		return fmt.Errorf("unknown mode: %v; expected one of: streams, pubsub", o.Mode)
+	}
+
+	if o.Batcher.Buffer < 1 {
+		o.Batcher.Buffer = 1
+	}
+
+	client, err := o.Redis.Client()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.Timeout)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return err
+	}
+
+	o.client = client
+	return nil
+}
+
+func (o *Redis) SetSerializer(s core.Serializer) {
+	o.ser = s
+}
+
+func (o *Redis) Close() error {
+	o.ser.Close()
+	return o.client.Close()
+}
+
+func (o *Redis) Run() {
+	o.Batcher.Run(o.In, func(buf []*core.Event) {
+		if len(buf) == 0 {
+			return
+		}
+
+		pubs := make([]eventPublishing, 0, len(buf))
+		for _, e := range buf {
+			now := time.Now()
+			event, err := o.ser.Serialize(e)
+			if err != nil {
+				o.Log.Error("serialization failed, event skipped",
+					"error", err,
+					slog.Group("event",
+						"id", e.Id,
+						"key", e.RoutingKey,
+					),
+				)
+				o.Done <- e
+				o.Observe(metrics.EventFailed, time.Since(now))
+				continue
+			}
+
+			pubs = append(pubs, eventPublishing{
+				event: e,
+				data:  event,
+				err:   ErrNotPublished,
+				dur:   time.Since(now),
+			})
+		}
+
+		if len(pubs) == 0 {
+			o.Log.Warn("nothing to produce")
+			return
+		}
+
+		now := time.Now()
+		o.Retryer.Do("publish", o.Log, func() error {
+			return o.publish(pubs)
+		})
+		dur := durationPerEvent(time.Since(now), len(pubs))
+
+		for _, pub := range pubs {
+			o.Done <- pub.event
+			if pub.err != nil {
+				o.Log.Error("event produce failed",
+					"error", pub.err,
+					slog.Group("event",
+						"id", pub.event.Id,
+						"key", pub.event.RoutingKey,
+					),
+				)
+				o.Observe(metrics.EventFailed, pub.dur+dur)
+			} else {
+				o.Log.Debug("event produced",
+					slog.Group("event",
+						"id", pub.event.Id,
+						"key", pub.event.RoutingKey,
+					),
+				)
+				o.Observe(metrics.EventAccepted, pub.dur+dur)
+			}
+		}
+	})
+}
+
+// publish sends all not yet published messages in one pipeline
+// only failed messages will be published again
+func (o *Redis) publish(pubs []eventPublishing) error {
+	ctx, cancel := context.WithTimeout(context.Background(), o.Timeout)
+	defer cancel()
+
+	pipe := o.client.Pipeline()
+	cmds := make(map[int]redis.Cmder, len(pubs))
+	for i, pub := range pubs {
+		if pub.err == nil {
+			continue
+		}
+
+		if o.Mode == "pubsub" {
+			cmds[i] = pipe.Publish(ctx, pub.event.RoutingKey, pub.data)
+			continue
+		}
+
+		values := make([]any, 0, 2+len(o.FieldLabels)*2)
+		values = append(values, o.DataField, pub.data)
+		for field, label := range o.FieldLabels {
+			if v, ok := pub.event.GetLabel(label); ok {
+				values = append(values, field, v)
+			}
+		}
+
+		cmds[i] = pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: pub.event.RoutingKey,
+			MaxLen: o.MaxLen,
+			Approx: o.Approximate,
+			Values: values,
+		})
+	}
+
+	// pipeline returns first failed command error, but each command has it's own result
+	pipe.Exec(ctx)
+
+	hasErrors := false
+	for i, cmd := range cmds {
+		pubs[i].err = cmd.Err()
+		if pubs[i].err != nil {
+			hasErrors = true
+		}
+	}
+
+	if hasErrors {
+		return errors.New("not all messages published successfully")
+	}
+
+	return nil
+}
+
+func durationPerEvent(totalTime time.Duration, batchSize int) time.Duration {
+	return time.Duration(int64(totalTime) / int64(batchSize))
+}
+
+func init() {
+	plugins.AddOutput("redis", func() core.Output {
+		return &Redis{
+			Mode:        "streams",
+			DataField:   "data",
+			MaxLen:      0,
+			Approximate: true,
+			Redis: &common.Redis{
+				ConnsMaxIdleTime: 10 * time.Minute,
+				ConnsMaxLifetime: 10 * time.Minute,
+				ConnsMaxOpen:     2,
+				ConnsMaxIdle:     1,
+				Timeout:          30 * time.Second,
+				TLSClientConfig:  &tls.TLSClientConfig{},
+			},
+			Batcher: &batcher.Batcher[*core.Event]{
+				Buffer:   100,
+				Interval: 5 * time.Second,
+			},
+			Retryer: &retryer.Retryer{
+				RetryAttempts: 0,
+				RetryAfter:    5 * time.Second,
+			},
+		}
+	})
+}
@@ -0,0 +1,267 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	sprig "github.com/go-task/slim-sprig/v3"
+	"github.com/klauspost/compress/zstd"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+	"github.com/gekatateam/neptunus/plugins/common/batcher"
+	"github.com/gekatateam/neptunus/plugins/common/pool"
+	"github.com/gekatateam/neptunus/plugins/common/retryer"
+	cte "github.com/gekatateam/neptunus/plugins/common/template"
+	"github.com/gekatateam/neptunus/plugins/common/tls"
+)
+
+// minimum part size allowed by S3 for multipart uploads
+const minPartSize = 5 * 1024 * 1024
+
+type S3 struct {
+	*core.BaseOutput `mapstructure:"-"`
+	Endpoint         string        `mapstructure:"endpoint"`
+	Region           string        `mapstructure:"region"`
+	Bucket           string        `mapstructure:"bucket"`
+	AccessKey        string        `mapstructure:"access_key"`
+	SecretKey        string        `mapstructure:"secret_key"`
+	SessionToken     string        `mapstructure:"session_token"`
+	BucketLookup     string        `mapstructure:"bucket_lookup"`
+	Key              string        `mapstructure:"key"`
+	Extension        string        `mapstructure:"extension"`
+	ContentType      string        `mapstructure:"content_type"`
+	Compression      string        `mapstructure:"compression"`
+	PartSize         uint64        `mapstructure:"part_size"`
+	UploadThreads    uint          `mapstructure:"upload_threads"`
+	Timeout          time.Duration `mapstructure:"timeout"`
+	IdleTimeout      time.Duration `mapstructure:"idle_timeout"`
+
+	*tls.TLSClientConfig          `mapstructure:",squash"`
+	*batcher.Batcher[*core.Event] `mapstructure:",squash"`
+	*retryer.Retryer              `mapstructure:",squash"`
+
+	uploadersPool *pool.Pool[*core.Event]
+	key           *template.Template
+	keyBuf        *bytes.Buffer
+	encoder       *zstd.Encoder
+
+	client *minio.Client
+	ser    core.Serializer
+}
+
+func (o *S3) Init() error {
+	if len(o.Endpoint) == 0 {
+		return errors.New("endpoint required")
+	}
+
+	if len(o.Bucket) == 0 {
+		return errors.New("bucket required")
+	}
+
+	if len(o.Key) == 0 {
+		return errors.New("key required")
+	}
+
+	var lookup minio.BucketLookupType
+	switch o.BucketLookup {
+	case "auto":
+		lookup = minio.BucketLookupAuto
+	case "path":
+		lookup = minio.BucketLookupPath
+	case "dns":
+		lookup = minio.BucketLookupDNS
+	default:
+		return fmt.Errorf("unknown bucket lookup: %v; expected one of: auto, path, dns", o.BucketLookup)
+	}
+
+	switch o.Compression {
+	case "none":
+	case "gzip":
+		o.Extension += ".gz"
+	case "zstd":
+		encoder, err := zstd.NewWriter(nil)
+		if err != nil {
+			return err
+		}
+		o.encoder = encoder
+		o.Extension += ".zst"
+	default:
+		return fmt.Errorf("unknown compression: %v; expected one of: none, gzip, zstd", o.Compression)
+	}
+
+	key, err := template.New("key").Funcs(sprig.FuncMap()).Parse(o.Key)
+	if err != nil {
+		return err
+	}
+	o.key = key
+	o.keyBuf = bytes.NewBuffer(make([]byte, 0, 256))
+
+	if o.PartSize < minPartSize {
+		o.PartSize = minPartSize
+	}
+
+	if o.UploadThreads < 1 {
+		o.UploadThreads = 1
+	}
+
+	if o.Batcher.Buffer < 0 {
+		o.Batcher.Buffer = 1
+	}
+
+	if o.IdleTimeout > 0 && o.IdleTimeout < time.Minute {
+		o.IdleTimeout = time.Minute
+	}
+
+	tlsConfig, err := o.TLSClientConfig.Config()
+	if err != nil {
+		return err
+	}
+
+	client, err := minio.New(o.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(o.AccessKey, o.SecretKey, o.SessionToken),
+		Secure:       o.TLSClientConfig.Enable,
+		Region:       o.Region,
+		BucketLookup: lookup,
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.Timeout)
+	defer cancel()
+
+	exists, err := client.BucketExists(ctx, o.Bucket)
+	if err != nil {
+		return fmt.Errorf("bucket %v check failed: %w", o.Bucket, err)
+	}
+
+	if !exists {
+		return fmt.Errorf("bucket %v does not exists", o.Bucket)
+	}
+
+	o.client = client
+	o.uploadersPool = pool.New(o.newUploader)
+
+	return nil
+}
+
+func (o *S3) SetSerializer(s core.Serializer) {
+	o.ser = s
+}
+
+func (o *S3) Run() {
+	clearTicker := time.NewTicker(time.Minute)
+	if o.IdleTimeout == 0 {
+		clearTicker.Stop()
+	}
+
+MAIN_LOOP:
+	for {
+		select {
+		case e, ok := <-o.In:
+			if !ok {
+				clearTicker.Stop()
+				break MAIN_LOOP
+			}
+
+			now := time.Now()
+			key, err := o.renderKey(e)
+			if err != nil {
+				o.Log.Error("object key rendering failed, event skipped",
+					"error", err,
+					slog.Group("event",
+						"id", e.Id,
+						"key", e.RoutingKey,
+					),
+				)
+				o.Done <- e
+				o.Observe(metrics.EventFailed, time.Since(now))
+				continue
+			}
+
+			o.uploadersPool.Get(key).Push(e)
+		case <-clearTicker.C:
+			for _, key := range o.uploadersPool.Keys() {
+				if time.Since(o.uploadersPool.Get(key).LastWrite()) > o.IdleTimeout {
+					o.uploadersPool.Remove(key)
+				}
+			}
+		}
+	}
+}
+
+func (o *S3) Close() error {
+	o.uploadersPool.Close()
+	o.ser.Close()
+	if o.encoder != nil {
+		o.encoder.Close()
+	}
+	return nil
+}
+
+func (o *S3) newUploader(key string) pool.Runner[*core.Event] {
+	return &uploader{
+		BaseOutput:    o.BaseOutput,
+		lastWrite:     time.Now(),
+		prefix:        key,
+		bucket:        o.Bucket,
+		extension:     o.Extension,
+		contentType:   o.ContentType,
+		compression:   o.Compression,
+		partSize:      o.PartSize,
+		uploadThreads: o.UploadThreads,
+		timeout:       o.Timeout,
+		encoder:       o.encoder,
+		client:        o.client,
+		ser:           o.ser,
+		Batcher:       o.Batcher,
+		Retryer:       o.Retryer,
+		input:         make(chan *core.Event),
+	}
+}
+
+func (o *S3) renderKey(e *core.Event) (string, error) {
+	o.keyBuf.Reset()
+	if err := o.key.Execute(o.keyBuf, cte.New(e)); err != nil {
+		return "", err
+	}
+	return strings.Trim(o.keyBuf.String(), "/"), nil
+}
+
+func init() {
+	plugins.AddOutput("s3", func() core.Output {
+		return &S3{
+			BucketLookup:  "auto",
+			Key:           `{{ .RoutingKey }}/{{ .Timestamp.UTC.Format "2006/01/02/15" }}`,
+			ContentType:   "application/octet-stream",
+			Compression:   "none",
+			PartSize:      16 * 1024 * 1024,
+			UploadThreads: 4,
+			Timeout:       1 * time.Minute,
+			IdleTimeout:   1 * time.Hour,
+			Batcher: &batcher.Batcher[*core.Event]{
+				Buffer:   1000,
+				Interval: 1 * time.Minute,
+			},
+			TLSClientConfig: &tls.TLSClientConfig{},
+			Retryer: &retryer.Retryer{
+				RetryAttempts: 0,
+				RetryAfter:    5 * time.Second,
+			},
+		}
+	})
+}
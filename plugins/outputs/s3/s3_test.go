@@ -0,0 +1,80 @@
+package s3
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	sprig "github.com/go-task/slim-sprig/v3"
+
+	"github.com/gekatateam/neptunus/core"
+)
+
+func TestRenderKey(t *testing.T) {
+	timestamp := time.Date(2024, 3, 1, 12, 5, 1, 0, time.UTC)
+
+	tests := map[string]struct {
+		key       string
+		event     *core.Event
+		expectKey string
+		expectErr string
+	}{
+		"routing-key-and-timestamp": {
+			key:       `{{ .RoutingKey }}/{{ .Timestamp.UTC.Format "2006/01/02/15" }}`,
+			event:     &core.Event{RoutingKey: "orders", Timestamp: timestamp},
+			expectKey: "orders/2024/03/01/12",
+		},
+		"label-and-field": {
+			key:       `{{ .GetLabel "tenant" }}/{{ .GetField "region" }}`,
+			event:     &core.Event{RoutingKey: "orders", Labels: map[string]string{"tenant": "acme"}, Data: map[string]any{"region": "eu"}},
+			expectKey: "acme/eu",
+		},
+		"slashes-trimmed": {
+			key:       `/{{ .RoutingKey }}/`,
+			event:     &core.Event{RoutingKey: "orders"},
+			expectKey: "orders",
+		},
+		"sprig-functions": {
+			key:       `{{ .RoutingKey | upper }}`,
+			event:     &core.Event{RoutingKey: "orders"},
+			expectKey: "ORDERS",
+		},
+		"execution-error": {
+			key:       `{{ .Unknown }}`,
+			event:     &core.Event{RoutingKey: "orders"},
+			expectErr: "can't evaluate field Unknown",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			key, err := template.New("key").Funcs(sprig.FuncMap()).Parse(test.key)
+			if err != nil {
+				t.Fatalf("template parsing failed: %v", err)
+			}
+
+			o := &S3{key: key, keyBuf: &bytes.Buffer{}}
+
+			// key buffer is reused between events
+			for i := 0; i < 2; i++ {
+				got, err := o.renderKey(test.event)
+				if len(test.expectErr) > 0 {
+					if err == nil || !strings.Contains(err.Error(), test.expectErr) {
+						t.Fatalf("unexpected error, want: %v, got: %v", test.expectErr, err)
+					}
+					return
+				}
+
+				if err != nil {
+					t.Fatalf("key rendering failed: %v", err)
+				}
+
+				if got != test.expectKey {
+					t.Fatalf("unexpected key, want: %v, got: %v", test.expectKey, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package s3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"log/slog"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/klauspost/compress/zstd"
+	"github.com/minio/minio-go/v7"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins/common/batcher"
+	"github.com/gekatateam/neptunus/plugins/common/retryer"
+)
+
+type uploader struct {
+	*core.BaseOutput
+
+	lastWrite     time.Time
+	prefix        string
+	bucket        string
+	extension     string
+	contentType   string
+	compression   string
+	partSize      uint64
+	uploadThreads uint
+	timeout       time.Duration
+
+	encoder *zstd.Encoder
+	client  *minio.Client
+	*batcher.Batcher[*core.Event]
+	*retryer.Retryer
+
+	ser   core.Serializer
+	input chan *core.Event
+}
+
+func (u *uploader) Run() {
+	u.Log.Info(fmt.Sprintf("uploader for %v spawned", u.prefix))
+
+	u.Batcher.Run(u.input, func(buf []*core.Event) {
+		if len(buf) == 0 {
+			return
+		}
+		now := time.Now()
+		u.lastWrite = now
+
+		object, err := u.ser.Serialize(buf...)
+		if err == nil {
+			object, err = u.compress(object)
+		}
+
+		if err != nil {
+			each := time.Since(now) / time.Duration(len(buf))
+			for _, e := range buf {
+				u.Log.Error("object preparation failed",
+					"error", err,
+					slog.Group("event",
+						"id", e.Id,
+						"key", e.RoutingKey,
+					),
+				)
+				u.Done <- e
+				u.Observe(metrics.EventFailed, each)
+			}
+			return
+		}
+
+		name := u.objectName()
+		totalBefore := time.Since(now)
+		now = time.Now() // reset now() to measure time spent on the upload
+		err = u.upload(name, object)
+		totalAfter := time.Since(now)
+
+		// events are acked only after whole object is uploaded
+		for i, e := range buf {
+			u.Done <- e
+			if err != nil {
+				u.Log.Error("event upload failed",
+					"error", err,
+					"object", name,
+					slog.Group("event",
+						"id", e.Id,
+						"key", e.RoutingKey,
+					),
+				)
+				u.Observe(metrics.EventFailed, durationPerEvent(totalBefore, totalAfter, len(buf), i))
+			} else {
+				u.Log.Debug("event uploaded",
+					"object", name,
+					slog.Group("event",
+						"id", e.Id,
+						"key", e.RoutingKey,
+					),
+				)
+				u.Observe(metrics.EventAccepted, durationPerEvent(totalBefore, totalAfter, len(buf), i))
+			}
+		}
+	})
+
+	u.Log.Info(fmt.Sprintf("uploader for %v closed", u.prefix))
+}
+
+func (u *uploader) Push(e *core.Event) {
+	u.input <- e
+}
+
+func (u *uploader) LastWrite() time.Time {
+	return u.lastWrite
+}
+
+func (u *uploader) Close() error {
+	close(u.input)
+	return nil
+}
+
+// each batch is uploaded as a new object, so object name is unique
+// and sortable by upload time in the scope of one key
+func (u *uploader) objectName() string {
+	return path.Join(u.prefix, time.Now().UTC().Format("20060102T150405Z")+"-"+uuid.NewString()+u.extension)
+}
+
+func (u *uploader) compress(data []byte) ([]byte, error) {
+	switch u.compression {
+	case "gzip":
+		buf := bytes.NewBuffer(make([]byte, 0, len(data)/2))
+		w := gzip.NewWriter(buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case "zstd":
+		return u.encoder.EncodeAll(data, make([]byte, 0, len(data)/2)), nil
+	default:
+		return data, nil
+	}
+}
+
+// objects larger than part size are uploaded using multipart upload
+func (u *uploader) upload(name string, object []byte) error {
+	opts := minio.PutObjectOptions{
+		ContentType: u.contentType,
+		PartSize:    u.partSize,
+		NumThreads:  u.uploadThreads,
+	}
+
+	switch u.compression {
+	case "gzip":
+		opts.ContentEncoding = "gzip"
+	case "zstd":
+		opts.ContentEncoding = "zstd"
+	}
+
+	return u.Retryer.Do("object upload", u.Log, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), u.timeout)
+		defer cancel()
+
+		info, err := u.client.PutObject(ctx, u.bucket, name, bytes.NewReader(object), int64(len(object)), opts)
+		if err != nil {
+			return err
+		}
+
+		u.Log.Debug(fmt.Sprintf("object %v uploaded, size: %v, etag: %v", info.Key, info.Size, info.ETag))
+		return nil
+	})
+}
+
+func durationPerEvent(totalBefore, totalAfter time.Duration, batchSize, i int) time.Duration {
+	each := totalBefore / time.Duration(batchSize)
+
+	if i == batchSize-1 { // last event also takes upload duraion
+		return each + totalAfter
+	}
+
+	return each
+}
@@ -0,0 +1,124 @@
+package s3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestCompress(t *testing.T) {
+	data := []byte(strings.Repeat(`{"message":"compress me"}`+"\n", 100))
+
+	tests := map[string]struct {
+		compression string
+		decompress  func(data []byte) ([]byte, error)
+	}{
+		"none": {
+			compression: "none",
+			decompress: func(data []byte) ([]byte, error) {
+				return data, nil
+			},
+		},
+		"gzip": {
+			compression: "gzip",
+			decompress: func(data []byte) ([]byte, error) {
+				r, err := gzip.NewReader(bytes.NewReader(data))
+				if err != nil {
+					return nil, err
+				}
+				return io.ReadAll(r)
+			},
+		},
+		"zstd": {
+			compression: "zstd",
+			decompress: func(data []byte) ([]byte, error) {
+				d, err := zstd.NewReader(nil)
+				if err != nil {
+					return nil, err
+				}
+				defer d.Close()
+				return d.DecodeAll(data, nil)
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			u := &uploader{compression: test.compression}
+			if test.compression == "zstd" {
+				encoder, err := zstd.NewWriter(nil)
+				if err != nil {
+					t.Fatalf("encoder not created: %v", err)
+				}
+				defer encoder.Close()
+				u.encoder = encoder
+			}
+
+			// compress is called for each batch with the same uploader
+			for i := 0; i < 2; i++ {
+				compressed, err := u.compress(data)
+				if err != nil {
+					t.Fatalf("compression failed: %v", err)
+				}
+
+				if test.compression != "none" && len(compressed) >= len(data) {
+					t.Fatalf("data not compressed, source size: %v, compressed size: %v", len(data), len(compressed))
+				}
+
+				decompressed, err := test.decompress(compressed)
+				if err != nil {
+					t.Fatalf("decompression failed: %v", err)
+				}
+
+				if !bytes.Equal(decompressed, data) {
+					t.Fatalf("unexpected decompressed data, want: %s, got: %s", data, decompressed)
+				}
+			}
+		})
+	}
+}
+
+func TestObjectName(t *testing.T) {
+	uuidPattern := `[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`
+
+	tests := map[string]struct {
+		prefix    string
+		extension string
+		pattern   string
+	}{
+		"prefix-with-extension": {
+			prefix:    "orders/2024/03/01",
+			extension: ".json.gz",
+			pattern:   `^orders/2024/03/01/\d{8}T\d{6}Z-` + uuidPattern + `\.json\.gz$`,
+		},
+		"no-extension": {
+			prefix:  "orders",
+			pattern: `^orders/\d{8}T\d{6}Z-` + uuidPattern + `$`,
+		},
+		"empty-prefix": {
+			prefix:    "",
+			extension: ".zst",
+			pattern:   `^\d{8}T\d{6}Z-` + uuidPattern + `\.zst$`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			u := &uploader{prefix: test.prefix, extension: test.extension}
+
+			first, second := u.objectName(), u.objectName()
+			if !regexp.MustCompile(test.pattern).MatchString(first) {
+				t.Fatalf("unexpected object name: %v, expected to match: %v", first, test.pattern)
+			}
+
+			if first == second {
+				t.Fatalf("object names are not unique: %v", first)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package stdout
+
+import (
+	"bufio"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+)
+
+// buffered events limit, after which buffer is flushed
+// even if there is more events to write
+const maxPending = 1000
+
+type Stdout struct {
+	*core.BaseOutput `mapstructure:"-"`
+
+	writer  *bufio.Writer
+	ser     core.Serializer
+	pending []pendingEvent
+}
+
+// pendingEvent is written to buffer, but not flushed yet
+type pendingEvent struct {
+	e   *core.Event
+	now time.Time
+}
+
+func (o *Stdout) Init() error {
+	o.writer = bufio.NewWriter(os.Stdout)
+	return nil
+}
+
+func (o *Stdout) SetSerializer(s core.Serializer) {
+	o.ser = s
+}
+
+func (o *Stdout) Run() {
+	for e := range o.In {
+		now := time.Now()
+		event, err := o.ser.Serialize(e)
+		if err != nil {
+			o.Log.Error("serialization failed",
+				"error", err,
+				slog.Group("event",
+					"id", e.Id,
+					"key", e.RoutingKey,
+				),
+			)
+			o.Done <- e
+			o.Observe(metrics.EventFailed, time.Since(now))
+			continue
+		}
+
+		// writes are buffered, and buffer is flushed when there is no more events to write
+		// events are acked only after flush, so buffered events are never reported as delivered
+		o.pending = append(o.pending, pendingEvent{e: e, now: now})
+		_, err = o.writer.Write(append(event, '\n'))
+		if err == nil && len(o.In) > 0 && len(o.pending) < maxPending {
+			continue
+		}
+
+		if err == nil {
+			err = o.writer.Flush()
+		}
+		o.ack(err)
+	}
+}
+
+// ack reports all pending events as delivered or failed
+func (o *Stdout) ack(err error) {
+	for _, p := range o.pending {
+		if err != nil {
+			o.Log.Error("writing to stdout failed",
+				"error", err,
+				slog.Group("event",
+					"id", p.e.Id,
+					"key", p.e.RoutingKey,
+				),
+			)
+			o.Done <- p.e
+			o.Observe(metrics.EventFailed, time.Since(p.now))
+			continue
+		}
+
+		o.Done <- p.e
+		o.Observe(metrics.EventAccepted, time.Since(p.now))
+	}
+	o.pending = o.pending[:0]
+}
+
+func (o *Stdout) Close() error {
+	o.ser.Close()
+	if o.writer != nil {
+		return o.writer.Flush()
+	}
+	return nil
+}
+
+func init() {
+	plugins.AddOutput("stdout", func() core.Output {
+		return &Stdout{}
+	})
+}
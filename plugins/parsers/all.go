@@ -1,6 +1,8 @@
 package parsers
 
 import (
+	_ "github.com/gekatateam/neptunus/plugins/parsers/csv"
 	_ "github.com/gekatateam/neptunus/plugins/parsers/json"
+	_ "github.com/gekatateam/neptunus/plugins/parsers/kv"
 	_ "github.com/gekatateam/neptunus/plugins/parsers/plain"
 )
@@ -0,0 +1,154 @@
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+)
+
+type Csv struct {
+	*core.BaseParser `mapstructure:"-"`
+	Delimiter        string            `mapstructure:"delimiter"`
+	Comment          string            `mapstructure:"comment"`
+	TrimLeadingSpace bool              `mapstructure:"trim_leading_space"`
+	Header           bool              `mapstructure:"header"`
+	Columns          []string          `mapstructure:"columns"`
+	Types            map[string]string `mapstructure:"types"`
+	SkipEmpty        bool              `mapstructure:"skip_empty"`
+
+	delimiter rune
+	comment   rune
+}
+
+func (p *Csv) Init() error {
+	delimiter := []rune(p.Delimiter)
+	if len(delimiter) != 1 {
+		return errors.New("delimiter must be a single character")
+	}
+	p.delimiter = delimiter[0]
+
+	if len(p.Comment) > 0 {
+		comment := []rune(p.Comment)
+		if len(comment) != 1 {
+			return errors.New("comment must be a single character")
+		}
+		p.comment = comment[0]
+	}
+
+	if !p.Header && len(p.Columns) == 0 {
+		return errors.New("columns required if header is disabled")
+	}
+
+	for k, t := range p.Types {
+		switch t {
+		case "string", "int", "float", "bool":
+		default:
+			return fmt.Errorf("unknown type for column %v: %v, expected one of: string, int, float, bool", k, t)
+		}
+	}
+
+	return nil
+}
+
+func (p *Csv) Close() error {
+	return nil
+}
+
+func (p *Csv) Parse(data []byte, routingKey string) ([]*core.Event, error) {
+	now := time.Now()
+	events := []*core.Event{}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = p.delimiter
+	r.Comment = p.comment
+	r.TrimLeadingSpace = p.TrimLeadingSpace
+
+	columns := p.Columns
+	if p.Header {
+		header, err := r.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return events, nil
+			}
+			p.Observe(metrics.EventFailed, time.Since(now))
+			return nil, err
+		}
+
+		// configured columns takes precedence over header
+		if len(columns) == 0 {
+			columns = header
+		}
+	}
+	r.FieldsPerRecord = len(columns)
+
+	for {
+		record, err := r.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			p.Observe(metrics.EventFailed, time.Since(now))
+			return nil, err
+		}
+
+		e := core.NewEvent(routingKey)
+		for i, column := range columns {
+			// empty values can not be converted, so they are skipped
+			if len(record[i]) == 0 && (p.SkipEmpty || p.typed(column)) {
+				continue
+			}
+
+			value, err := p.convert(column, record[i])
+			if err != nil {
+				p.Observe(metrics.EventFailed, time.Since(now))
+				return nil, fmt.Errorf("column %v: %w", column, err)
+			}
+
+			if err := e.SetField(column, value); err != nil {
+				p.Observe(metrics.EventFailed, time.Since(now))
+				return nil, fmt.Errorf("column %v: %w", column, err)
+			}
+		}
+
+		events = append(events, e)
+		p.Observe(metrics.EventAccepted, time.Since(now))
+		now = time.Now()
+	}
+
+	return events, nil
+}
+
+func (p *Csv) typed(column string) bool {
+	t, ok := p.Types[column]
+	return ok && t != "string"
+}
+
+func (p *Csv) convert(column, value string) (any, error) {
+	switch p.Types[column] {
+	case "int":
+		return strconv.ParseInt(value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(value, 64)
+	case "bool":
+		return strconv.ParseBool(value)
+	default:
+		return value, nil
+	}
+}
+
+func init() {
+	plugins.AddParser("csv", func() core.Parser {
+		return &Csv{
+			Delimiter: ",",
+			Header:    true,
+		}
+	})
+}
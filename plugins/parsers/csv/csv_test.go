@@ -0,0 +1,91 @@
+package csv_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/pkg/mapstructure"
+	"github.com/gekatateam/neptunus/plugins/parsers/csv"
+)
+
+func TestCsv(t *testing.T) {
+	tests := map[string]struct {
+		config      map[string]any
+		data        string
+		expectData  []map[string]any
+		expectError bool
+	}{
+		"header-and-types": {
+			config: map[string]any{
+				"types": map[string]string{"age": "int", "score": "float"},
+			},
+			data: "name,age,score,user.city\n" +
+				"John,42,1.5,London\n" +
+				"\"Doe, Jane\",,2,\n",
+			expectData: []map[string]any{
+				{"name": "John", "age": int64(42), "score": 1.5, "user": map[string]any{"city": "London"}},
+				{"name": "Doe, Jane", "score": 2.0, "user": map[string]any{"city": ""}},
+			},
+		},
+		"configured-columns-without-header": {
+			config: map[string]any{
+				"header":     false,
+				"columns":    []string{"a", "b"},
+				"delimiter":  ";",
+				"skip_empty": true,
+			},
+			data: "1;\n3;4\n",
+			expectData: []map[string]any{
+				{"a": "1"},
+				{"a": "3", "b": "4"},
+			},
+		},
+		"wrong-fields-count": {
+			config:      map[string]any{},
+			data:        "a,b\n1,2,3\n",
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			parser := &csv.Csv{
+				BaseParser: &core.BaseParser{
+					Obs: metrics.ObserveMock,
+				},
+				Delimiter: ",",
+				Header:    true,
+			}
+			if err := mapstructure.Decode(test.config, parser); err != nil {
+				t.Fatalf("parser config not applied: %v", err)
+			}
+			if err := parser.Init(); err != nil {
+				t.Fatalf("parser not initialized: %v", err)
+			}
+
+			events, err := parser.Parse([]byte(test.data), "test")
+			if test.expectError {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(events) != len(test.expectData) {
+				t.Fatalf("unexpected events count - want: %v, got: %v", len(test.expectData), len(events))
+			}
+
+			for i, e := range events {
+				if !reflect.DeepEqual(e.Data, test.expectData[i]) {
+					t.Fatalf("unexpected event %v data - want: %v, got: %v", i, test.expectData[i], e.Data)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package kv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+)
+
+type Kv struct {
+	*core.BaseParser `mapstructure:"-"`
+	PairSeparator    string `mapstructure:"pair_separator"`
+	KvSeparator      string `mapstructure:"kv_separator"`
+	Quotes           string `mapstructure:"quotes"`
+	Unescape         bool   `mapstructure:"unescape"`
+	InferTypes       bool   `mapstructure:"infer_types"`
+	Prefix           string `mapstructure:"prefix"`
+	ExpandKeys       bool   `mapstructure:"expand_keys"`
+	SplitLines       bool   `mapstructure:"split_lines"`
+}
+
+func (p *Kv) Init() error {
+	if len(p.PairSeparator) == 0 {
+		return errors.New("pair_separator required")
+	}
+
+	if len(p.KvSeparator) == 0 {
+		return errors.New("kv_separator required")
+	}
+
+	if p.PairSeparator == p.KvSeparator {
+		return errors.New("pair_separator and kv_separator must be different")
+	}
+
+	return nil
+}
+
+func (p *Kv) Close() error {
+	return nil
+}
+
+func (p *Kv) Parse(data []byte, routingKey string) ([]*core.Event, error) {
+	now := time.Now()
+	events := []*core.Event{}
+
+	var lines [][]byte
+	if p.SplitLines {
+		lines = bytes.Split(data, []byte("\n"))
+	} else {
+		lines = [][]byte{data}
+	}
+
+	for i, line := range lines {
+		line = bytes.TrimRight(line, "\r")
+		if p.SplitLines && len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		e, err := p.parseLine(string(line), routingKey)
+		if err != nil {
+			p.Observe(metrics.EventFailed, time.Since(now))
+			if p.SplitLines {
+				return nil, fmt.Errorf("line %v: %w", i+1, err)
+			}
+			return nil, err
+		}
+
+		events = append(events, e)
+		p.Observe(metrics.EventAccepted, time.Since(now))
+		now = time.Now()
+	}
+
+	return events, nil
+}
+
+func (p *Kv) parseLine(line, routingKey string) (*core.Event, error) {
+	e := core.NewEventWithData(routingKey, map[string]any{})
+	rest := line
+
+	for {
+		// multiple pair separators in a row are allowed
+		for strings.HasPrefix(rest, p.PairSeparator) {
+			rest = rest[len(p.PairSeparator):]
+		}
+
+		if len(rest) == 0 {
+			break
+		}
+
+		key, _, r, err := p.token(rest, true)
+		if err != nil {
+			return nil, err
+		}
+		rest = r
+
+		if len(key) == 0 {
+			return nil, fmt.Errorf("empty key at %q", rest)
+		}
+
+		var value any = true // key without value is a flag
+		if strings.HasPrefix(rest, p.KvSeparator) {
+			rawValue, quoted, r, err := p.token(rest[len(p.KvSeparator):], false)
+			if err != nil {
+				return nil, fmt.Errorf("key %v: %w", key, err)
+			}
+			rest = r
+
+			// quoted values are always strings
+			if quoted {
+				value = rawValue
+			} else {
+				value = p.infer(rawValue)
+			}
+		}
+
+		if len(rest) > 0 && !strings.HasPrefix(rest, p.PairSeparator) {
+			return nil, fmt.Errorf("key %v: unexpected %q after value", key, rest)
+		}
+
+		key = p.Prefix + key
+		if p.ExpandKeys {
+			if err := e.SetField(key, value); err != nil {
+				return nil, fmt.Errorf("key %v: %w", key, err)
+			}
+		} else {
+			e.Data.(map[string]any)[key] = value
+		}
+	}
+
+	return e, nil
+}
+
+// token reads a key or a value, quoted or not, and returns it with the rest of string
+// not quoted key ends before kv or pair separator, not quoted value ends before pair separator
+func (p *Kv) token(s string, isKey bool) (string, bool, string, error) {
+	if len(s) > 0 && strings.IndexByte(p.Quotes, s[0]) >= 0 {
+		token, rest, err := p.quoted(s)
+		return token, true, rest, err
+	}
+
+	end := strings.Index(s, p.PairSeparator)
+	if end < 0 {
+		end = len(s)
+	}
+
+	if isKey {
+		if i := strings.Index(s[:end], p.KvSeparator); i >= 0 {
+			end = i
+		}
+	}
+
+	return s[:end], false, s[end:], nil
+}
+
+// quoted reads a string in quotes; quote char may be escaped with backslash
+func (p *Kv) quoted(s string) (string, string, error) {
+	quote := s[0]
+	escaped := false
+
+	for i := 1; i < len(s); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case s[i] == '\\':
+			escaped = true
+		case s[i] == quote:
+			if !p.Unescape {
+				return s[1:i], s[i+1:], nil
+			}
+			return unescape(s[1:i]), s[i+1:], nil
+		}
+	}
+
+	return "", "", fmt.Errorf("unterminated quoted string %q", s)
+}
+
+func unescape(s string) string {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+
+	b := strings.Builder{}
+	b.Grow(len(s))
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		default: // quotes, backslash and any other char are written as is
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String()
+}
+
+func (p *Kv) infer(s string) any {
+	if !p.InferTypes {
+		return s
+	}
+
+	// only decimal numbers are inferred, so NaN or Inf are strings
+	if len(s) > 0 && strings.IndexByte("+-.0123456789", s[0]) >= 0 && strings.IndexByte(".0123456789", s[len(s)-1]) >= 0 {
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	return s
+}
+
+func init() {
+	plugins.AddParser("kv", func() core.Parser {
+		return &Kv{
+			PairSeparator: " ",
+			KvSeparator:   "=",
+			Quotes:        `"'`,
+			Unescape:      true,
+			InferTypes:    true,
+			SplitLines:    true,
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package kv_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/pkg/mapstructure"
+	"github.com/gekatateam/neptunus/plugins/parsers/kv"
+)
+
+func TestKv(t *testing.T) {
+	tests := map[string]struct {
+		config      map[string]any
+		data        string
+		expectData  []map[string]any
+		expectError bool
+	}{
+		"logfmt-lines": {
+			config: map[string]any{},
+			data: `level=INFO message="pipeline \"grp\" started" pipeline.id=grp` + "\n" +
+				`level=ERROR  count=3 ratio=0.5 ok=false debug msg= inf=-Inf` + "\n",
+			expectData: []map[string]any{
+				{"level": "INFO", "message": `pipeline "grp" started`, "pipeline.id": "grp"},
+				{"level": "ERROR", "count": int64(3), "ratio": 0.5, "ok": false, "debug": true, "msg": "", "inf": "-Inf"},
+			},
+		},
+		"custom-separators-prefix-expand": {
+			config: map[string]any{
+				"pair_separator": "; ",
+				"kv_separator":   ":",
+				"quotes":         "'",
+				"infer_types":    false,
+				"prefix":         "kv.",
+				"expand_keys":    true,
+				"split_lines":    false,
+			},
+			data: `user.name:'John; Doe'; user.age:42`,
+			expectData: []map[string]any{
+				{"kv": map[string]any{"user": map[string]any{"name": "John; Doe", "age": "42"}}},
+			},
+		},
+		"unterminated-quote": {
+			config:      map[string]any{},
+			data:        `message="oops`,
+			expectError: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			parser := &kv.Kv{
+				BaseParser: &core.BaseParser{
+					Obs: metrics.ObserveMock,
+				},
+				PairSeparator: " ",
+				KvSeparator:   "=",
+				Quotes:        `"'`,
+				Unescape:      true,
+				InferTypes:    true,
+				SplitLines:    true,
+			}
+			if err := mapstructure.Decode(test.config, parser); err != nil {
+				t.Fatalf("parser config not applied: %v", err)
+			}
+			if err := parser.Init(); err != nil {
+				t.Fatalf("parser not initialized: %v", err)
+			}
+
+			events, err := parser.Parse([]byte(test.data), "test")
+			if test.expectError {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(events) != len(test.expectData) {
+				t.Fatalf("unexpected events count - want: %v, got: %v", len(test.expectData), len(events))
+			}
+
+			for i, e := range events {
+				if !reflect.DeepEqual(e.Data, test.expectData[i]) {
+					t.Fatalf("unexpected event %v data - want: %v, got: %v", i, test.expectData[i], e.Data)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package aggregate
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+)
+
+type Aggregate struct {
+	id                  uint64
+	*core.BaseProcessor `mapstructure:"-"`
+	Mode                string              `mapstructure:"mode"`
+	Window              string              `mapstructure:"window"`
+	Size                time.Duration       `mapstructure:"size"`
+	Hop                 time.Duration       `mapstructure:"hop"`
+	Gap                 time.Duration       `mapstructure:"gap"`
+	AllowedLateness     time.Duration       `mapstructure:"allowed_lateness"`
+	IdleTimeout         time.Duration       `mapstructure:"idle_timeout"`
+	RoutingKey          string              `mapstructure:"routing_key"`
+	GroupLabels         []string            `mapstructure:"group_labels"`
+	GroupFields         []string            `mapstructure:"group_fields"`
+	DropOrigin          bool                `mapstructure:"drop_origin"`
+	Fields              map[string][]string `mapstructure:"fields"`
+
+	storage storage
+}
+
+func (p *Aggregate) Init() error {
+	p.GroupLabels = slices.Compact(p.GroupLabels)
+	p.GroupFields = slices.Compact(p.GroupFields)
+
+	switch p.Window {
+	case "tumbling":
+		if p.Size <= 0 {
+			return errors.New("size must be greater than zero")
+		}
+	case "hopping":
+		if p.Size <= 0 {
+			return errors.New("size must be greater than zero")
+		}
+
+		if p.Hop <= 0 || p.Hop > p.Size {
+			return errors.New("hop must be greater than zero and not greater than size")
+		}
+	case "session":
+		if p.Gap <= 0 {
+			return errors.New("gap must be greater than zero")
+		}
+	default:
+		return fmt.Errorf("unknown window: %v, expected one of: tumbling, hopping, session", p.Window)
+	}
+
+	if p.AllowedLateness < 0 {
+		p.AllowedLateness = 0
+	}
+
+	fields := make(map[string]functions, len(p.Fields))
+	for k, v := range p.Fields {
+		if len(v) == 0 {
+			return fmt.Errorf("field %v has no configured functions", k)
+		}
+
+		fns := []function{}
+		for _, f := range slices.Compact(v) {
+			fn, err := parseFunction(f)
+			if err != nil {
+				return fmt.Errorf("field %v: %w", k, err)
+			}
+			fns = append(fns, fn)
+		}
+
+		fields[k] = newFunctions(fns)
+	}
+
+	c := windowsConfig{
+		kind:     p.Window,
+		size:     p.Size,
+		hop:      p.Hop,
+		gap:      p.Gap,
+		lateness: p.AllowedLateness,
+		idle:     p.IdleTimeout,
+		fields:   fields,
+	}
+
+	switch p.Mode {
+	case "individual":
+		p.storage = newIndividualStorage(c)
+	case "shared":
+		p.storage = newSharedStorage(p.id, c)
+	default:
+		return fmt.Errorf("unknown mode: %v, expected one of: shared, individual", p.Mode)
+	}
+
+	return nil
+}
+
+func (p *Aggregate) Close() error {
+	return nil
+}
+
+func (p *Aggregate) SetId(id uint64) {
+	p.id = id
+}
+
+func (p *Aggregate) Run() {
+	var tick <-chan time.Time
+	if p.IdleTimeout > 0 {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case now := <-tick:
+			p.emit(p.storage.tick(now))
+		case e, ok := <-p.In:
+			if !ok {
+				p.emit(p.storage.leave())
+				return
+			}
+
+			now := time.Now()
+			p.observe(e)
+			p.Observe(metrics.EventAccepted, time.Since(now))
+		}
+	}
+}
+
+func (p *Aggregate) observe(e *core.Event) {
+	g, ok := p.group(e)
+	if !ok { // if event has no group label or field, skip it
+		p.origin(e)
+		return
+	}
+
+	// aggregated events are held until windows with them are flushed
+	// if origin is passed further, its copy is held
+	// so event values does not changed by next plugins
+	m := &member{e: e, refs: 1}
+	if !p.DropOrigin {
+		m.e = e.Clone()
+	}
+
+	late, fired := p.storage.observe(g, m.e, m)
+	if late {
+		if !p.DropOrigin {
+			m.e.Done()
+		}
+		e.SetLabel("::late", "true")
+		p.Out <- e
+	} else {
+		m.release()
+		if !p.DropOrigin {
+			p.Out <- e
+		}
+	}
+
+	p.emit(fired)
+}
+
+func (p *Aggregate) origin(e *core.Event) {
+	if p.DropOrigin {
+		p.Drop <- e
+	} else {
+		p.Out <- e
+	}
+}
+
+func (p *Aggregate) group(e *core.Event) (*group, bool) {
+	g := &group{
+		labels: make(map[string]string, len(p.GroupLabels)),
+		fields: make(map[string]any, len(p.GroupFields)),
+	}
+
+	b := strings.Builder{}
+	for _, k := range p.GroupLabels {
+		v, ok := e.GetLabel(k)
+		if !ok {
+			return nil, false
+		}
+
+		g.labels[k] = v
+		b.WriteString(k)
+		b.WriteByte(0)
+		b.WriteString(v)
+		b.WriteByte(0)
+	}
+
+	for _, k := range p.GroupFields {
+		v, err := e.GetField(k)
+		if err != nil {
+			return nil, false
+		}
+
+		switch v.(type) {
+		case map[string]any, []any:
+			return nil, false
+		}
+
+		g.fields[k] = v
+		b.WriteString(k)
+		b.WriteByte(0)
+		fmt.Fprint(&b, v)
+		b.WriteByte(0)
+	}
+
+	g.key = b.String()
+	return g, true
+}
+
+func (p *Aggregate) emit(windows []*window) {
+	for _, w := range windows {
+		e := core.NewEvent(p.RoutingKey)
+		e.Timestamp = w.start
+
+		for k, v := range w.group.labels {
+			e.SetLabel(k, v)
+		}
+
+		for k, v := range w.group.fields {
+			e.SetField(k, v)
+		}
+
+		e.SetField("window", map[string]any{
+			"start": w.start,
+			"end":   w.end,
+			"count": w.count,
+		})
+
+		for k, a := range w.aggs {
+			e.SetField("aggregates."+k, a.result())
+		}
+
+		members := w.members
+		e.AddHook(func() {
+			for _, m := range members {
+				m.release()
+			}
+		})
+
+		p.Out <- e
+	}
+}
+
+func init() {
+	plugins.AddProcessor("aggregate", func() core.Processor {
+		return &Aggregate{
+			Mode:       "shared",
+			Window:     "tumbling",
+			Size:       time.Minute,
+			RoutingKey: "neptunus.generated.aggregate",
+			DropOrigin: true,
+		}
+	})
+}
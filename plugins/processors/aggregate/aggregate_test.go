@@ -0,0 +1,209 @@
+package aggregate_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/pkg/mapstructure"
+	"github.com/gekatateam/neptunus/plugins/processors/aggregate"
+)
+
+var base = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func event(offset time.Duration, user string, value any) *core.Event {
+	return &core.Event{
+		Timestamp: base.Add(offset),
+		Labels:    map[string]string{"user": user},
+		Data:      map[string]any{"value": value},
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	tests := map[string]struct {
+		config       map[string]any
+		events       []*core.Event
+		expectEvents []map[string]any // expected fields of aggregated events in flush order
+		expectLate   int
+	}{
+		"tumbling-window": {
+			config: map[string]any{
+				"window":       "tumbling",
+				"size":         "10s",
+				"group_labels": []string{"user"},
+				"fields": map[string][]string{
+					"value": {"count", "sum", "min", "max", "p50", "first", "last", "distinct"},
+				},
+			},
+			events: []*core.Event{
+				event(1*time.Second, "a", 1),
+				event(3*time.Second, "a", 3),
+				event(2*time.Second, "a", 3),
+				event(12*time.Second, "a", 5), // closes first window
+				event(5*time.Second, "a", 7),  // late
+			},
+			expectEvents: []map[string]any{
+				{
+					"window.count":              3,
+					"aggregates.value.count":    3,
+					"aggregates.value.sum":      7.0,
+					"aggregates.value.min":      1.0,
+					"aggregates.value.max":      3.0,
+					"aggregates.value.p50":      3.0,
+					"aggregates.value.first":    1,
+					"aggregates.value.last":     3,
+					"aggregates.value.distinct": 2,
+				},
+				{
+					"window.count":           1,
+					"aggregates.value.count": 1,
+				},
+			},
+			expectLate: 1,
+		},
+		"hopping-window-with-lateness": {
+			config: map[string]any{
+				"window":           "hopping",
+				"size":             "10s",
+				"hop":              "5s",
+				"allowed_lateness": "5s",
+				"fields": map[string][]string{
+					"value": {"count"},
+				},
+			},
+			events: []*core.Event{
+				event(6*time.Second, "a", 1),  // windows [0;10) and [5;15)
+				event(4*time.Second, "a", 1),  // windows [-5;5) and [0;10)
+				event(16*time.Second, "a", 1), // windows [10;20) and [15;25), closes [-5;5) and [0;10)
+				event(21*time.Second, "a", 1), // windows [15;25) and [20;30), closes [5;15)
+				event(2*time.Second, "a", 1),  // late
+			},
+			expectEvents: []map[string]any{
+				{"window.count": 1},
+				{"window.count": 2},
+				{"window.count": 1},
+				{"window.count": 1},
+				{"window.count": 2},
+				{"window.count": 1},
+			},
+			expectLate: 1,
+		},
+		"session-window": {
+			config: map[string]any{
+				"window":           "session",
+				"gap":              "5s",
+				"allowed_lateness": "5s",
+				"group_labels":     []string{"user"},
+				"fields": map[string][]string{
+					"value": {"collect"},
+				},
+			},
+			events: []*core.Event{
+				event(0*time.Second, "a", 1),
+				event(8*time.Second, "a", 3),
+				event(4*time.Second, "a", 2), // merges two sessions, because first one is not flushed yet
+				event(9*time.Second, "b", 1),
+				event(20*time.Second, "a", 4), // closes merged session of "a" and session of "b"
+			},
+			expectEvents: []map[string]any{
+				{"window.count": 3},
+				{"window.count": 1},
+				{"window.count": 1},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			processor := &aggregate.Aggregate{
+				BaseProcessor: &core.BaseProcessor{
+					Log: logger.Mock(),
+					Obs: metrics.ObserveMock,
+				},
+				Mode:       "individual",
+				RoutingKey: "aggregate",
+				DropOrigin: true,
+			}
+			if err := mapstructure.Decode(test.config, processor); err != nil {
+				t.Fatalf("processor config not applied: %v", err)
+			}
+			if err := processor.Init(); err != nil {
+				t.Fatalf("processor not initialized: %v", err)
+			}
+
+			input := make(chan *core.Event, 100)
+			output := make(chan *core.Event, 100)
+			drop := make(chan *core.Event, 100)
+
+			var done atomic.Int32
+			for _, e := range test.events {
+				e.AddHook(func() {
+					done.Add(1)
+				})
+			}
+
+			wg := &sync.WaitGroup{}
+			processor.SetChannels(input, output, drop)
+			wg.Add(1)
+			go func() {
+				processor.Run()
+				wg.Done()
+			}()
+
+			for _, e := range test.events {
+				input <- e
+			}
+			close(input)
+			wg.Wait()
+			processor.Close()
+			close(drop)
+			close(output)
+
+			late, i := 0, 0
+			for e := range output {
+				if _, ok := e.GetLabel("::late"); ok {
+					late++
+					e.Done()
+					continue
+				}
+
+				if i >= len(test.expectEvents) {
+					t.Fatalf("unexpected aggregated event: %v", e.Data)
+				}
+
+				for path, value := range test.expectEvents[i] {
+					got, err := e.GetField(path)
+					if err != nil {
+						t.Fatalf("aggregated event %v has no expected field: %v", i, path)
+					}
+
+					if got != value {
+						t.Fatalf("aggregated event %v field %v - want: %v, got: %v", i, path, value, got)
+					}
+				}
+
+				if done.Load() == int32(len(test.events)) {
+					t.Fatalf("origin events marked as done before aggregated events delivery")
+				}
+				e.Done()
+				i++
+			}
+
+			if i != len(test.expectEvents) {
+				t.Fatalf("unexpected aggregated events count - want: %v, got: %v", len(test.expectEvents), i)
+			}
+
+			if late != test.expectLate {
+				t.Fatalf("unexpected late events count - want: %v, got: %v", test.expectLate, late)
+			}
+
+			if done.Load() != int32(len(test.events)) {
+				t.Fatalf("not all origin events marked as done - want: %v, got: %v", len(test.events), done.Load())
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package aggregate
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var functionsMap = map[string]bool{
+	"count":    true,
+	"sum":      true,
+	"avg":      true,
+	"min":      true,
+	"max":      true,
+	"distinct": true,
+	"first":    true,
+	"last":     true,
+	"collect":  true,
+}
+
+// function describes configured aggregation function
+// percentiles are configured as "pNN" or "pNN.N", e.g. "p99.9"
+type function struct {
+	name     string
+	quantile float64
+}
+
+func parseFunction(f string) (function, error) {
+	if functionsMap[f] {
+		return function{name: f}, nil
+	}
+
+	if strings.HasPrefix(f, "p") {
+		q, err := strconv.ParseFloat(f[1:], 64)
+		if err == nil && q >= 0 && q <= 100 {
+			// "." is a path separator, so p99.9 stored as p99_9
+			return function{name: strings.ReplaceAll(f, ".", "_"), quantile: q / 100}, nil
+		}
+	}
+
+	return function{}, fmt.Errorf("unknown function: %v", f)
+}
+
+type functions struct {
+	list []function
+
+	numbers  bool
+	distinct bool
+	collect  bool
+	quantile bool
+}
+
+func newFunctions(list []function) functions {
+	f := functions{list: list}
+	for _, fn := range list {
+		switch fn.name {
+		case "sum", "avg", "min", "max":
+			f.numbers = true
+		case "distinct":
+			f.distinct = true
+		case "collect":
+			f.collect = true
+		case "count", "first", "last":
+		default:
+			f.numbers = true
+			f.quantile = true
+		}
+	}
+	return f
+}
+
+// aggregator accumulates one field values in one window
+type aggregator struct {
+	fns functions
+
+	count    int
+	numCount int
+	sum      float64
+	min      float64
+	max      float64
+
+	first   any
+	firstTs time.Time
+	last    any
+	lastTs  time.Time
+
+	distinct map[any]struct{}
+	values   []any
+	numbers  []float64
+}
+
+func newAggregator(fns functions) *aggregator {
+	a := &aggregator{fns: fns}
+	if fns.distinct {
+		a.distinct = make(map[any]struct{})
+	}
+	return a
+}
+
+func (a *aggregator) observe(value any, ts time.Time) {
+	if a.count == 0 || ts.Before(a.firstTs) {
+		a.first, a.firstTs = value, ts
+	}
+
+	if a.count == 0 || !ts.Before(a.lastTs) {
+		a.last, a.lastTs = value, ts
+	}
+	a.count++
+
+	if a.fns.distinct {
+		a.distinct[distinctKey(value)] = struct{}{}
+	}
+
+	if a.fns.collect {
+		a.values = append(a.values, value)
+	}
+
+	if a.fns.numbers {
+		if n, ok := toNumber(value); ok {
+			a.observeNumber(n)
+		}
+	}
+}
+
+func (a *aggregator) observeNumber(n float64) {
+	if a.numCount == 0 || n < a.min {
+		a.min = n
+	}
+
+	if a.numCount == 0 || n > a.max {
+		a.max = n
+	}
+
+	a.numCount++
+	a.sum += n
+
+	if a.fns.quantile {
+		a.numbers = append(a.numbers, n)
+	}
+}
+
+// merge adds other aggregator state into current
+// used when session windows are merged
+func (a *aggregator) merge(other *aggregator) {
+	if other.count == 0 {
+		return
+	}
+
+	if a.count == 0 || other.firstTs.Before(a.firstTs) {
+		a.first, a.firstTs = other.first, other.firstTs
+	}
+
+	if a.count == 0 || !other.lastTs.Before(a.lastTs) {
+		a.last, a.lastTs = other.last, other.lastTs
+	}
+	a.count += other.count
+
+	for k := range other.distinct {
+		a.distinct[k] = struct{}{}
+	}
+	a.values = append(a.values, other.values...)
+
+	if other.numCount > 0 {
+		if a.numCount == 0 || other.min < a.min {
+			a.min = other.min
+		}
+
+		if a.numCount == 0 || other.max > a.max {
+			a.max = other.max
+		}
+
+		a.numCount += other.numCount
+		a.sum += other.sum
+		a.numbers = append(a.numbers, other.numbers...)
+	}
+}
+
+func (a *aggregator) result() map[string]any {
+	if a.fns.quantile {
+		slices.Sort(a.numbers)
+	}
+
+	r := make(map[string]any, len(a.fns.list))
+	for _, fn := range a.fns.list {
+		switch fn.name {
+		case "count":
+			r[fn.name] = a.count
+		case "distinct":
+			r[fn.name] = len(a.distinct)
+		case "first":
+			r[fn.name] = a.first
+		case "last":
+			r[fn.name] = a.last
+		case "collect":
+			r[fn.name] = a.values
+		default:
+			if a.numCount == 0 { // no numbers observed
+				continue
+			}
+
+			switch fn.name {
+			case "sum":
+				r[fn.name] = a.sum
+			case "avg":
+				r[fn.name] = a.sum / float64(a.numCount)
+			case "min":
+				r[fn.name] = a.min
+			case "max":
+				r[fn.name] = a.max
+			default:
+				r[fn.name] = percentile(a.numbers, fn.quantile)
+			}
+		}
+	}
+
+	return r
+}
+
+// percentile calculates linearly interpolated percentile
+// of sorted values
+func percentile(sorted []float64, q float64) float64 {
+	if len(sorted) == 1 {
+		return sorted[0]
+	}
+
+	rank := q * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+// distinctKey returns map key for a value
+// not comparable values, like maps and slices, are represented as strings
+func distinctKey(value any) any {
+	if value == nil || reflect.TypeOf(value).Comparable() {
+		return value
+	}
+	return fmt.Sprint(value)
+}
+
+func toNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
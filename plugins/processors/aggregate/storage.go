@@ -0,0 +1,274 @@
+package aggregate
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+)
+
+type storage interface {
+	// observe adds event to windows of a group
+	// it returns true if event is late - all its windows are already flushed
+	// and windows that must be flushed after watermark update
+	observe(g *group, e *core.Event, m *member) (late bool, fired []*window)
+	// tick advances watermark if there are no events for a long time
+	// and returns windows that must be flushed
+	tick(now time.Time) []*window
+	// leave returns all windows that must be flushed on plugin stop
+	leave() []*window
+}
+
+type windowsConfig struct {
+	kind     string
+	size     time.Duration
+	hop      time.Duration
+	gap      time.Duration
+	lateness time.Duration
+	idle     time.Duration
+	fields   map[string]functions
+}
+
+type individualStorage struct {
+	windowsConfig
+	w map[string][]*window // group key -> open windows
+
+	// watermark is the maximum event time observed
+	// window is flushed when watermark passes window end plus allowed lateness
+	watermark   time.Time
+	nextFire    time.Time
+	lastSeen    time.Time
+	lastAdvance time.Time
+}
+
+func newIndividualStorage(c windowsConfig) *individualStorage {
+	return &individualStorage{
+		windowsConfig: c,
+		w:             make(map[string][]*window),
+	}
+}
+
+func (s *individualStorage) observe(g *group, e *core.Event, m *member) (bool, []*window) {
+	ts := e.Timestamp
+
+	switch s.kind {
+	case "session":
+		end := ts.Add(s.gap)
+		if s.closed(end) {
+			return true, nil
+		}
+
+		w := newWindow(g, ts, end, s.fields)
+		w.observe(e, m)
+
+		// new session absorbs all overlapping sessions of the group
+		list := s.w[g.key]
+		kept := list[:0]
+		for _, o := range list {
+			if o.start.Before(w.end) && w.start.Before(o.end) {
+				w.merge(o)
+			} else {
+				kept = append(kept, o)
+			}
+		}
+		s.w[g.key] = append(kept, w)
+		s.schedule(w)
+	default: // tumbling and hopping windows
+		added := false
+		for _, start := range s.starts(ts) {
+			end := start.Add(s.size)
+			if s.closed(end) {
+				continue
+			}
+
+			w := s.find(g, start)
+			if w == nil {
+				w = newWindow(g, start, end, s.fields)
+				s.w[g.key] = append(s.w[g.key], w)
+				s.schedule(w)
+			}
+
+			w.observe(e, m)
+			added = true
+		}
+
+		if !added {
+			return true, nil
+		}
+	}
+
+	s.lastSeen = time.Now()
+	if ts.After(s.watermark) {
+		s.watermark = ts
+	}
+
+	return false, s.fire(false)
+}
+
+func (s *individualStorage) tick(now time.Time) []*window {
+	if s.idle > 0 && !s.lastSeen.IsZero() {
+		// if there are no events for idle timeout
+		// watermark moves forward with wall clock
+		from := s.lastSeen.Add(s.idle)
+		if s.lastAdvance.After(from) {
+			from = s.lastAdvance
+		}
+
+		if now.After(from) {
+			s.watermark = s.watermark.Add(now.Sub(from))
+			s.lastAdvance = now
+		}
+	}
+
+	return s.fire(false)
+}
+
+func (s *individualStorage) leave() []*window {
+	return s.fire(true)
+}
+
+// starts returns start times of all windows, which contain ts
+func (s *individualStorage) starts(ts time.Time) []time.Time {
+	if s.kind == "tumbling" {
+		return []time.Time{ts.Truncate(s.size)}
+	}
+
+	var starts []time.Time
+	for start := ts.Truncate(s.hop); start.Add(s.size).After(ts); start = start.Add(-s.hop) {
+		starts = append(starts, start)
+	}
+	return starts
+}
+
+func (s *individualStorage) find(g *group, start time.Time) *window {
+	for _, w := range s.w[g.key] {
+		if w.start.Equal(start) {
+			return w
+		}
+	}
+	return nil
+}
+
+func (s *individualStorage) closed(end time.Time) bool {
+	return !s.watermark.IsZero() && !end.Add(s.lateness).After(s.watermark)
+}
+
+func (s *individualStorage) schedule(w *window) {
+	fireAt := w.end.Add(s.lateness)
+	if s.nextFire.IsZero() || fireAt.Before(s.nextFire) {
+		s.nextFire = fireAt
+	}
+}
+
+func (s *individualStorage) fire(force bool) []*window {
+	if !force && (s.nextFire.IsZero() || s.watermark.Before(s.nextFire)) {
+		return nil
+	}
+
+	var fired []*window
+	s.nextFire = time.Time{}
+	for key, list := range s.w {
+		kept := list[:0]
+		for _, w := range list {
+			if force || s.closed(w.end) {
+				fired = append(fired, w)
+			} else {
+				kept = append(kept, w)
+				s.schedule(w)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(s.w, key)
+		} else {
+			s.w[key] = kept
+		}
+	}
+
+	slices.SortFunc(fired, func(a, b *window) int {
+		return a.end.Compare(b.end)
+	})
+
+	return fired
+}
+
+var ss = &sharedStorages{
+	s:  make(map[uint64]*sharedStorage),
+	mu: &sync.Mutex{},
+}
+
+type sharedStorages struct {
+	s  map[uint64]*sharedStorage
+	mu *sync.Mutex
+}
+
+func (s *sharedStorages) delete(k uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.s, k)
+}
+
+func (s *sharedStorages) newStorage(k uint64, c windowsConfig) *sharedStorage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if storage, ok := s.s[k]; ok {
+		storage.writers++
+		return storage
+	}
+
+	storage := &sharedStorage{
+		id:      k,
+		writers: 1,
+		storage: newIndividualStorage(c),
+		mu:      &sync.Mutex{},
+	}
+	s.s[k] = storage
+
+	return storage
+}
+
+func newSharedStorage(k uint64, c windowsConfig) *sharedStorage {
+	return ss.newStorage(k, c)
+}
+
+// shared storage is used by all processors in set,
+// so windows and watermark are common for all lines
+// fired windows are emitted by a processor that moved the watermark
+type sharedStorage struct {
+	id      uint64
+	writers int32
+
+	storage *individualStorage
+	mu      *sync.Mutex
+}
+
+func (s *sharedStorage) observe(g *group, e *core.Event, m *member) (bool, []*window) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.storage.observe(g, e, m)
+}
+
+func (s *sharedStorage) tick(now time.Time) []*window {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.storage.tick(now)
+}
+
+// last stopped processor flushes all remaining windows
+func (s *sharedStorage) leave() []*window {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writers--
+	if s.writers > 0 {
+		return nil
+	}
+
+	ss.delete(s.id)
+	return s.storage.leave()
+}
@@ -0,0 +1,89 @@
+package aggregate
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+)
+
+// member is an aggregated event, that may be a member of multiple windows
+// event is marked as done when all windows with it are flushed and delivered
+type member struct {
+	e    *core.Event
+	refs int32
+}
+
+func (m *member) hold() {
+	atomic.AddInt32(&m.refs, 1)
+}
+
+func (m *member) release() {
+	if atomic.AddInt32(&m.refs, -1) == 0 {
+		m.e.Done()
+	}
+}
+
+type group struct {
+	key    string
+	labels map[string]string
+	fields map[string]any
+}
+
+type window struct {
+	group *group
+	start time.Time
+	end   time.Time // exclusive
+
+	count   int
+	aggs    map[string]*aggregator
+	members []*member
+}
+
+func newWindow(g *group, start, end time.Time, fields map[string]functions) *window {
+	w := &window{
+		group: g,
+		start: start,
+		end:   end,
+		aggs:  make(map[string]*aggregator, len(fields)),
+	}
+
+	for path, fns := range fields {
+		w.aggs[path] = newAggregator(fns)
+	}
+
+	return w
+}
+
+func (w *window) observe(e *core.Event, m *member) {
+	w.count++
+
+	for path, a := range w.aggs {
+		value, err := e.GetField(path)
+		if err != nil {
+			continue // if event has no field, skip it
+		}
+		a.observe(value, e.Timestamp)
+	}
+
+	if m != nil {
+		m.hold()
+		w.members = append(w.members, m)
+	}
+}
+
+func (w *window) merge(other *window) {
+	if other.start.Before(w.start) {
+		w.start = other.start
+	}
+
+	if other.end.After(w.end) {
+		w.end = other.end
+	}
+
+	w.count += other.count
+	for path, a := range w.aggs {
+		a.merge(other.aggs[path])
+	}
+	w.members = append(w.members, other.members...)
+}
@@ -1,16 +1,23 @@
 package processors
 
 import (
+	_ "github.com/gekatateam/neptunus/plugins/processors/aggregate"
+	_ "github.com/gekatateam/neptunus/plugins/processors/cel"
 	_ "github.com/gekatateam/neptunus/plugins/processors/clone"
 	_ "github.com/gekatateam/neptunus/plugins/processors/converter"
+	_ "github.com/gekatateam/neptunus/plugins/processors/correlate"
 	_ "github.com/gekatateam/neptunus/plugins/processors/deduplicate"
 	_ "github.com/gekatateam/neptunus/plugins/processors/defaults"
 	_ "github.com/gekatateam/neptunus/plugins/processors/delete"
+	_ "github.com/gekatateam/neptunus/plugins/processors/dissect"
 	_ "github.com/gekatateam/neptunus/plugins/processors/drop"
+	_ "github.com/gekatateam/neptunus/plugins/processors/geoip"
+	_ "github.com/gekatateam/neptunus/plugins/processors/grok"
 	_ "github.com/gekatateam/neptunus/plugins/processors/http"
 	_ "github.com/gekatateam/neptunus/plugins/processors/line"
 	_ "github.com/gekatateam/neptunus/plugins/processors/llm"
 	_ "github.com/gekatateam/neptunus/plugins/processors/log"
+	_ "github.com/gekatateam/neptunus/plugins/processors/lookup"
 	_ "github.com/gekatateam/neptunus/plugins/processors/parser"
 	_ "github.com/gekatateam/neptunus/plugins/processors/regex"
 	_ "github.com/gekatateam/neptunus/plugins/processors/rk"
@@ -18,5 +25,6 @@ import (
 	_ "github.com/gekatateam/neptunus/plugins/processors/starlark"
 	_ "github.com/gekatateam/neptunus/plugins/processors/stats"
 	_ "github.com/gekatateam/neptunus/plugins/processors/template"
+	_ "github.com/gekatateam/neptunus/plugins/processors/throttle"
 	_ "github.com/gekatateam/neptunus/plugins/processors/through"
 )
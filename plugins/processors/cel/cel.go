@@ -0,0 +1,193 @@
+package cel
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/cel-go/cel"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+	common "github.com/gekatateam/neptunus/plugins/common/cel"
+)
+
+type Cel struct {
+	*core.BaseProcessor `mapstructure:"-"`
+	Id                  string            `mapstructure:"id"`
+	RoutingKey          string            `mapstructure:"routing_key"`
+	Labels              map[string]string `mapstructure:"labels"`
+	Fields              map[string]string `mapstructure:"fields"`
+
+	id         cel.Program
+	routingKey cel.Program
+	labels     map[string]cel.Program
+	fields     map[string]cel.Program
+
+	activation *common.Activation
+	results    []result
+}
+
+// all expressions are evaluated over an incoming event
+// and only then results are applied, so expressions
+// do not see each other changes
+type result struct {
+	kind  string
+	key   string
+	value any
+}
+
+const (
+	kindId         = "id"
+	kindRoutingKey = "routing_key"
+	kindLabel      = "label"
+	kindField      = "field"
+)
+
+func (p *Cel) Init() error {
+	env, err := common.NewEnv()
+	if err != nil {
+		return err
+	}
+
+	if len(p.Id) > 0 {
+		id, err := common.Compile(env, p.Id, cel.StringType)
+		if err != nil {
+			return fmt.Errorf("id expression compilation failed: %w", err)
+		}
+		p.id = id
+	}
+
+	if len(p.RoutingKey) > 0 {
+		rk, err := common.Compile(env, p.RoutingKey, cel.StringType)
+		if err != nil {
+			return fmt.Errorf("routing key expression compilation failed: %w", err)
+		}
+		p.routingKey = rk
+	}
+
+	p.labels = make(map[string]cel.Program, len(p.Labels))
+	p.fields = make(map[string]cel.Program, len(p.Fields))
+
+	for l, e := range p.Labels {
+		lp, err := common.Compile(env, e, cel.StringType)
+		if err != nil {
+			return fmt.Errorf("label %v expression compilation failed: %w", l, err)
+		}
+		p.labels[l] = lp
+	}
+
+	for f, e := range p.Fields {
+		fp, err := common.Compile(env, e, nil)
+		if err != nil {
+			return fmt.Errorf("field %v expression compilation failed: %w", f, err)
+		}
+		p.fields[f] = fp
+	}
+
+	p.activation = common.NewActivation(nil)
+	p.results = make([]result, 0, 2+len(p.labels)+len(p.fields))
+
+	return nil
+}
+
+func (p *Cel) Close() error {
+	return nil
+}
+
+func (p *Cel) Run() {
+	for e := range p.In {
+		now := time.Now()
+		hasError := false
+		p.activation.Reset(e)
+		p.results = p.results[:0]
+
+		if p.id != nil {
+			hasError = !p.eval(e, p.id, kindId, "") || hasError
+		}
+
+		if p.routingKey != nil {
+			hasError = !p.eval(e, p.routingKey, kindRoutingKey, "") || hasError
+		}
+
+		for label, lp := range p.labels {
+			hasError = !p.eval(e, lp, kindLabel, label) || hasError
+		}
+
+		for field, fp := range p.fields {
+			hasError = !p.eval(e, fp, kindField, field) || hasError
+		}
+
+		for _, r := range p.results {
+			switch r.kind {
+			case kindId:
+				e.Id = r.value.(string)
+			case kindRoutingKey:
+				e.RoutingKey = r.value.(string)
+			case kindLabel:
+				e.SetLabel(r.key, r.value.(string))
+			case kindField:
+				if err := e.SetField(r.key, r.value); err != nil {
+					p.Log.Error("expression evaluated successfully, but field set failed",
+						"error", err,
+						slog.Group("event",
+							"id", e.Id,
+							"key", e.RoutingKey,
+							"field", r.key,
+						),
+					)
+					e.StackError(err)
+					hasError = true
+				}
+			}
+		}
+
+		p.Out <- e
+		if hasError {
+			p.Observe(metrics.EventFailed, time.Since(now))
+		} else {
+			p.Observe(metrics.EventAccepted, time.Since(now))
+		}
+	}
+}
+
+func (p *Cel) eval(e *core.Event, program cel.Program, kind, key string) bool {
+	var native any
+	val, _, err := program.Eval(p.activation)
+	if err != nil {
+		goto EVAL_FAILED
+	}
+
+	if kind == kindField {
+		native, err = common.ToNative(val)
+		if err != nil {
+			goto EVAL_FAILED
+		}
+		p.results = append(p.results, result{kind: kind, key: key, value: native})
+		return true
+	}
+
+	if s, ok := val.Value().(string); ok {
+		p.results = append(p.results, result{kind: kind, key: key, value: s})
+		return true
+	}
+	err = fmt.Errorf("unknown expression result, expected string, got %v", val.Type())
+
+EVAL_FAILED:
+	p.Log.Error("expression evaluation failed",
+		"error", err,
+		slog.Group("event",
+			"id", e.Id,
+			"key", e.RoutingKey,
+		),
+	)
+	e.StackError(fmt.Errorf("%v %v expression evaluation failed: %w", kind, key, err))
+	return false
+}
+
+func init() {
+	plugins.AddProcessor("cel", func() core.Processor {
+		return &Cel{}
+	})
+}
@@ -0,0 +1,183 @@
+package cel_test
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/pkg/mapstructure"
+	"github.com/gekatateam/neptunus/plugins/processors/cel"
+)
+
+func TestCel(t *testing.T) {
+	tests := map[string]struct {
+		config       map[string]any
+		event        *core.Event
+		expectId     string
+		expectKey    string
+		expectLabels map[string]string
+		expectFields map[string]any
+		expectErrors []string
+	}{
+		"id-and-routing-key": {
+			config: map[string]any{
+				"id":          `"id-" + labels["env"]`,
+				"routing_key": `routing_key + "." + labels["env"]`,
+			},
+			event: &core.Event{
+				RoutingKey: "logs",
+				Labels:     map[string]string{"env": "prod"},
+				Data:       map[string]any{},
+			},
+			expectId:     "id-prod",
+			expectKey:    "logs.prod",
+			expectLabels: map[string]string{"env": "prod"},
+			expectFields: map[string]any{},
+		},
+		"fields-and-labels": {
+			config: map[string]any{
+				"labels": map[string]string{
+					"level":  `fields.level.upperAscii()`,
+					"status": `string(fields.request.status)`,
+				},
+				"fields": map[string]string{
+					"request.failed": `fields.request.status >= 500`,
+					"meta":           `{"env": labels["env"], "codes": [fields.request.status, 1]}`,
+					// expressions do not see each other changes
+					"level": `labels.?level.orValue("none")`,
+				},
+			},
+			event: &core.Event{
+				Labels: map[string]string{"env": "prod"},
+				Data: map[string]any{
+					"level":   "warn",
+					"request": map[string]any{"status": 503},
+				},
+			},
+			expectLabels: map[string]string{
+				"env":    "prod",
+				"level":  "WARN",
+				"status": "503",
+			},
+			expectFields: map[string]any{
+				"level": "none",
+				"request": map[string]any{
+					"status": 503,
+					"failed": true,
+				},
+				"meta": map[string]any{
+					"env":   "prod",
+					"codes": []any{int64(503), int64(1)},
+				},
+			},
+		},
+		"non-string-label-result": {
+			config: map[string]any{
+				"labels": map[string]string{
+					"status": `fields.request.status`,
+					"env":    `"dev"`,
+				},
+			},
+			event: &core.Event{
+				Labels: map[string]string{"env": "prod"},
+				Data: map[string]any{
+					"request": map[string]any{"status": 503},
+				},
+			},
+			expectLabels: map[string]string{"env": "dev"},
+			expectFields: map[string]any{
+				"request": map[string]any{"status": 503},
+			},
+			expectErrors: []string{"label status expression evaluation failed: unknown expression result, expected string, got int"},
+		},
+		"field-conversion-failure": {
+			config: map[string]any{
+				"fields": map[string]string{
+					"codes": `{1: "one"}`,
+					"ok":    `true`,
+				},
+			},
+			event: &core.Event{
+				Data: map[string]any{},
+			},
+			expectFields: map[string]any{"ok": true},
+			expectErrors: []string{"field codes expression evaluation failed: map key must be a string, got int"},
+		},
+		"field-evaluation-failure": {
+			config: map[string]any{
+				"fields": map[string]string{
+					"name": `fields.user.name`,
+				},
+			},
+			event: &core.Event{
+				Data: map[string]any{},
+			},
+			expectFields: map[string]any{},
+			expectErrors: []string{"field name expression evaluation failed: no such key: user"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			processor := &cel.Cel{
+				BaseProcessor: &core.BaseProcessor{
+					Log: logger.Mock(),
+					Obs: metrics.ObserveMock,
+				},
+			}
+			if err := mapstructure.Decode(test.config, processor); err != nil {
+				t.Fatalf("processor config not applied: %v", err)
+			}
+			if err := processor.Init(); err != nil {
+				t.Fatalf("processor not initialized: %v", err)
+			}
+
+			input := make(chan *core.Event, 1)
+			output := make(chan *core.Event, 1)
+			processor.SetChannels(input, output, nil)
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				processor.Run()
+				wg.Done()
+			}()
+
+			input <- test.event
+			close(input)
+			processor.Close()
+			wg.Wait()
+
+			e := <-output
+			if e.Id != test.expectId {
+				t.Fatalf("unexpected id, want: %v, got: %v", test.expectId, e.Id)
+			}
+
+			if e.RoutingKey != test.expectKey {
+				t.Fatalf("unexpected routing key, want: %v, got: %v", test.expectKey, e.RoutingKey)
+			}
+
+			if !reflect.DeepEqual(e.Labels, test.expectLabels) {
+				t.Fatalf("unexpected labels, want: %v, got: %v", test.expectLabels, e.Labels)
+			}
+
+			if !reflect.DeepEqual(e.Data, test.expectFields) {
+				t.Fatalf("unexpected fields, want: %#v, got: %#v", test.expectFields, e.Data)
+			}
+
+			if len(e.Errors) != len(test.expectErrors) {
+				t.Fatalf("unexpected errors count, want: %v, got: %v; errors: %v", len(test.expectErrors), len(e.Errors), e.Errors)
+			}
+
+			for i, err := range e.Errors {
+				if !strings.Contains(err.Error(), test.expectErrors[i]) {
+					t.Fatalf("unexpected error, want: %v, got: %v", test.expectErrors[i], err)
+				}
+			}
+		})
+	}
+}
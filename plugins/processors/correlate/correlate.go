@@ -0,0 +1,299 @@
+package correlate
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/cel-go/cel"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+	common "github.com/gekatateam/neptunus/plugins/common/cel"
+)
+
+type Correlate struct {
+	id                  uint64
+	*core.BaseProcessor `mapstructure:"-"`
+	Mode                string        `mapstructure:"mode"`
+	KeyLabel            string        `mapstructure:"key_label"`
+	KeyField            string        `mapstructure:"key_field"`
+	Timeout             time.Duration `mapstructure:"timeout"`
+	Prolong             bool          `mapstructure:"prolong"`
+	MaxEvents           int           `mapstructure:"max_events"`
+	CloseWhen           string        `mapstructure:"close_when"`
+	RoutingKey          string        `mapstructure:"routing_key"`
+	Merge               string        `mapstructure:"merge"`
+	EventsPath          string        `mapstructure:"events_path"`
+	InfoPath            string        `mapstructure:"info_path"`
+	DropOrigin          bool          `mapstructure:"drop_origin"`
+
+	storage    storage
+	closeWhen  cel.Program
+	activation *common.Activation
+}
+
+const (
+	reasonCondition = "condition"
+	reasonLimit     = "limit"
+	reasonTimeout   = "timeout"
+	reasonStop      = "stop"
+)
+
+func (p *Correlate) Init() error {
+	if len(p.KeyLabel) == 0 && len(p.KeyField) == 0 {
+		return errors.New("key_label or key_field required")
+	}
+
+	if len(p.KeyLabel) > 0 && len(p.KeyField) > 0 {
+		return errors.New("only one of key_label or key_field must be set")
+	}
+
+	if p.Timeout <= 0 {
+		return errors.New("timeout must be greater than zero")
+	}
+
+	if p.MaxEvents < 0 {
+		p.MaxEvents = 0
+	}
+
+	switch p.Merge {
+	case "list":
+		if len(p.EventsPath) == 0 {
+			return errors.New("events_path required in list merge mode")
+		}
+	case "fields":
+	default:
+		return fmt.Errorf("unknown merge mode: %v, expected one of: list, fields", p.Merge)
+	}
+
+	if len(p.InfoPath) == 0 {
+		return errors.New("info_path required")
+	}
+
+	if len(p.CloseWhen) > 0 {
+		env, err := common.NewEnv()
+		if err != nil {
+			return err
+		}
+
+		program, err := common.Compile(env, p.CloseWhen, cel.BoolType)
+		if err != nil {
+			return fmt.Errorf("close_when expression compilation failed: %w", err)
+		}
+
+		p.closeWhen = program
+		p.activation = common.NewActivation(nil)
+	}
+
+	switch p.Mode {
+	case "individual":
+		p.storage = newIndividualStorage(p.Timeout, p.Prolong, p.MaxEvents)
+	case "shared":
+		p.storage = newSharedStorage(p.id, p.Timeout, p.Prolong, p.MaxEvents)
+	default:
+		return fmt.Errorf("unknown mode: %v, expected one of: shared, individual", p.Mode)
+	}
+
+	return nil
+}
+
+func (p *Correlate) Close() error {
+	return nil
+}
+
+func (p *Correlate) SetId(id uint64) {
+	p.id = id
+}
+
+func (p *Correlate) Run() {
+	period := p.Timeout / 10
+	if period < 100*time.Millisecond {
+		period = 100 * time.Millisecond
+	}
+	ticker := time.NewTicker(period)
+
+	for {
+		select {
+		case now := <-ticker.C:
+			for _, t := range p.storage.expired(now) {
+				p.emit(t, reasonTimeout)
+			}
+		case e, ok := <-p.In:
+			if !ok {
+				ticker.Stop()
+				for _, t := range p.storage.leave() {
+					p.emit(t, reasonStop)
+				}
+				return
+			}
+
+			now := time.Now()
+			key, ok := p.key(e)
+			if !ok { // if event has no key, skip it
+				p.Out <- e
+				p.Observe(metrics.EventAccepted, time.Since(now))
+				continue
+			}
+
+			closing, err := p.closing(e)
+			if err != nil {
+				p.Log.Error("close_when expression evaluation failed",
+					"error", err,
+					slog.Group("event",
+						"id", e.Id,
+						"key", e.RoutingKey,
+					),
+				)
+				e.StackError(fmt.Errorf("close_when expression evaluation failed: %w", err))
+				p.Out <- e
+				p.Observe(metrics.EventFailed, time.Since(now))
+				continue
+			}
+
+			// member events are stored until transaction is closed
+			// and marked as done only after merged event delivery
+			member := e
+			if !p.DropOrigin {
+				member = e.Clone()
+				p.Out <- e
+			}
+
+			if t := p.storage.add(key, member, closing); t != nil {
+				if closing {
+					p.emit(t, reasonCondition)
+				} else {
+					p.emit(t, reasonLimit)
+				}
+			}
+			p.Observe(metrics.EventAccepted, time.Since(now))
+		}
+	}
+}
+
+func (p *Correlate) key(e *core.Event) (string, bool) {
+	if len(p.KeyLabel) > 0 {
+		return e.GetLabel(p.KeyLabel)
+	}
+
+	value, err := e.GetField(p.KeyField)
+	if err != nil {
+		return "", false
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case map[string]any, []any, nil:
+		return "", false
+	default:
+		return fmt.Sprint(v), true
+	}
+}
+
+func (p *Correlate) closing(e *core.Event) (bool, error) {
+	if p.closeWhen == nil {
+		return false, nil
+	}
+
+	p.activation.Reset(e)
+	result, _, err := p.closeWhen.Eval(p.activation)
+	if err != nil {
+		return false, err
+	}
+
+	ok, isBool := result.Value().(bool)
+	if !isBool {
+		return false, fmt.Errorf("unknown expression result, expected bool, got %v", result.Type())
+	}
+
+	return ok, nil
+}
+
+func (p *Correlate) emit(t *transaction, reason string) {
+	first, last := t.events[0], t.events[len(t.events)-1]
+
+	routingKey := p.RoutingKey
+	if len(routingKey) == 0 {
+		routingKey = first.RoutingKey
+	}
+
+	e := core.NewEvent(routingKey)
+	e.Timestamp = first.Timestamp
+
+	members := make([]any, 0, len(t.events))
+	data := make(map[string]any)
+	for _, m := range t.events {
+		for k, v := range m.Labels {
+			e.SetLabel(k, v)
+		}
+
+		for _, tag := range m.Tags {
+			e.AddTag(tag)
+		}
+
+		switch p.Merge {
+		case "list":
+			members = append(members, m.Data)
+		case "fields":
+			if d, ok := m.Data.(map[string]any); ok {
+				merge(data, d)
+			}
+		}
+	}
+
+	e.Data = data
+	if p.Merge == "list" {
+		e.SetField(p.EventsPath, members)
+	}
+
+	e.SetField(p.InfoPath, map[string]any{
+		"key":      t.key,
+		"count":    len(t.events),
+		"duration": last.Timestamp.Sub(first.Timestamp),
+		"reason":   reason,
+	})
+
+	events := t.events
+	e.AddHook(func() {
+		for _, m := range events {
+			m.Done()
+		}
+	})
+
+	p.Out <- e
+}
+
+// merge deeply copies src map into dst
+// values of src overwrites values of dst, except maps, which are merged
+func merge(dst, src map[string]any) {
+	for k, v := range src {
+		if srcMap, ok := v.(map[string]any); ok {
+			dstMap, ok := dst[k].(map[string]any)
+			if !ok {
+				dstMap = make(map[string]any, len(srcMap))
+				dst[k] = dstMap
+			}
+			merge(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+func init() {
+	plugins.AddProcessor("correlate", func() core.Processor {
+		return &Correlate{
+			Mode:       "shared",
+			Timeout:    time.Minute,
+			MaxEvents:  1000,
+			RoutingKey: "neptunus.generated.correlation",
+			Merge:      "list",
+			EventsPath: "events",
+			InfoPath:   "correlation",
+			DropOrigin: true,
+		}
+	})
+}
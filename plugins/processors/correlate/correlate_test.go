@@ -0,0 +1,157 @@
+package correlate_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/pkg/mapstructure"
+	"github.com/gekatateam/neptunus/plugins/processors/correlate"
+)
+
+func TestCorrelate(t *testing.T) {
+	tests := map[string]struct {
+		config       map[string]any
+		input        chan *core.Event
+		output       chan *core.Event
+		drop         chan *core.Event
+		events       []*core.Event
+		expectEvents map[string]map[string]any // correlation key -> expected fields
+		expectOrigin int
+	}{
+		"close-by-condition-and-stop": {
+			config: map[string]any{
+				"mode":        "individual",
+				"key_label":   "trx",
+				"timeout":     "1m",
+				"close_when":  `fields.?status.orValue("") == "done"`,
+				"merge":       "list",
+				"events_path": "events",
+				"info_path":   "correlation",
+				"drop_origin": true,
+			},
+			input:  make(chan *core.Event, 100),
+			output: make(chan *core.Event, 100),
+			drop:   make(chan *core.Event, 100),
+			events: []*core.Event{
+				{
+					Labels: map[string]string{"trx": "1"},
+					Data:   map[string]any{"status": "started"},
+				},
+				{
+					Labels: map[string]string{"trx": "2"},
+					Data:   map[string]any{"status": "started"},
+				},
+				{
+					Labels: map[string]string{"trx": "1"},
+					Data:   map[string]any{"status": "done"},
+				},
+				{
+					Labels: map[string]string{"no-trx": "1"},
+					Data:   map[string]any{"status": "done"},
+				},
+			},
+			expectEvents: map[string]map[string]any{
+				"1": {"correlation.count": 2, "correlation.reason": "condition", "events.1.status": "done"},
+				"2": {"correlation.count": 1, "correlation.reason": "stop", "events.0.status": "started"},
+			},
+			expectOrigin: 1,
+		},
+		"merge-fields-with-limit": {
+			config: map[string]any{
+				"mode":        "individual",
+				"key_field":   "trx.id",
+				"timeout":     "1m",
+				"max_events":  2,
+				"merge":       "fields",
+				"info_path":   "correlation",
+				"drop_origin": false,
+			},
+			input:  make(chan *core.Event, 100),
+			output: make(chan *core.Event, 100),
+			drop:   make(chan *core.Event, 100),
+			events: []*core.Event{
+				{
+					Data: map[string]any{"trx": map[string]any{"id": 1}, "request": "ping"},
+				},
+				{
+					Data: map[string]any{"trx": map[string]any{"id": 1}, "response": "pong"},
+				},
+			},
+			expectEvents: map[string]map[string]any{
+				"1": {"correlation.count": 2, "correlation.reason": "limit", "request": "ping", "response": "pong"},
+			},
+			expectOrigin: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			processor := &correlate.Correlate{
+				BaseProcessor: &core.BaseProcessor{
+					Log: logger.Mock(),
+					Obs: metrics.ObserveMock,
+				},
+			}
+			if err := mapstructure.Decode(test.config, processor); err != nil {
+				t.Fatalf("processor config not applied: %v", err)
+			}
+			if err := processor.Init(); err != nil {
+				t.Fatalf("processor not initialized: %v", err)
+			}
+
+			wg := &sync.WaitGroup{}
+			processor.SetChannels(test.input, test.output, test.drop)
+			wg.Add(1)
+			go func() {
+				processor.Run()
+				wg.Done()
+			}()
+
+			for _, e := range test.events {
+				test.input <- e
+			}
+			close(test.input)
+			processor.Close()
+			wg.Wait()
+			close(test.drop)
+			close(test.output)
+
+			origin := 0
+			for e := range test.output {
+				key, err := e.GetField("correlation.key")
+				if err != nil {
+					origin++
+					continue
+				}
+
+				expected, ok := test.expectEvents[key.(string)]
+				if !ok {
+					t.Fatalf("unexpected correlation key: %v", key)
+				}
+
+				for path, value := range expected {
+					got, err := e.GetField(path)
+					if err != nil {
+						t.Fatalf("correlation event %v has no expected field: %v", key, path)
+					}
+
+					if got != value {
+						t.Fatalf("correlation event %v field %v - want: %v, got: %v", key, path, value, got)
+					}
+				}
+				delete(test.expectEvents, key.(string))
+			}
+
+			if len(test.expectEvents) > 0 {
+				t.Fatalf("not all expected correlation events produced, left: %v", test.expectEvents)
+			}
+
+			if origin != test.expectOrigin {
+				t.Fatalf("unexpected origin events count - want: %v, got: %v", test.expectOrigin, origin)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package correlate
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+)
+
+type transaction struct {
+	key      string
+	events   []*core.Event
+	deadline time.Time
+}
+
+type storage interface {
+	// add appends event to a key transaction
+	// if transaction must be closed, it is removed from storage and returned
+	add(key string, e *core.Event, closing bool) *transaction
+	// expired removes and returns transactions whose deadline has passed
+	expired(now time.Time) []*transaction
+	// leave removes and returns all transactions that must be emitted on plugin stop
+	leave() []*transaction
+}
+
+type individualStorage struct {
+	t map[string]*transaction
+
+	timeout   time.Duration
+	prolong   bool
+	maxEvents int
+}
+
+func newIndividualStorage(timeout time.Duration, prolong bool, maxEvents int) *individualStorage {
+	return &individualStorage{
+		t:         make(map[string]*transaction),
+		timeout:   timeout,
+		prolong:   prolong,
+		maxEvents: maxEvents,
+	}
+}
+
+func (s *individualStorage) add(key string, e *core.Event, closing bool) *transaction {
+	now := time.Now()
+
+	t, ok := s.t[key]
+	if !ok {
+		t = &transaction{
+			key:      key,
+			deadline: now.Add(s.timeout),
+		}
+		s.t[key] = t
+	}
+
+	t.events = append(t.events, e)
+	if s.prolong {
+		t.deadline = now.Add(s.timeout)
+	}
+
+	if closing || (s.maxEvents > 0 && len(t.events) >= s.maxEvents) {
+		delete(s.t, key)
+		return t
+	}
+
+	return nil
+}
+
+func (s *individualStorage) expired(now time.Time) []*transaction {
+	var expired []*transaction
+	for k, t := range s.t {
+		if now.After(t.deadline) {
+			expired = append(expired, t)
+			delete(s.t, k)
+		}
+	}
+	return expired
+}
+
+func (s *individualStorage) leave() []*transaction {
+	all := make([]*transaction, 0, len(s.t))
+	for _, t := range s.t {
+		all = append(all, t)
+	}
+	clear(s.t)
+	return all
+}
+
+var ss = &sharedStorages{
+	s:  make(map[uint64]*sharedStorage),
+	mu: &sync.Mutex{},
+}
+
+type sharedStorages struct {
+	s  map[uint64]*sharedStorage
+	mu *sync.Mutex
+}
+
+func (s *sharedStorages) delete(k uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.s, k)
+}
+
+func (s *sharedStorages) newStorage(k uint64, timeout time.Duration, prolong bool, maxEvents int) *sharedStorage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if storage, ok := s.s[k]; ok {
+		storage.writers++
+		return storage
+	}
+
+	storage := &sharedStorage{
+		id:      k,
+		writers: 1,
+		storage: newIndividualStorage(timeout, prolong, maxEvents),
+		mu:      &sync.Mutex{},
+	}
+	s.s[k] = storage
+
+	return storage
+}
+
+func newSharedStorage(k uint64, timeout time.Duration, prolong bool, maxEvents int) *sharedStorage {
+	return ss.newStorage(k, timeout, prolong, maxEvents)
+}
+
+// shared storage is used by all processors in set
+// transactions closed by condition are emitted by a processor that consumed closing event
+// expired transactions are emitted by a processor that found them first
+type sharedStorage struct {
+	id      uint64
+	writers int32
+
+	storage *individualStorage
+	mu      *sync.Mutex
+}
+
+func (s *sharedStorage) add(key string, e *core.Event, closing bool) *transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.storage.add(key, e, closing)
+}
+
+func (s *sharedStorage) expired(now time.Time) []*transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.storage.expired(now)
+}
+
+// last stopped processor emits all remaining transactions
+func (s *sharedStorage) leave() []*transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writers--
+	if s.writers > 0 {
+		return nil
+	}
+
+	ss.delete(s.id)
+	return s.storage.leave()
+}
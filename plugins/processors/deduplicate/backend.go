@@ -0,0 +1,103 @@
+package deduplicate
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// backend stores seen keys
+type backend interface {
+	// add stores a key and reports whether it is unique
+	// false means that key already exists
+	add(key string) (unique bool, err error)
+	close() error
+}
+
+type redisBackend struct {
+	client   redis.UniversalClient
+	keyspace string
+	ttl      time.Duration
+}
+
+func (b *redisBackend) add(key string) (bool, error) {
+	boolCmd := b.client.SetNX(context.Background(), b.keyspace+key, time.Now().String(), b.ttl)
+	if err := boolCmd.Err(); err != nil {
+		return false, err
+	}
+
+	// false means than key not set because key already exists
+	return boolCmd.Val(), nil
+}
+
+func (b *redisBackend) close() error {
+	return b.client.Close()
+}
+
+var backendStorage = &sharedBackendStorage{
+	mu:       &sync.Mutex{},
+	backends: make(map[string]*sharedBackend),
+}
+
+// in-process backends may be shared between processors in set
+// or between all processors, that use the same file
+type sharedBackendStorage struct {
+	mu       *sync.Mutex
+	backends map[string]*sharedBackend
+}
+
+type sharedBackend struct {
+	backend
+	id       string
+	settings any
+	refs     int
+}
+
+// settings are backend configuration, shared backend can be reused
+// only with the same settings, e.g. one bolt file with another bucket or ttl is rejected
+func (s *sharedBackendStorage) LoadOrCreate(id string, settings any, create func() (backend, error)) (backend, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if b, ok := s.backends[id]; ok {
+		if !reflect.DeepEqual(b.settings, settings) {
+			return nil, fmt.Errorf("%v backend is already used with different settings: %+v", id, b.settings)
+		}
+
+		b.refs++
+		return b, nil
+	}
+
+	b, err := create()
+	if err != nil {
+		return nil, err
+	}
+
+	shared := &sharedBackend{
+		backend:  b,
+		id:       id,
+		settings: settings,
+		refs:     1,
+	}
+	s.backends[id] = shared
+
+	return shared, nil
+}
+
+// backend is closed when the last processor closes it
+func (b *sharedBackend) close() error {
+	backendStorage.mu.Lock()
+	defer backendStorage.mu.Unlock()
+
+	b.refs--
+	if b.refs > 0 {
+		return nil
+	}
+
+	delete(backendStorage.backends, b.id)
+	return b.backend.close()
+}
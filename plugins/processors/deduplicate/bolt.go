@@ -0,0 +1,143 @@
+package deduplicate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type Bolt struct {
+	Path            string        `mapstructure:"path"`
+	Bucket          string        `mapstructure:"bucket"`
+	TTL             time.Duration `mapstructure:"ttl"`
+	CleanupInterval time.Duration `mapstructure:"cleanup_interval"`
+	Timeout         time.Duration `mapstructure:"timeout"`
+}
+
+// boltBackend stores keys in a bbolt database file
+// with key expiration time as value, so keys are survive restarts
+//
+// database file can be opened only once, so backend is shared
+// between all processors with the same path
+type boltBackend struct {
+	db     *bolt.DB
+	bucket []byte
+	ttl    time.Duration
+	log    *slog.Logger
+	done   chan struct{}
+}
+
+func newBoltBackend(c Bolt, log *slog.Logger) (*boltBackend, error) {
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0o755); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(c.Path, 0o600, &bolt.Options{Timeout: c.Timeout})
+	if err != nil {
+		return nil, fmt.Errorf("database opening failed: %w", err)
+	}
+
+	b := &boltBackend{
+		db:     db,
+		bucket: []byte(c.Bucket),
+		ttl:    c.TTL,
+		log:    log,
+		done:   make(chan struct{}),
+	}
+
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(b.bucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("bucket creation failed: %w", err)
+	}
+
+	if b.ttl > 0 && c.CleanupInterval > 0 {
+		go b.cleanup(c.CleanupInterval)
+	}
+
+	return b, nil
+}
+
+func (b *boltBackend) add(key string) (bool, error) {
+	var unique bool
+	now := time.Now()
+
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.bucket)
+
+		if v := bucket.Get([]byte(key)); v != nil {
+			expireAt := int64(binary.BigEndian.Uint64(v))
+			if expireAt == 0 || now.UnixNano() < expireAt {
+				unique = false
+				return nil
+			}
+		}
+
+		var expireAt int64
+		if b.ttl > 0 {
+			expireAt = now.Add(b.ttl).UnixNano()
+		}
+
+		unique = true
+		return bucket.Put([]byte(key), binary.BigEndian.AppendUint64(nil, uint64(expireAt)))
+	})
+
+	return unique, err
+}
+
+func (b *boltBackend) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+		case <-ticker.C:
+			now := time.Now().UnixNano()
+			deleted := 0
+			err := b.db.Update(func(tx *bolt.Tx) error {
+				bucket := tx.Bucket(b.bucket)
+
+				// keys must not be deleted while iterating with cursor
+				var expired [][]byte
+				bucket.ForEach(func(k, v []byte) error {
+					if expireAt := int64(binary.BigEndian.Uint64(v)); expireAt > 0 && now >= expireAt {
+						expired = append(expired, bytes.Clone(k))
+					}
+					return nil
+				})
+
+				for _, k := range expired {
+					if err := bucket.Delete(k); err != nil {
+						return err
+					}
+				}
+
+				deleted = len(expired)
+				return nil
+			})
+
+			if err != nil {
+				b.log.Error("expired keys cleanup failed",
+					"error", err,
+				)
+			} else {
+				b.log.Debug(fmt.Sprintf("expired keys cleanup done, %v keys deleted", deleted))
+			}
+		}
+	}
+}
+
+func (b *boltBackend) close() error {
+	close(b.done)
+	return b.db.Close()
+}
@@ -3,97 +3,152 @@ package deduplicate
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/redis/go-redis/v9"
-
 	"github.com/gekatateam/neptunus/core"
 	"github.com/gekatateam/neptunus/metrics"
 	"github.com/gekatateam/neptunus/plugins"
+	common "github.com/gekatateam/neptunus/plugins/common/redis"
 	"github.com/gekatateam/neptunus/plugins/common/retryer"
 	"github.com/gekatateam/neptunus/plugins/common/tls"
 )
 
+// key parts are joined with a char that is unlikely to be in a value
+const keySeparator = "\x00"
+
 type Deduplicate struct {
 	*core.BaseProcessor `mapstructure:"-"`
-	IdempotencyKey      string `mapstructure:"idempotency_key"`
-	Redis               Redis  `mapstructure:"redis"`
+	IdempotencyKey      string   `mapstructure:"idempotency_key"`
+	KeyLabels           []string `mapstructure:"key_labels"`
+	KeyFields           []string `mapstructure:"key_fields"`
+	Backend             string   `mapstructure:"backend"`
+	Redis               Redis    `mapstructure:"redis"`
+	Memory              Memory   `mapstructure:"memory"`
+	Filter              Filter   `mapstructure:"filter"`
+	Bolt                Bolt     `mapstructure:"bolt"`
 	*retryer.Retryer    `mapstructure:",squash"`
 
-	id     uint64
-	client redis.UniversalClient
+	id      uint64
+	backend backend
 }
 
 type Redis struct {
-	Shared           bool          `mapstructure:"shared"`
-	Servers          []string      `mapstructure:"servers"`
-	Username         string        `mapstructure:"username"`
-	Password         string        `mapstructure:"password"`
-	Keyspace         string        `mapstructure:"keyspace"`
-	TTL              time.Duration `mapstructure:"ttl"`
-	Timeout          time.Duration `mapstructure:"timeout"`
-	ConnsMaxIdleTime time.Duration `mapstructure:"conns_max_idle_time"`
-	ConnsMaxLifetime time.Duration `mapstructure:"conns_max_life_time"`
-	ConnsMaxOpen     int           `mapstructure:"conns_max_open"`
-	ConnsMaxIdle     int           `mapstructure:"conns_max_idle"`
-
-	*tls.TLSClientConfig `mapstructure:",squash"`
+	Shared   bool          `mapstructure:"shared"`
+	Keyspace string        `mapstructure:"keyspace"`
+	TTL      time.Duration `mapstructure:"ttl"`
+
+	*common.Redis `mapstructure:",squash"`
 }
 
 func (p *Deduplicate) Init() error {
-	if len(p.IdempotencyKey) == 0 {
-		return errors.New("idempotency_key required")
+	// idempotency key is a shortcut for a single label key
+	if len(p.IdempotencyKey) > 0 {
+		p.KeyLabels = append([]string{p.IdempotencyKey}, p.KeyLabels...)
 	}
 
-	if len(p.Redis.Servers) == 0 {
-		return errors.New("at least one Redis server address required")
+	if len(p.KeyLabels) == 0 && len(p.KeyFields) == 0 {
+		return errors.New("idempotency_key, key_labels or key_fields required")
 	}
 
-	tlsConfig, err := p.Redis.TLSClientConfig.Config()
-	if err != nil {
+	switch p.Backend {
+	case "redis":
+		return p.initRedis()
+	case "memory":
+		if p.Memory.TTL < 0 || p.Memory.MaxKeys < 0 {
+			return errors.New("memory ttl and max_keys must not be negative")
+		}
+
+		if !p.Memory.Shared {
+			p.backend = newMemoryBackend(p.Memory)
+			return nil
+		}
+
+		b, err := backendStorage.LoadOrCreate(fmt.Sprintf("memory:%v", p.id), p.Memory, func() (backend, error) {
+			return newMemoryBackend(p.Memory), nil
+		})
+		p.backend = b
 		return err
+	case "filter":
+		if p.Filter.Capacity == 0 {
+			return errors.New("filter capacity must be greater than zero")
+		}
+
+		if p.Filter.FalsePositiveRate <= 0 || p.Filter.FalsePositiveRate >= 1 {
+			return errors.New("filter false_positive_rate must be between 0 and 1")
+		}
+
+		if !p.Filter.Shared {
+			p.backend = newFilterBackend(p.Filter)
+			return nil
+		}
+
+		b, err := backendStorage.LoadOrCreate(fmt.Sprintf("filter:%v", p.id), p.Filter, func() (backend, error) {
+			return newFilterBackend(p.Filter), nil
+		})
+		p.backend = b
+		return err
+	case "bolt":
+		if len(p.Bolt.Path) == 0 {
+			return errors.New("bolt path required")
+		}
+
+		if len(p.Bolt.Bucket) == 0 {
+			return errors.New("bolt bucket required")
+		}
+
+		path, err := filepath.Abs(p.Bolt.Path)
+		if err != nil {
+			return err
+		}
+		p.Bolt.Path = path
+
+		b, err := backendStorage.LoadOrCreate("bolt:"+path, p.Bolt, func() (backend, error) {
+			return newBoltBackend(p.Bolt, p.Log)
+		})
+		p.backend = b
+		return err
+	default:
+		return fmt.Errorf("unknown backend: %v, expected one of: redis, memory, filter, bolt", p.Backend)
 	}
+}
 
+func (p *Deduplicate) initRedis() error {
 	if len(p.Redis.Keyspace) > 0 && !strings.HasSuffix(p.Redis.Keyspace, ":") {
 		p.Redis.Keyspace = p.Redis.Keyspace + ":"
 	}
 
-	// also, pool options:
-	// MinIdleConns    int
-	// MaxActiveConns  int
-	p.client = redis.NewUniversalClient(&redis.UniversalOptions{
-		Addrs:                 p.Redis.Servers,
-		Username:              p.Redis.Username,
-		Password:              p.Redis.Password,
-		MaxRetries:            0,
-		DialTimeout:           p.Redis.Timeout,
-		ReadTimeout:           p.Redis.Timeout,
-		WriteTimeout:          p.Redis.Timeout,
-		ContextTimeoutEnabled: true,
-		PoolSize:              p.Redis.ConnsMaxOpen,
-		PoolTimeout:           p.Redis.Timeout,
-		MaxIdleConns:          p.Redis.ConnsMaxIdle,
-		ConnMaxIdleTime:       p.Redis.ConnsMaxIdleTime,
-		ConnMaxLifetime:       p.Redis.ConnsMaxLifetime,
-		TLSConfig:             tlsConfig,
-	})
+	client, err := p.Redis.Client()
+	if err != nil {
+		return err
+	}
 
 	if p.Redis.Shared {
-		p.client = clientStorage.CompareAndStore(p.id, p.client)
+		client = clientStorage.CompareAndStore(p.id, client)
 	}
 
-	if err := p.client.Ping(context.Background()).Err(); err != nil {
-		defer p.client.Close()
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		defer client.Close()
 		return err
 	}
 
+	p.backend = &redisBackend{
+		client:   client,
+		keyspace: p.Redis.Keyspace,
+		ttl:      p.Redis.TTL,
+	}
+
 	return nil
 }
 
 func (p *Deduplicate) Close() error {
-	return p.client.Close()
+	if p.backend == nil {
+		return nil
+	}
+	return p.backend.close()
 }
 
 func (p *Deduplicate) SetId(id uint64) {
@@ -105,9 +160,9 @@ func (p *Deduplicate) Run() {
 		now := time.Now()
 		e.SetLabel("::duplicate", "false")
 
-		key, ok := e.GetLabel(p.IdempotencyKey)
+		key, ok := p.key(e)
 		if !ok {
-			p.Log.Debug("event has no configured label, skipped",
+			p.Log.Debug("event has no configured label or field, skipped",
 				slog.Group("event",
 					"id", e.Id,
 					"key", e.RoutingKey,
@@ -119,19 +174,14 @@ func (p *Deduplicate) Run() {
 		}
 
 		var unique bool
-		err := p.Retryer.Do("set key", p.Log, func() error {
-			boolCmd := p.client.SetNX(context.Background(), p.Redis.Keyspace+key, time.Now().String(), p.Redis.TTL)
-			if err := boolCmd.Err(); err != nil {
-				return err
-			}
-
-			// false means than key not set because key already exists
-			unique = boolCmd.Val()
-			return nil
+		err := p.Retryer.Do("add key", p.Log, func() error {
+			var err error
+			unique, err = p.backend.add(key)
+			return err
 		})
 
 		if err != nil {
-			p.Log.Error("redis cmd exec failed",
+			p.Log.Error(p.Backend+" backend op failed",
 				"error", err,
 				slog.Group("event",
 					"id", e.Id,
@@ -158,19 +208,72 @@ func (p *Deduplicate) Run() {
 	}
 }
 
+// key builds deduplication key from configured labels and fields values
+// joined with zero byte, so values containing separator-like characters
+// can not produce the same key; if event has no any of them, false returns
+func (p *Deduplicate) key(e *core.Event) (string, bool) {
+	parts := make([]string, 0, len(p.KeyLabels)+len(p.KeyFields))
+
+	for _, k := range p.KeyLabels {
+		v, ok := e.GetLabel(k)
+		if !ok {
+			return "", false
+		}
+		parts = append(parts, v)
+	}
+
+	for _, k := range p.KeyFields {
+		v, err := e.GetField(k)
+		if err != nil {
+			return "", false
+		}
+
+		switch f := v.(type) {
+		case string:
+			parts = append(parts, f)
+		case map[string]any, []any, nil:
+			return "", false
+		default:
+			parts = append(parts, fmt.Sprint(f))
+		}
+	}
+
+	return strings.Join(parts, keySeparator), true
+}
+
 func init() {
 	plugins.AddProcessor("deduplicate", func() core.Processor {
 		return &Deduplicate{
+			Backend: "redis",
 			Redis: Redis{
-				Shared:           true,
-				Keyspace:         "neptunus:deduplicate",
-				ConnsMaxIdleTime: 10 * time.Minute,
-				ConnsMaxLifetime: 10 * time.Minute,
-				ConnsMaxOpen:     2,
-				ConnsMaxIdle:     1,
-				Timeout:          30 * time.Second,
-				TTL:              1 * time.Hour,
-				TLSClientConfig:  &tls.TLSClientConfig{},
+				Shared:   true,
+				Keyspace: "neptunus:deduplicate",
+				TTL:      1 * time.Hour,
+				Redis: &common.Redis{
+					ConnsMaxIdleTime: 10 * time.Minute,
+					ConnsMaxLifetime: 10 * time.Minute,
+					ConnsMaxOpen:     2,
+					ConnsMaxIdle:     1,
+					Timeout:          30 * time.Second,
+					TLSClientConfig:  &tls.TLSClientConfig{},
+				},
+			},
+			Memory: Memory{
+				Shared:  true,
+				TTL:     1 * time.Hour,
+				MaxKeys: 1_000_000,
+			},
+			Filter: Filter{
+				Shared:            true,
+				TTL:               1 * time.Hour,
+				Capacity:          1_000_000,
+				FalsePositiveRate: 0.001,
+			},
+			Bolt: Bolt{
+				Bucket:          "deduplicate",
+				TTL:             1 * time.Hour,
+				CleanupInterval: 5 * time.Minute,
+				Timeout:         30 * time.Second,
 			},
 			Retryer: &retryer.Retryer{
 				RetryAttempts: 0,
@@ -0,0 +1,240 @@
+package deduplicate_test
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/pkg/mapstructure"
+	"github.com/gekatateam/neptunus/plugins/common/retryer"
+	"github.com/gekatateam/neptunus/plugins/processors/deduplicate"
+)
+
+func TestDeduplicate(t *testing.T) {
+	tests := map[string]struct {
+		config          map[string]any
+		events          []*core.Event
+		expectDuplicate []string
+	}{
+		"memory-backend-by-label": {
+			config: map[string]any{
+				"backend":         "memory",
+				"idempotency_key": "id",
+				"memory": map[string]any{
+					"shared":   true,
+					"ttl":      "1h",
+					"max_keys": 100,
+				},
+			},
+			events: []*core.Event{
+				{Labels: map[string]string{"id": "1"}},
+				{Labels: map[string]string{"id": "2"}},
+				{Labels: map[string]string{"id": "1"}},
+				{Labels: map[string]string{"no-id": "1"}},
+			},
+			expectDuplicate: []string{"false", "false", "true", "false"},
+		},
+		"filter-backend-by-composite-key": {
+			config: map[string]any{
+				"backend":    "filter",
+				"key_labels": []string{"source"},
+				"key_fields": []string{"request.id"},
+				"filter": map[string]any{
+					"ttl":                 "1h",
+					"capacity":            100,
+					"false_positive_rate": 0.001,
+				},
+			},
+			events: []*core.Event{
+				{Labels: map[string]string{"source": "a"}, Data: map[string]any{"request": map[string]any{"id": 1}}},
+				{Labels: map[string]string{"source": "b"}, Data: map[string]any{"request": map[string]any{"id": 1}}},
+				{Labels: map[string]string{"source": "a"}, Data: map[string]any{"request": map[string]any{"id": 1}}},
+				{Labels: map[string]string{"source": "a"}, Data: map[string]any{"request": map[string]any{"id": map[string]any{}}}},
+			},
+			expectDuplicate: []string{"false", "false", "true", "false"},
+		},
+		"composite-key-parts-not-merged": {
+			config: map[string]any{
+				"backend":    "memory",
+				"key_labels": []string{"a", "b"},
+				"memory": map[string]any{
+					"ttl":      "1h",
+					"max_keys": 100,
+				},
+			},
+			events: []*core.Event{
+				{Labels: map[string]string{"a": "x:y", "b": "z"}},
+				{Labels: map[string]string{"a": "x", "b": "y:z"}},
+				{Labels: map[string]string{"a": "x", "b": "y:z"}},
+			},
+			expectDuplicate: []string{"false", "false", "true"},
+		},
+		"bolt-backend-by-field": {
+			config: map[string]any{
+				"backend":    "bolt",
+				"key_fields": []string{"id"},
+				"bolt": map[string]any{
+					"path":             filepath.Join(t.TempDir(), "deduplicate.db"),
+					"bucket":           "deduplicate",
+					"ttl":              "1h",
+					"cleanup_interval": "1m",
+					"timeout":          "1s",
+				},
+			},
+			events: []*core.Event{
+				{Labels: map[string]string{}, Data: map[string]any{"id": "x"}},
+				{Labels: map[string]string{}, Data: map[string]any{"id": "x"}},
+				{Labels: map[string]string{}, Data: map[string]any{"id": "y"}},
+			},
+			expectDuplicate: []string{"false", "true", "false"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			processor := &deduplicate.Deduplicate{
+				BaseProcessor: &core.BaseProcessor{
+					Log: logger.Mock(),
+					Obs: metrics.ObserveMock,
+				},
+				Retryer: &retryer.Retryer{},
+			}
+			if err := mapstructure.Decode(test.config, processor); err != nil {
+				t.Fatalf("processor config not applied: %v", err)
+			}
+			if err := processor.Init(); err != nil {
+				t.Fatalf("processor not initialized: %v", err)
+			}
+
+			input := make(chan *core.Event, 100)
+			output := make(chan *core.Event, 100)
+			drop := make(chan *core.Event, 100)
+			processor.SetChannels(input, output, drop)
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				processor.Run()
+				wg.Done()
+			}()
+
+			for _, e := range test.events {
+				input <- e
+			}
+			close(input)
+			wg.Wait()
+			processor.Close()
+
+			if len(output) != len(test.expectDuplicate) {
+				t.Fatalf("unexpected output events count - want: %v, got: %v", len(test.expectDuplicate), len(output))
+			}
+
+			for i, want := range test.expectDuplicate {
+				e := <-output
+				if got, _ := e.GetLabel("::duplicate"); got != want {
+					t.Fatalf("event %v: unexpected ::duplicate label - want: %v, got: %v", i, want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDeduplicate_SharedBolt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deduplicate.db")
+
+	first := &deduplicate.Deduplicate{
+		BaseProcessor: &core.BaseProcessor{
+			Log: logger.Mock(),
+			Obs: metrics.ObserveMock,
+		},
+		Retryer: &retryer.Retryer{},
+	}
+	if err := mapstructure.Decode(map[string]any{
+		"backend":         "bolt",
+		"idempotency_key": "id",
+		"bolt": map[string]any{
+			"path":    path,
+			"bucket":  "deduplicate",
+			"ttl":     "1h",
+			"timeout": "1s",
+		},
+	}, first); err != nil {
+		t.Fatalf("processor config not applied: %v", err)
+	}
+	if err := first.Init(); err != nil {
+		t.Fatalf("processor not initialized: %v", err)
+	}
+	defer first.Close()
+
+	tests := map[string]struct {
+		config    map[string]any
+		expectErr bool
+	}{
+		"same-settings": {
+			config: map[string]any{
+				"backend":         "bolt",
+				"idempotency_key": "id",
+				"bolt": map[string]any{
+					"path":    path,
+					"bucket":  "deduplicate",
+					"ttl":     "1h",
+					"timeout": "1s",
+				},
+			},
+			expectErr: false,
+		},
+		"other-bucket": {
+			config: map[string]any{
+				"backend":         "bolt",
+				"idempotency_key": "id",
+				"bolt": map[string]any{
+					"path":    path,
+					"bucket":  "other",
+					"ttl":     "1h",
+					"timeout": "1s",
+				},
+			},
+			expectErr: true,
+		},
+		"other-ttl": {
+			config: map[string]any{
+				"backend":         "bolt",
+				"idempotency_key": "id",
+				"bolt": map[string]any{
+					"path":    path,
+					"bucket":  "deduplicate",
+					"ttl":     "2h",
+					"timeout": "1s",
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			second := &deduplicate.Deduplicate{
+				BaseProcessor: &core.BaseProcessor{
+					Log: logger.Mock(),
+					Obs: metrics.ObserveMock,
+				},
+				Retryer: &retryer.Retryer{},
+			}
+			if err := mapstructure.Decode(test.config, second); err != nil {
+				t.Fatalf("processor config not applied: %v", err)
+			}
+
+			err := second.Init()
+			if (err != nil) != test.expectErr {
+				t.Fatalf("unexpected init result, want error: %v, got: %v", test.expectErr, err)
+			}
+
+			if err == nil {
+				second.Close()
+			}
+		})
+	}
+}
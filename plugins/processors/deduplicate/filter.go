@@ -0,0 +1,69 @@
+package deduplicate
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bits-and-blooms/bloom/v3"
+)
+
+type Filter struct {
+	Shared            bool          `mapstructure:"shared"`
+	TTL               time.Duration `mapstructure:"ttl"`
+	Capacity          uint          `mapstructure:"capacity"`
+	FalsePositiveRate float64       `mapstructure:"false_positive_rate"`
+}
+
+// filterBackend uses bloom filters, that never stores keys
+// and may report unique key as a duplicate with configured probability
+//
+// bloom filter can not forget keys, so two generations are used
+// new keys are added to current generation, and keys are checked in both
+// every ttl/2 current generation becomes previous, and previous is dropped
+// so a key is forgotten after ttl/2 - ttl
+type filterBackend struct {
+	current  *bloom.BloomFilter
+	previous *bloom.BloomFilter
+
+	rotation time.Duration
+	rotateAt time.Time
+
+	mu *sync.Mutex
+}
+
+func newFilterBackend(c Filter) *filterBackend {
+	b := &filterBackend{
+		current:  bloom.NewWithEstimates(c.Capacity, c.FalsePositiveRate),
+		previous: bloom.NewWithEstimates(c.Capacity, c.FalsePositiveRate),
+		rotation: c.TTL / 2,
+		mu:       &sync.Mutex{},
+	}
+
+	if b.rotation > 0 {
+		b.rotateAt = time.Now().Add(b.rotation)
+	}
+
+	return b
+}
+
+func (b *filterBackend) add(key string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rotation > 0 && time.Now().After(b.rotateAt) {
+		b.previous, b.current = b.current, b.previous.ClearAll()
+		b.rotateAt = time.Now().Add(b.rotation)
+	}
+
+	if b.previous.TestString(key) {
+		return false, nil
+	}
+
+	return !b.current.TestAndAddString(key), nil
+}
+
+func (b *filterBackend) close() error {
+	b.current.ClearAll()
+	b.previous.ClearAll()
+	return nil
+}
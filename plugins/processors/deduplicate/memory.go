@@ -0,0 +1,45 @@
+package deduplicate
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+type Memory struct {
+	Shared  bool          `mapstructure:"shared"`
+	TTL     time.Duration `mapstructure:"ttl"`
+	MaxKeys int           `mapstructure:"max_keys"`
+}
+
+// memoryBackend stores keys in LRU cache with TTL
+// if cache is full, the oldest keys are evicted
+type memoryBackend struct {
+	cache *expirable.LRU[string, struct{}]
+	mu    *sync.Mutex
+}
+
+func newMemoryBackend(c Memory) *memoryBackend {
+	return &memoryBackend{
+		cache: expirable.NewLRU[string, struct{}](c.MaxKeys, nil, c.TTL),
+		mu:    &sync.Mutex{},
+	}
+}
+
+func (b *memoryBackend) add(key string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.cache.Contains(key) {
+		return false, nil
+	}
+
+	b.cache.Add(key, struct{}{})
+	return true, nil
+}
+
+func (b *memoryBackend) close() error {
+	b.cache.Purge()
+	return nil
+}
@@ -0,0 +1,159 @@
+package dissect
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+)
+
+type Dissect struct {
+	*core.BaseProcessor `mapstructure:"-"`
+	Field               string            `mapstructure:"field"`
+	Pattern             string            `mapstructure:"pattern"`
+	Target              string            `mapstructure:"target"`
+	AppendSeparator     string            `mapstructure:"append_separator"`
+	Types               map[string]string `mapstructure:"types"`
+	TagOnFailure        string            `mapstructure:"tag_on_failure"`
+
+	pattern *pattern
+}
+
+func (p *Dissect) Init() error {
+	if len(p.Field) == 0 {
+		return errors.New("field required")
+	}
+
+	if len(p.Pattern) == 0 {
+		return errors.New("pattern required")
+	}
+
+	pattern, err := parsePattern(p.Pattern)
+	if err != nil {
+		return fmt.Errorf("pattern parsing failed: %w", err)
+	}
+	p.pattern = pattern
+
+	for k, t := range p.Types {
+		switch t {
+		case "string", "int", "float", "bool":
+		default:
+			return fmt.Errorf("unknown type for key %v: %v, expected one of: string, int, float, bool", k, t)
+		}
+	}
+
+	return nil
+}
+
+func (p *Dissect) Close() error {
+	return nil
+}
+
+func (p *Dissect) Run() {
+	for e := range p.In {
+		now := time.Now()
+
+		rawField, err := e.GetField(p.Field)
+		if err != nil {
+			p.Log.Debug("event has no configured field, skipped",
+				slog.Group("event",
+					"id", e.Id,
+					"key", e.RoutingKey,
+				),
+			)
+			p.Out <- e
+			p.Observe(metrics.EventAccepted, time.Since(now))
+			continue
+		}
+
+		field, ok := rawField.(string)
+		if !ok {
+			p.Log.Debug("configured field is not a string, skipped",
+				slog.Group("event",
+					"id", e.Id,
+					"key", e.RoutingKey,
+				),
+			)
+			p.Out <- e
+			p.Observe(metrics.EventAccepted, time.Since(now))
+			continue
+		}
+
+		values, ok := p.pattern.dissect(field, p.AppendSeparator)
+		if !ok {
+			p.Log.Debug("pattern not matched",
+				slog.Group("event",
+					"id", e.Id,
+					"key", e.RoutingKey,
+				),
+			)
+			if len(p.TagOnFailure) > 0 {
+				e.AddTag(p.TagOnFailure)
+			}
+			p.Out <- e
+			p.Observe(metrics.EventAccepted, time.Since(now))
+			continue
+		}
+
+		hasError := false
+		for k, v := range values {
+			path := k
+			if len(p.Target) > 0 {
+				path = p.Target + "." + k
+			}
+
+			value, err := p.convert(k, v)
+			if err == nil {
+				err = e.SetField(path, value)
+			}
+
+			if err != nil {
+				p.Log.Error("set field failed",
+					"error", err,
+					slog.Group("event",
+						"id", e.Id,
+						"key", e.RoutingKey,
+						"field", path,
+					),
+				)
+				e.StackError(fmt.Errorf("set field %v failed: %w", path, err))
+				hasError = true
+			}
+		}
+
+		p.Out <- e
+		if hasError {
+			p.Observe(metrics.EventFailed, time.Since(now))
+		} else {
+			p.Observe(metrics.EventAccepted, time.Since(now))
+		}
+	}
+}
+
+func (p *Dissect) convert(k, v string) (any, error) {
+	switch p.Types[k] {
+	case "int":
+		return strconv.ParseInt(v, 10, 64)
+	case "float":
+		return strconv.ParseFloat(v, 64)
+	case "bool":
+		return strconv.ParseBool(v)
+	default:
+		return v, nil
+	}
+}
+
+func init() {
+	plugins.AddProcessor("dissect", func() core.Processor {
+		return &Dissect{
+			Field:           "message",
+			AppendSeparator: " ",
+			TagOnFailure:    "_dissectfailure",
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package dissect_test
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/pkg/mapstructure"
+	"github.com/gekatateam/neptunus/plugins/processors/dissect"
+)
+
+func TestDissect(t *testing.T) {
+	tests := map[string]struct {
+		config     map[string]any
+		message    string
+		expectData map[string]any
+		expectTags []string
+	}{
+		"modifiers-and-types": {
+			config: map[string]any{
+				"pattern": "[%{ts}] %{level->} %{?pid} %{+ts} %{status}: %{msg}",
+				"target":  "log",
+				"types":   map[string]string{"status": "int"},
+			},
+			message: "[2024-01-01] INFO    123 12:00:00 200: request done",
+			expectData: map[string]any{
+				"ts":     "2024-01-01 12:00:00",
+				"level":  "INFO",
+				"status": int64(200),
+				"msg":    "request done",
+			},
+		},
+		"prefix-not-matched": {
+			config: map[string]any{
+				"pattern": "[%{ts}] %{msg}",
+			},
+			message:    "2024-01-01 message",
+			expectTags: []string{"_dissectfailure"},
+		},
+		"delimiter-not-found": {
+			config: map[string]any{
+				"pattern": "%{a}|%{b}|%{c}",
+			},
+			message:    "one|two",
+			expectTags: []string{"_dissectfailure"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			processor := &dissect.Dissect{
+				BaseProcessor: &core.BaseProcessor{
+					Log: logger.Mock(),
+					Obs: metrics.ObserveMock,
+				},
+				Field:           "message",
+				AppendSeparator: " ",
+				TagOnFailure:    "_dissectfailure",
+			}
+			if err := mapstructure.Decode(test.config, processor); err != nil {
+				t.Fatalf("processor config not applied: %v", err)
+			}
+			if err := processor.Init(); err != nil {
+				t.Fatalf("processor not initialized: %v", err)
+			}
+
+			input := make(chan *core.Event, 1)
+			output := make(chan *core.Event, 1)
+			processor.SetChannels(input, output, nil)
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				processor.Run()
+				wg.Done()
+			}()
+
+			input <- &core.Event{Data: map[string]any{"message": test.message}}
+			close(input)
+			wg.Wait()
+			processor.Close()
+
+			e := <-output
+			got, _ := e.GetField("log")
+			if test.expectData == nil {
+				if got != nil {
+					t.Fatalf("unexpected data - want: nil, got: %v", got)
+				}
+			} else if !reflect.DeepEqual(got, test.expectData) {
+				t.Fatalf("unexpected data - want: %v, got: %v", test.expectData, got)
+			}
+
+			if !slices.Equal(e.Tags, test.expectTags) {
+				t.Fatalf("unexpected tags - want: %v, got: %v", test.expectTags, e.Tags)
+			}
+		})
+	}
+}
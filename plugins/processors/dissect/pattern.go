@@ -0,0 +1,121 @@
+package dissect
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// pattern is a prefix and a list of keys,
+// each key is followed by a delimiter, last key delimiter may be empty
+type pattern struct {
+	prefix string
+	keys   []key
+}
+
+type key struct {
+	name      string
+	skip      bool // %{?name} or %{}, value is matched but not set
+	append    bool // %{+name}, value is appended to a previous value of the same key
+	rightPad  bool // %{name->}, repeated delimiters after value are skipped
+	delimiter string
+}
+
+func parsePattern(raw string) (*pattern, error) {
+	p := &pattern{}
+
+	rest := raw
+	start := strings.Index(rest, "%{")
+	if start < 0 {
+		return nil, errors.New("pattern contains no keys")
+	}
+	p.prefix, rest = rest[:start], rest[start:]
+
+	for len(rest) > 0 {
+		end := strings.Index(rest, "}")
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed key at %q", rest)
+		}
+
+		k := parseKey(rest[2:end])
+		rest = rest[end+1:]
+
+		next := strings.Index(rest, "%{")
+		if next < 0 {
+			next = len(rest)
+		}
+		k.delimiter, rest = rest[:next], rest[next:]
+
+		if len(k.delimiter) == 0 && len(rest) > 0 {
+			return nil, fmt.Errorf("key %q must be followed by a delimiter", k.name)
+		}
+
+		p.keys = append(p.keys, k)
+	}
+
+	return p, nil
+}
+
+func parseKey(raw string) key {
+	k := key{}
+
+	if strings.HasSuffix(raw, "->") {
+		k.rightPad = true
+		raw = strings.TrimSuffix(raw, "->")
+	}
+
+	switch {
+	case len(raw) == 0:
+		k.skip = true
+	case strings.HasPrefix(raw, "?"):
+		k.skip = true
+		raw = raw[1:]
+	case strings.HasPrefix(raw, "+"):
+		k.append = true
+		raw = raw[1:]
+	}
+
+	k.name = raw
+	return k
+}
+
+// dissect splits value by pattern delimiters
+// false returns if value does not match pattern
+func (p *pattern) dissect(value string, appendSeparator string) (map[string]string, bool) {
+	if !strings.HasPrefix(value, p.prefix) {
+		return nil, false
+	}
+	rest := value[len(p.prefix):]
+
+	result := make(map[string]string, len(p.keys))
+	for _, k := range p.keys {
+		var v string
+		if len(k.delimiter) == 0 {
+			v, rest = rest, ""
+		} else {
+			i := strings.Index(rest, k.delimiter)
+			if i < 0 {
+				return nil, false
+			}
+			v, rest = rest[:i], rest[i+len(k.delimiter):]
+
+			if k.rightPad {
+				for strings.HasPrefix(rest, k.delimiter) {
+					rest = rest[len(k.delimiter):]
+				}
+			}
+		}
+
+		if k.skip {
+			continue
+		}
+
+		if prev, ok := result[k.name]; ok && k.append {
+			result[k.name] = prev + appendSeparator + v
+		} else {
+			result[k.name] = v
+		}
+	}
+
+	return result, true
+}
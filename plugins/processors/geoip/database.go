@@ -0,0 +1,160 @@
+package geoip
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+var ds = &databaseStorage{
+	s:  make(map[string]*database),
+	mu: &sync.Mutex{},
+}
+
+// databases are shared between all processors, that use the same file,
+// so file is loaded and watched only once
+type databaseStorage struct {
+	s  map[string]*database
+	mu *sync.Mutex
+}
+
+func (s *databaseStorage) load(path string, interval time.Duration, log *slog.Logger) (*database, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if db, ok := s.s[path]; ok {
+		db.readers++
+		return db, nil
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := openReader(path)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &database{
+		path:     path,
+		readers:  1,
+		interval: interval,
+		log:      log,
+		modTime:  stat.ModTime(),
+		size:     stat.Size(),
+		done:     make(chan struct{}),
+		wg:       &sync.WaitGroup{},
+	}
+	db.reader.Store(reader)
+	s.s[path] = db
+
+	log.Info(fmt.Sprintf("geoip database %v loaded, type: %v, build: %v", path,
+		reader.Metadata.DatabaseType, time.Unix(int64(reader.Metadata.BuildEpoch), 0).UTC()))
+
+	if interval > 0 {
+		db.wg.Add(1)
+		go db.watch()
+	}
+
+	return db, nil
+}
+
+// database file is read into memory instead of mmap,
+// so a replaced reader may be safely used by processors until they see a new one
+func openReader(path string) (*maxminddb.Reader, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return maxminddb.FromBytes(content)
+}
+
+type database struct {
+	path    string
+	readers int32
+
+	reader     atomic.Pointer[maxminddb.Reader]
+	generation atomic.Uint64
+	interval   time.Duration
+	log        *slog.Logger
+
+	modTime time.Time
+	size    int64
+
+	done chan struct{}
+	wg   *sync.WaitGroup
+}
+
+// lookup decodes record for ip into result
+// false returns if ip not found in database
+func (d *database) lookup(ip net.IP, result any) (bool, error) {
+	_, ok, err := d.reader.Load().LookupNetwork(ip, result)
+	return ok, err
+}
+
+// watch checks file modification time and size every interval
+// and replaces reader if file changed
+// if new file can not be loaded, old reader is kept
+func (d *database) watch() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.done:
+			return
+		case <-ticker.C:
+			stat, err := os.Stat(d.path)
+			if err != nil {
+				d.log.Error("geoip database stat failed",
+					"error", err,
+				)
+				continue
+			}
+
+			if stat.ModTime().Equal(d.modTime) && stat.Size() == d.size {
+				continue
+			}
+
+			reader, err := openReader(d.path)
+			if err != nil {
+				d.log.Error("geoip database reload failed, previous version is used",
+					"error", err,
+				)
+				continue
+			}
+
+			d.reader.Store(reader)
+			d.generation.Add(1)
+			d.modTime, d.size = stat.ModTime(), stat.Size()
+			d.log.Info(fmt.Sprintf("geoip database %v reloaded, build: %v", d.path,
+				time.Unix(int64(reader.Metadata.BuildEpoch), 0).UTC()))
+		}
+	}
+}
+
+// database watcher is stopped when the last processor closes it
+func (d *database) close() {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	d.readers--
+	if d.readers > 0 {
+		return
+	}
+
+	close(d.done)
+	d.wg.Wait()
+	delete(ds.s, d.path)
+}
@@ -0,0 +1,387 @@
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"slices"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+)
+
+type Geoip struct {
+	*core.BaseProcessor `mapstructure:"-"`
+	CityDb              string        `mapstructure:"city_db"`
+	AsnDb               string        `mapstructure:"asn_db"`
+	ReloadInterval      time.Duration `mapstructure:"reload_interval"`
+	SourceLabel         string        `mapstructure:"source_label"`
+	SourceField         string        `mapstructure:"source_field"`
+	Target              string        `mapstructure:"target"`
+	Fields              []string      `mapstructure:"fields"`
+	Language            string        `mapstructure:"language"`
+	CacheSize           int           `mapstructure:"cache_size"`
+
+	city *database
+	asn  *database
+
+	cityFields []string
+	asnFields  []string
+
+	// cached attributes by ip, nil means that ip not found
+	cache      *lru.Cache[string, map[string]any]
+	generation uint64
+}
+
+var (
+	cityFields = []string{
+		"continent_code", "continent_name", "country_code", "country_name",
+		"region_code", "region_name", "city_name", "postal_code",
+		"timezone", "location", "accuracy_radius",
+	}
+	asnFields = []string{
+		"asn", "as_organization",
+	}
+)
+
+type cityRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Continent struct {
+		Code  string            `maxminddb:"code"`
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"continent"`
+	Country struct {
+		IsoCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+	Location struct {
+		AccuracyRadius uint16  `maxminddb:"accuracy_radius"`
+		Latitude       float64 `maxminddb:"latitude"`
+		Longitude      float64 `maxminddb:"longitude"`
+		TimeZone       string  `maxminddb:"time_zone"`
+	} `maxminddb:"location"`
+	Postal struct {
+		Code string `maxminddb:"code"`
+	} `maxminddb:"postal"`
+	Subdivisions []struct {
+		IsoCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+}
+
+type asnRecord struct {
+	AutonomousSystemNumber       uint   `maxminddb:"autonomous_system_number"`
+	AutonomousSystemOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
+func (p *Geoip) Init() error {
+	if len(p.CityDb) == 0 && len(p.AsnDb) == 0 {
+		return errors.New("at least one of city_db or asn_db required")
+	}
+
+	if len(p.SourceLabel) == 0 && len(p.SourceField) == 0 {
+		return errors.New("source_label or source_field required")
+	}
+
+	if len(p.Fields) == 0 {
+		if len(p.CityDb) > 0 {
+			p.Fields = append(p.Fields, cityFields...)
+		}
+		if len(p.AsnDb) > 0 {
+			p.Fields = append(p.Fields, asnFields...)
+		}
+	}
+
+	slices.Sort(p.Fields)
+	for _, f := range slices.Compact(p.Fields) {
+		switch {
+		case slices.Contains(cityFields, f):
+			if len(p.CityDb) == 0 {
+				return fmt.Errorf("field %v requires city_db", f)
+			}
+			p.cityFields = append(p.cityFields, f)
+		case slices.Contains(asnFields, f):
+			if len(p.AsnDb) == 0 {
+				return fmt.Errorf("field %v requires asn_db", f)
+			}
+			p.asnFields = append(p.asnFields, f)
+		default:
+			return fmt.Errorf("unknown field: %v", f)
+		}
+	}
+
+	if p.CacheSize <= 0 {
+		p.CacheSize = 1
+	}
+
+	cache, err := lru.New[string, map[string]any](p.CacheSize)
+	if err != nil {
+		return err
+	}
+	p.cache = cache
+
+	if len(p.cityFields) > 0 {
+		db, err := p.loadDb(p.CityDb)
+		if err != nil {
+			return fmt.Errorf("city database loading failed: %w", err)
+		}
+		p.city = db
+	}
+
+	if len(p.asnFields) > 0 {
+		db, err := p.loadDb(p.AsnDb)
+		if err != nil {
+			p.Close()
+			return fmt.Errorf("asn database loading failed: %w", err)
+		}
+		p.asn = db
+	}
+
+	return nil
+}
+
+func (p *Geoip) loadDb(path string) (*database, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ds.load(path, p.ReloadInterval, p.Log)
+}
+
+func (p *Geoip) Close() error {
+	if p.city != nil {
+		p.city.close()
+	}
+
+	if p.asn != nil {
+		p.asn.close()
+	}
+
+	return nil
+}
+
+func (p *Geoip) Run() {
+	for e := range p.In {
+		now := time.Now()
+
+		source, ok := p.source(e)
+		if !ok {
+			p.Log.Debug("event has no configured label or field, skipped",
+				slog.Group("event",
+					"id", e.Id,
+					"key", e.RoutingKey,
+				),
+			)
+			p.Out <- e
+			p.Observe(metrics.EventAccepted, time.Since(now))
+			continue
+		}
+
+		attrs, err := p.lookup(source)
+		if err != nil {
+			p.Log.Error("geoip lookup failed",
+				"error", err,
+				slog.Group("event",
+					"id", e.Id,
+					"key", e.RoutingKey,
+				),
+			)
+			e.StackError(err)
+			p.Out <- e
+			p.Observe(metrics.EventFailed, time.Since(now))
+			continue
+		}
+
+		hasError := false
+		for k, v := range attrs {
+			if err := e.SetField(p.path(k), v); err != nil {
+				p.Log.Error("set field failed",
+					"error", err,
+					slog.Group("event",
+						"id", e.Id,
+						"key", e.RoutingKey,
+						"field", p.path(k),
+					),
+				)
+				e.StackError(fmt.Errorf("set field %v failed: %w", p.path(k), err))
+				hasError = true
+			}
+		}
+
+		p.Out <- e
+		if hasError {
+			p.Observe(metrics.EventFailed, time.Since(now))
+		} else {
+			p.Observe(metrics.EventAccepted, time.Since(now))
+		}
+	}
+}
+
+func (p *Geoip) source(e *core.Event) (string, bool) {
+	if len(p.SourceField) > 0 {
+		v, err := e.GetField(p.SourceField)
+		if err != nil {
+			return "", false
+		}
+
+		s, ok := v.(string)
+		return s, ok
+	}
+
+	return e.GetLabel(p.SourceLabel)
+}
+
+func (p *Geoip) path(k string) string {
+	if len(p.Target) == 0 {
+		return k
+	}
+	return p.Target + "." + k
+}
+
+// lookup returns attributes of ip address from cache or databases
+// source may be an ip or a host:port pair, like http input "sender" label
+func (p *Geoip) lookup(source string) (map[string]any, error) {
+	// cache is purged if any database has been reloaded
+	var generation uint64
+	if p.city != nil {
+		generation += p.city.generation.Load()
+	}
+	if p.asn != nil {
+		generation += p.asn.generation.Load()
+	}
+
+	if generation != p.generation {
+		p.cache.Purge()
+		p.generation = generation
+	}
+
+	ip := net.ParseIP(source)
+	if ip == nil {
+		if host, _, err := net.SplitHostPort(source); err == nil {
+			ip = net.ParseIP(host)
+		}
+	}
+
+	if ip == nil {
+		return nil, fmt.Errorf("%v is not an ip address", source)
+	}
+
+	key := ip.String()
+	if attrs, ok := p.cache.Get(key); ok {
+		return attrs, nil
+	}
+
+	var attrs map[string]any
+
+	if p.city != nil {
+		record := &cityRecord{}
+		found, err := p.city.lookup(ip, record)
+		if err != nil {
+			return nil, err
+		}
+
+		if found {
+			attrs = make(map[string]any, len(p.cityFields)+len(p.asnFields))
+			p.cityAttrs(record, attrs)
+		}
+	}
+
+	if p.asn != nil {
+		record := &asnRecord{}
+		found, err := p.asn.lookup(ip, record)
+		if err != nil {
+			return nil, err
+		}
+
+		if found {
+			if attrs == nil {
+				attrs = make(map[string]any, len(p.asnFields))
+			}
+			p.asnAttrs(record, attrs)
+		}
+	}
+
+	p.cache.Add(key, attrs)
+	return attrs, nil
+}
+
+// empty values are not set
+func (p *Geoip) cityAttrs(r *cityRecord, attrs map[string]any) {
+	setString := func(k, v string) {
+		if len(v) > 0 {
+			attrs[k] = v
+		}
+	}
+
+	for _, f := range p.cityFields {
+		switch f {
+		case "continent_code":
+			setString(f, r.Continent.Code)
+		case "continent_name":
+			setString(f, r.Continent.Names[p.Language])
+		case "country_code":
+			setString(f, r.Country.IsoCode)
+		case "country_name":
+			setString(f, r.Country.Names[p.Language])
+		case "region_code":
+			if len(r.Subdivisions) > 0 {
+				setString(f, r.Subdivisions[0].IsoCode)
+			}
+		case "region_name":
+			if len(r.Subdivisions) > 0 {
+				setString(f, r.Subdivisions[0].Names[p.Language])
+			}
+		case "city_name":
+			setString(f, r.City.Names[p.Language])
+		case "postal_code":
+			setString(f, r.Postal.Code)
+		case "timezone":
+			setString(f, r.Location.TimeZone)
+		case "location":
+			if r.Location.Latitude != 0 || r.Location.Longitude != 0 {
+				attrs["location.lat"] = r.Location.Latitude
+				attrs["location.lon"] = r.Location.Longitude
+			}
+		case "accuracy_radius":
+			if r.Location.AccuracyRadius > 0 {
+				attrs[f] = int(r.Location.AccuracyRadius)
+			}
+		}
+	}
+}
+
+func (p *Geoip) asnAttrs(r *asnRecord, attrs map[string]any) {
+	for _, f := range p.asnFields {
+		switch f {
+		case "asn":
+			if r.AutonomousSystemNumber > 0 {
+				attrs[f] = int(r.AutonomousSystemNumber)
+			}
+		case "as_organization":
+			if len(r.AutonomousSystemOrganization) > 0 {
+				attrs[f] = r.AutonomousSystemOrganization
+			}
+		}
+	}
+}
+
+func init() {
+	plugins.AddProcessor("geoip", func() core.Processor {
+		return &Geoip{
+			ReloadInterval: time.Minute,
+			SourceLabel:    "sender",
+			Target:         "geo",
+			Language:       "en",
+			CacheSize:      10_000,
+		}
+	})
+}
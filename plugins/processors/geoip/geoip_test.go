@@ -0,0 +1,484 @@
+package geoip_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/pkg/mapstructure"
+	"github.com/gekatateam/neptunus/plugins/processors/geoip"
+)
+
+// test databases are built in MaxMind DB format with records
+// like in MaxMind test fixtures, e.g. GeoIP2-City-Test.mmdb
+var (
+	cityNetworks = map[string]any{
+		"81.2.69.0/24": map[string]any{
+			"city": map[string]any{"names": map[string]any{"en": "London"}},
+			"continent": map[string]any{
+				"code":  "EU",
+				"names": map[string]any{"en": "Europe", "de": "Europa"},
+			},
+			"country": map[string]any{
+				"iso_code": "GB",
+				"names":    map[string]any{"en": "United Kingdom", "de": "Vereinigtes Königreich"},
+			},
+			"location": map[string]any{
+				"accuracy_radius": uint16(100),
+				"latitude":        51.5142,
+				"longitude":       -0.0931,
+				"time_zone":       "Europe/London",
+			},
+			"postal": map[string]any{"code": "EC2V"},
+			"subdivisions": []any{
+				map[string]any{"iso_code": "ENG", "names": map[string]any{"en": "England"}},
+			},
+		},
+		"89.160.20.112/28": map[string]any{
+			"continent": map[string]any{"code": "EU", "names": map[string]any{"en": "Europe"}},
+			"country":   map[string]any{"iso_code": "SE", "names": map[string]any{"en": "Sweden"}},
+		},
+	}
+
+	asnNetworks = map[string]any{
+		"1.128.0.0/11": map[string]any{
+			"autonomous_system_number":       uint32(1221),
+			"autonomous_system_organization": "Telstra Pty Ltd",
+		},
+		"81.2.69.0/24": map[string]any{
+			"autonomous_system_number":       uint32(20712),
+			"autonomous_system_organization": "Andrews & Arnold Ltd",
+		},
+		// record can not be decoded into asn record
+		"203.0.113.0/24": map[string]any{
+			"autonomous_system_number": "AS64496",
+		},
+	}
+)
+
+func TestGeoip(t *testing.T) {
+	dir := t.TempDir()
+	cityDb := filepath.Join(dir, "City-Test.mmdb")
+	asnDb := filepath.Join(dir, "ASN-Test.mmdb")
+	writeMmdb(t, cityDb, "GeoIP2-City", cityNetworks)
+	writeMmdb(t, asnDb, "GeoLite2-ASN", asnNetworks)
+
+	tests := map[string]struct {
+		config       map[string]any
+		event        *core.Event
+		expectFields map[string]any
+		expectErrors int
+	}{
+		"city-and-asn-by-label": {
+			config: map[string]any{
+				"city_db": cityDb,
+				"asn_db":  asnDb,
+			},
+			event: &core.Event{
+				Labels: map[string]string{"sender": "81.2.69.142:5555"},
+				Data:   map[string]any{},
+			},
+			expectFields: map[string]any{
+				"geo": map[string]any{
+					"continent_code":  "EU",
+					"continent_name":  "Europe",
+					"country_code":    "GB",
+					"country_name":    "United Kingdom",
+					"region_code":     "ENG",
+					"region_name":     "England",
+					"city_name":       "London",
+					"postal_code":     "EC2V",
+					"timezone":        "Europe/London",
+					"location":        map[string]any{"lat": 51.5142, "lon": -0.0931},
+					"accuracy_radius": 100,
+					"asn":             20712,
+					"as_organization": "Andrews & Arnold Ltd",
+				},
+			},
+		},
+		"selected-fields-by-field-with-language": {
+			config: map[string]any{
+				"city_db":      cityDb,
+				"asn_db":       asnDb,
+				"source_field": "client.ip",
+				"target":       "",
+				"language":     "de",
+				"fields":       []string{"country_name", "asn"},
+			},
+			event: &core.Event{
+				Data: map[string]any{"client": map[string]any{"ip": "81.2.69.160"}},
+			},
+			expectFields: map[string]any{
+				"client":       map[string]any{"ip": "81.2.69.160"},
+				"country_name": "Vereinigtes Königreich",
+				"asn":          20712,
+			},
+		},
+		"empty-values-are-not-set": {
+			config: map[string]any{
+				"city_db": cityDb,
+			},
+			event: &core.Event{
+				Labels: map[string]string{"sender": "89.160.20.120"},
+				Data:   map[string]any{},
+			},
+			expectFields: map[string]any{
+				"geo": map[string]any{
+					"continent_code": "EU",
+					"continent_name": "Europe",
+					"country_code":   "SE",
+					"country_name":   "Sweden",
+				},
+			},
+		},
+		"asn-only": {
+			config: map[string]any{
+				"asn_db": asnDb,
+			},
+			event: &core.Event{
+				Labels: map[string]string{"sender": "1.128.0.1"},
+				Data:   map[string]any{},
+			},
+			expectFields: map[string]any{
+				"geo": map[string]any{
+					"asn":             1221,
+					"as_organization": "Telstra Pty Ltd",
+				},
+			},
+		},
+		"ip-not-found": {
+			config: map[string]any{
+				"city_db": cityDb,
+				"asn_db":  asnDb,
+			},
+			event: &core.Event{
+				Labels: map[string]string{"sender": "10.0.0.1"},
+				Data:   map[string]any{},
+			},
+			expectFields: map[string]any{},
+		},
+		"no-source-label": {
+			config: map[string]any{
+				"city_db": cityDb,
+			},
+			event: &core.Event{
+				Labels: map[string]string{"host": "81.2.69.142"},
+				Data:   map[string]any{},
+			},
+			expectFields: map[string]any{},
+		},
+		"non-string-source-field": {
+			config: map[string]any{
+				"city_db":      cityDb,
+				"source_field": "ip",
+			},
+			event: &core.Event{
+				Data: map[string]any{"ip": 1337},
+			},
+			expectFields: map[string]any{"ip": 1337},
+		},
+		"source-is-not-an-ip": {
+			config: map[string]any{
+				"city_db": cityDb,
+			},
+			event: &core.Event{
+				Labels: map[string]string{"sender": "localhost:8080"},
+				Data:   map[string]any{},
+			},
+			expectFields: map[string]any{},
+			expectErrors: 1,
+		},
+		"ipv6-lookup-in-ipv4-database-failed": {
+			config: map[string]any{
+				"city_db": cityDb,
+			},
+			event: &core.Event{
+				Labels: map[string]string{"sender": "[2001:db8::1]:443"},
+				Data:   map[string]any{},
+			},
+			expectFields: map[string]any{},
+			expectErrors: 1,
+		},
+		"record-decoding-failed": {
+			config: map[string]any{
+				"city_db": cityDb,
+				"asn_db":  asnDb,
+			},
+			event: &core.Event{
+				Labels: map[string]string{"sender": "203.0.113.10"},
+				Data:   map[string]any{},
+			},
+			expectFields: map[string]any{},
+			expectErrors: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			processor := &geoip.Geoip{
+				BaseProcessor: &core.BaseProcessor{
+					Log: logger.Mock(),
+					Obs: metrics.ObserveMock,
+				},
+				SourceLabel: "sender",
+				Target:      "geo",
+				Language:    "en",
+				CacheSize:   100,
+			}
+			if err := mapstructure.Decode(test.config, processor); err != nil {
+				t.Fatalf("processor config not applied: %v", err)
+			}
+			if err := processor.Init(); err != nil {
+				t.Fatalf("processor not initialized: %v", err)
+			}
+
+			input := make(chan *core.Event, 1)
+			output := make(chan *core.Event, 1)
+			processor.SetChannels(input, output, nil)
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				processor.Run()
+				wg.Done()
+			}()
+
+			input <- test.event
+			close(input)
+			wg.Wait()
+			processor.Close()
+
+			e := <-output
+			if !reflect.DeepEqual(e.Data, test.expectFields) {
+				t.Fatalf("unexpected fields, want: %v, got: %v", test.expectFields, e.Data)
+			}
+
+			if len(e.Errors) != test.expectErrors {
+				t.Fatalf("unexpected errors count, want: %v, got: %v; errors: %v", test.expectErrors, len(e.Errors), e.Errors)
+			}
+		})
+	}
+}
+
+func TestGeoip_Init(t *testing.T) {
+	dir := t.TempDir()
+	cityDb := filepath.Join(dir, "City-Test.mmdb")
+	writeMmdb(t, cityDb, "GeoIP2-City", cityNetworks)
+
+	broken := filepath.Join(dir, "Broken.mmdb")
+	if err := os.WriteFile(broken, []byte("not a database"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]map[string]any{
+		"no-databases": {},
+		"field-requires-other-database": {
+			"city_db": cityDb,
+			"fields":  []string{"asn"},
+		},
+		"unknown-field": {
+			"city_db": cityDb,
+			"fields":  []string{"weather"},
+		},
+		"database-not-exists": {
+			"city_db": filepath.Join(dir, "missing.mmdb"),
+		},
+		"database-is-broken": {
+			"city_db": cityDb,
+			"asn_db":  broken,
+		},
+	}
+
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			processor := &geoip.Geoip{
+				BaseProcessor: &core.BaseProcessor{
+					Log: logger.Mock(),
+					Obs: metrics.ObserveMock,
+				},
+				SourceLabel: "sender",
+				Target:      "geo",
+				Language:    "en",
+				CacheSize:   100,
+			}
+			if err := mapstructure.Decode(config, processor); err != nil {
+				t.Fatalf("processor config not applied: %v", err)
+			}
+			if err := processor.Init(); err == nil {
+				t.Fatal("processor initialized, but error expected")
+			}
+		})
+	}
+}
+
+// writeMmdb writes IPv4 database with 24 bit records
+// see https://maxmind.github.io/MaxMind-DB/ for format specification
+func writeMmdb(t *testing.T, path, dbType string, networks map[string]any) {
+	t.Helper()
+
+	type node struct {
+		children [2]*node
+		data     int // data section offset, -1 for internal nodes
+	}
+
+	data := &bytes.Buffer{}
+	root := &node{data: -1}
+
+	cidrs := make([]string, 0, len(networks))
+	for cidr := range networks {
+		cidrs = append(cidrs, cidr)
+	}
+	sort.Strings(cidrs)
+
+	for _, cidr := range cidrs {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		offset := data.Len()
+		mmdbEncode(data, networks[cidr])
+
+		ip := ipnet.IP.To4()
+		ones, _ := ipnet.Mask.Size()
+		n := root
+		for i := 0; i < ones; i++ {
+			bit := (ip[i/8] >> (7 - i%8)) & 1
+			if n.children[bit] == nil {
+				n.children[bit] = &node{data: -1}
+			}
+			n = n.children[bit]
+		}
+		n.data = offset
+	}
+
+	// internal nodes are numbered in breadth-first order, root is zero
+	var nodes []*node
+	index := make(map[*node]int)
+	for queue := []*node{root}; len(queue) > 0; queue = queue[1:] {
+		n := queue[0]
+		index[n] = len(nodes)
+		nodes = append(nodes, n)
+		for _, c := range n.children {
+			if c != nil && c.data < 0 {
+				queue = append(queue, c)
+			}
+		}
+	}
+
+	db := &bytes.Buffer{}
+	count := len(nodes)
+	for _, n := range nodes {
+		for _, c := range n.children {
+			var record int
+			switch {
+			case c == nil:
+				record = count // no data
+			case c.data < 0:
+				record = index[c]
+			default:
+				record = count + 16 + c.data
+			}
+			db.Write([]byte{byte(record >> 16), byte(record >> 8), byte(record)})
+		}
+	}
+
+	db.Write(make([]byte, 16)) // data section separator
+	db.Write(data.Bytes())
+	db.WriteString("\xAB\xCD\xEFMaxMind.com")
+	mmdbEncode(db, map[string]any{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(1700000000),
+		"database_type":               dbType,
+		"description":                 map[string]any{"en": "neptunus test database"},
+		"ip_version":                  uint16(4),
+		"languages":                   []any{"en", "de"},
+		"node_count":                  uint32(count),
+		"record_size":                 uint16(24),
+	})
+
+	if err := os.WriteFile(path, db.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mmdbEncode(buf *bytes.Buffer, value any) {
+	control := func(typ, size int) {
+		var ctrl byte
+		var ext []byte
+		if typ > 7 {
+			ext = []byte{byte(typ - 7)}
+		} else {
+			ctrl = byte(typ << 5)
+		}
+
+		var sizeBytes []byte
+		switch {
+		case size < 29:
+			ctrl |= byte(size)
+		case size < 285:
+			ctrl |= 29
+			sizeBytes = []byte{byte(size - 29)}
+		default:
+			ctrl |= 30
+			sizeBytes = binary.BigEndian.AppendUint16(nil, uint16(size-285))
+		}
+
+		buf.WriteByte(ctrl)
+		buf.Write(ext)
+		buf.Write(sizeBytes)
+	}
+
+	encodeUint := func(typ int, v uint64) {
+		b := binary.BigEndian.AppendUint64(nil, v)
+		b = bytes.TrimLeft(b, "\x00")
+		control(typ, len(b))
+		buf.Write(b)
+	}
+
+	switch v := value.(type) {
+	case string:
+		control(2, len(v))
+		buf.WriteString(v)
+	case float64:
+		control(3, 8)
+		buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(v)))
+	case uint16:
+		encodeUint(5, uint64(v))
+	case uint32:
+		encodeUint(6, uint64(v))
+	case uint64:
+		encodeUint(9, v)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		control(7, len(v))
+		for _, k := range keys {
+			mmdbEncode(buf, k)
+			mmdbEncode(buf, v[k])
+		}
+	case []any:
+		control(11, len(v))
+		for _, e := range v {
+			mmdbEncode(buf, e)
+		}
+	default:
+		panic(fmt.Sprintf("unsupported mmdb type: %T", v))
+	}
+}
@@ -0,0 +1,217 @@
+package grok
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/elastic/go-grok"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+)
+
+type Grok struct {
+	*core.BaseProcessor `mapstructure:"-"`
+	Field               string            `mapstructure:"field"`
+	Patterns            []string          `mapstructure:"patterns"`
+	PatternDefinitions  map[string]string `mapstructure:"pattern_definitions"`
+	PatternsFiles       []string          `mapstructure:"patterns_files"`
+	Target              string            `mapstructure:"target"`
+	NamedCapturesOnly   bool              `mapstructure:"named_captures_only"`
+	TagOnFailure        string            `mapstructure:"tag_on_failure"`
+
+	groks []*grok.Grok
+}
+
+func (p *Grok) Init() error {
+	if len(p.Field) == 0 {
+		return errors.New("field required")
+	}
+
+	if len(p.Patterns) == 0 {
+		return errors.New("at least one pattern required")
+	}
+
+	definitions := make(map[string]string)
+	for _, file := range p.PatternsFiles {
+		if err := readPatternsFile(file, definitions); err != nil {
+			return fmt.Errorf("patterns file %v reading failed: %w", file, err)
+		}
+	}
+
+	// inline definitions overrides definitions from files
+	for k, v := range p.PatternDefinitions {
+		definitions[k] = v
+	}
+
+	for i, pattern := range p.Patterns {
+		g, err := grok.NewComplete(definitions)
+		if err != nil {
+			return fmt.Errorf("pattern definitions loading failed: %w", err)
+		}
+
+		if err := g.Compile(pattern, p.NamedCapturesOnly); err != nil {
+			return fmt.Errorf("pattern %v compilation failed: %w", i, err)
+		}
+
+		p.groks = append(p.groks, g)
+	}
+
+	return nil
+}
+
+// readPatternsFile reads patterns definitions in logstash format,
+// one "NAME pattern" per line; empty lines and lines starting with # are skipped
+func readPatternsFile(file string, definitions map[string]string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		name, pattern, found := strings.Cut(text, " ")
+		if !found {
+			return fmt.Errorf("line %v: expected NAME and pattern separated by space", line)
+		}
+		definitions[name] = strings.TrimSpace(pattern)
+	}
+
+	return scanner.Err()
+}
+
+func (p *Grok) Close() error {
+	return nil
+}
+
+func (p *Grok) Run() {
+	for e := range p.In {
+		now := time.Now()
+
+		rawField, err := e.GetField(p.Field)
+		if err != nil {
+			p.Log.Debug("event has no configured field, skipped",
+				slog.Group("event",
+					"id", e.Id,
+					"key", e.RoutingKey,
+				),
+			)
+			p.Out <- e
+			p.Observe(metrics.EventAccepted, time.Since(now))
+			continue
+		}
+
+		field, ok := rawField.(string)
+		if !ok {
+			p.Log.Debug("configured field is not a string, skipped",
+				slog.Group("event",
+					"id", e.Id,
+					"key", e.RoutingKey,
+				),
+			)
+			p.Out <- e
+			p.Observe(metrics.EventAccepted, time.Since(now))
+			continue
+		}
+
+		captures, err := p.match(field)
+		if err != nil {
+			p.Log.Error("captures conversion failed",
+				"error", err,
+				slog.Group("event",
+					"id", e.Id,
+					"key", e.RoutingKey,
+				),
+			)
+			e.StackError(err)
+			p.Out <- e
+			p.Observe(metrics.EventFailed, time.Since(now))
+			continue
+		}
+
+		if captures == nil {
+			p.Log.Debug("no pattern matched",
+				slog.Group("event",
+					"id", e.Id,
+					"key", e.RoutingKey,
+				),
+			)
+			if len(p.TagOnFailure) > 0 {
+				e.AddTag(p.TagOnFailure)
+			}
+			p.Out <- e
+			p.Observe(metrics.EventAccepted, time.Since(now))
+			continue
+		}
+
+		hasError := false
+		for k, v := range captures {
+			path := k
+			if len(p.Target) > 0 {
+				path = p.Target + "." + k
+			}
+
+			if err := e.SetField(path, v); err != nil {
+				p.Log.Error("set field failed",
+					"error", err,
+					slog.Group("event",
+						"id", e.Id,
+						"key", e.RoutingKey,
+						"field", path,
+					),
+				)
+				e.StackError(fmt.Errorf("set field %v failed: %w", path, err))
+				hasError = true
+			}
+		}
+
+		p.Out <- e
+		if hasError {
+			p.Observe(metrics.EventFailed, time.Since(now))
+		} else {
+			p.Observe(metrics.EventAccepted, time.Since(now))
+		}
+	}
+}
+
+// match tries patterns in order and returns captures of the first matched one
+// nil returns if no pattern matched
+func (p *Grok) match(field string) (map[string]any, error) {
+	for _, g := range p.groks {
+		captures, err := g.ParseTypedString(field)
+		if err != nil {
+			return nil, err
+		}
+
+		// pattern may match without captures
+		if len(captures) > 0 || g.MatchString(field) {
+			return captures, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func init() {
+	plugins.AddProcessor("grok", func() core.Processor {
+		return &Grok{
+			Field:             "message",
+			NamedCapturesOnly: true,
+			TagOnFailure:      "_grokparsefailure",
+		}
+	})
+}
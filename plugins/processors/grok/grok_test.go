@@ -0,0 +1,124 @@
+package grok_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/pkg/mapstructure"
+	"github.com/gekatateam/neptunus/plugins/processors/grok"
+)
+
+func TestGrok(t *testing.T) {
+	patternsFile := filepath.Join(t.TempDir(), "patterns")
+	if err := os.WriteFile(patternsFile, []byte("# custom patterns\nTRX trx-[0-9]+\n"), 0644); err != nil {
+		t.Fatalf("patterns file not written: %v", err)
+	}
+
+	tests := map[string]struct {
+		config       map[string]any
+		event        *core.Event
+		expectData   map[string]any
+		expectTags   []string
+		expectErrors int
+	}{
+		"second-pattern-typed-nested": {
+			config: map[string]any{
+				"patterns": []string{
+					`^%{IP:client.ip} %{WORD:method} %{URIPATH:url.path} %{NUMBER:status:int}$`,
+					`^%{IP:client.ip} %{NUMBER:duration:float}s %{TRX:trx.id}$`,
+				},
+				"patterns_files": []string{patternsFile},
+				"target":         "parsed",
+			},
+			event: &core.Event{
+				Data: map[string]any{"message": "10.0.0.1 0.25s trx-42"},
+			},
+			expectData: map[string]any{
+				"message": "10.0.0.1 0.25s trx-42",
+				"parsed": map[string]any{
+					"client":   map[string]any{"ip": "10.0.0.1"},
+					"duration": 0.25,
+					"trx":      map[string]any{"id": "trx-42"},
+				},
+			},
+		},
+		"no-match-tagged": {
+			config: map[string]any{
+				"patterns":       []string{`^%{IP:ip}$`},
+				"tag_on_failure": "_grokparsefailure",
+			},
+			event: &core.Event{
+				Data: map[string]any{"message": "not an ip"},
+			},
+			expectData: map[string]any{"message": "not an ip"},
+			expectTags: []string{"_grokparsefailure"},
+		},
+		"inline-definitions-and-conversion-error": {
+			config: map[string]any{
+				"field":               "log",
+				"patterns":            []string{`^%{CODE:code:int}$`},
+				"pattern_definitions": map[string]string{"CODE": `[a-z]+`},
+			},
+			event: &core.Event{
+				Data: map[string]any{"log": "abc"},
+			},
+			expectData:   map[string]any{"log": "abc"},
+			expectErrors: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			processor := &grok.Grok{
+				BaseProcessor: &core.BaseProcessor{
+					Log: logger.Mock(),
+					Obs: metrics.ObserveMock,
+				},
+				Field:             "message",
+				NamedCapturesOnly: true,
+			}
+			if err := mapstructure.Decode(test.config, processor); err != nil {
+				t.Fatalf("processor config not applied: %v", err)
+			}
+			if err := processor.Init(); err != nil {
+				t.Fatalf("processor not initialized: %v", err)
+			}
+
+			input := make(chan *core.Event, 1)
+			output := make(chan *core.Event, 1)
+			processor.SetChannels(input, output, nil)
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				processor.Run()
+				wg.Done()
+			}()
+
+			input <- test.event
+			close(input)
+			wg.Wait()
+			processor.Close()
+
+			e := <-output
+			if !reflect.DeepEqual(e.Data, test.expectData) {
+				t.Fatalf("unexpected data - want: %v, got: %v", test.expectData, e.Data)
+			}
+
+			if !slices.Equal(e.Tags, test.expectTags) {
+				t.Fatalf("unexpected tags - want: %v, got: %v", test.expectTags, e.Tags)
+			}
+
+			if len(e.Errors) != test.expectErrors {
+				t.Fatalf("unexpected errors count - want: %v, got: %v", test.expectErrors, len(e.Errors))
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package lookup
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/gekatateam/mappath"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+)
+
+type Lookup struct {
+	id                  uint64
+	*core.BaseProcessor `mapstructure:"-"`
+	File                string            `mapstructure:"file"`
+	Format              string            `mapstructure:"format"`
+	CsvDelimiter        string            `mapstructure:"csv_delimiter"`
+	ReloadInterval      time.Duration     `mapstructure:"reload_interval"`
+	KeyColumns          []string          `mapstructure:"key_columns"`
+	MatchLabels         map[string]string `mapstructure:"match_labels"`
+	MatchFields         map[string]string `mapstructure:"match_fields"`
+	Labels              map[string]string `mapstructure:"labels"`
+	Fields              map[string]string `mapstructure:"fields"`
+
+	table *sharedTable
+	parts []string
+}
+
+func (p *Lookup) Init() error {
+	if len(p.File) == 0 {
+		return errors.New("file required")
+	}
+
+	if len(p.Format) == 0 {
+		switch filepath.Ext(p.File) {
+		case ".csv":
+			p.Format = "csv"
+		case ".json":
+			p.Format = "json"
+		case ".jsonl", ".ndjson":
+			p.Format = "jsonl"
+		default:
+			return fmt.Errorf("unknown file extension: %v, format must be set explicitly", filepath.Ext(p.File))
+		}
+	}
+
+	switch p.Format {
+	case "csv", "json", "jsonl":
+	default:
+		return fmt.Errorf("unknown format: %v, expected one of: csv, json, jsonl", p.Format)
+	}
+
+	delimiter := []rune(p.CsvDelimiter)
+	if len(delimiter) != 1 {
+		return errors.New("csv_delimiter must be a single character")
+	}
+
+	if len(p.KeyColumns) == 0 {
+		return errors.New("at least one key column required")
+	}
+
+	columns := slices.Clone(p.KeyColumns)
+	slices.Sort(columns)
+	if len(slices.Compact(columns)) != len(p.KeyColumns) {
+		return errors.New("key columns must be unique")
+	}
+
+	for _, column := range p.KeyColumns {
+		_, byLabel := p.MatchLabels[column]
+		_, byField := p.MatchFields[column]
+
+		if byLabel == byField {
+			return fmt.Errorf("key column %v must be matched by exactly one label or field", column)
+		}
+	}
+
+	if len(p.MatchLabels)+len(p.MatchFields) != len(p.KeyColumns) {
+		return errors.New("match_labels and match_fields must contain key columns only")
+	}
+
+	if len(p.Labels) == 0 && len(p.Fields) == 0 {
+		return errors.New("at least one label or field to merge required")
+	}
+
+	table, err := ts.load(p.id, tableConfig{
+		file:      p.File,
+		format:    p.Format,
+		delimiter: delimiter[0],
+		columns:   p.KeyColumns,
+		interval:  p.ReloadInterval,
+	}, p.Log)
+	if err != nil {
+		return fmt.Errorf("lookup file loading failed: %w", err)
+	}
+
+	p.table = table
+	p.parts = make([]string, len(p.KeyColumns))
+
+	return nil
+}
+
+func (p *Lookup) Close() error {
+	p.table.close()
+	return nil
+}
+
+func (p *Lookup) SetId(id uint64) {
+	p.id = id
+}
+
+func (p *Lookup) Run() {
+	for e := range p.In {
+		now := time.Now()
+
+		if !p.key(e) {
+			p.Log.Debug("event has no configured label or field, skipped",
+				slog.Group("event",
+					"id", e.Id,
+					"key", e.RoutingKey,
+				),
+			)
+			p.Out <- e
+			p.Observe(metrics.EventAccepted, time.Since(now))
+			continue
+		}
+
+		row, ok := p.table.get(p.parts)
+		if !ok {
+			p.Log.Debug("no row found in lookup table",
+				slog.Group("event",
+					"id", e.Id,
+					"key", e.RoutingKey,
+				),
+			)
+			p.Out <- e
+			p.Observe(metrics.EventAccepted, time.Since(now))
+			continue
+		}
+
+		hasError := false
+		for label, column := range p.Labels {
+			if v, ok := row[column]; ok && v != nil {
+				e.SetLabel(label, fmt.Sprint(v))
+			}
+		}
+
+		for field, column := range p.Fields {
+			v, ok := row[column]
+			if !ok {
+				continue
+			}
+
+			// rows are shared between events, so complex values must be copied
+			if err := e.SetField(field, mappath.Clone(v)); err != nil {
+				p.Log.Error("set field failed",
+					"error", err,
+					slog.Group("event",
+						"id", e.Id,
+						"key", e.RoutingKey,
+						"field", field,
+					),
+				)
+				e.StackError(fmt.Errorf("set field %v failed: %w", field, err))
+				hasError = true
+			}
+		}
+
+		p.Out <- e
+		if hasError {
+			p.Observe(metrics.EventFailed, time.Since(now))
+		} else {
+			p.Observe(metrics.EventAccepted, time.Since(now))
+		}
+	}
+}
+
+// key fills key parts from event labels and fields
+// in key columns order; false returns if any of them is missing
+func (p *Lookup) key(e *core.Event) bool {
+	for i, column := range p.KeyColumns {
+		if label, ok := p.MatchLabels[column]; ok {
+			v, ok := e.GetLabel(label)
+			if !ok {
+				return false
+			}
+			p.parts[i] = v
+			continue
+		}
+
+		v, err := e.GetField(p.MatchFields[column])
+		if err != nil {
+			return false
+		}
+
+		switch v.(type) {
+		case map[string]any, []any, nil:
+			return false
+		default:
+			p.parts[i] = fmt.Sprint(v)
+		}
+	}
+
+	return true
+}
+
+func init() {
+	plugins.AddProcessor("lookup", func() core.Processor {
+		return &Lookup{
+			CsvDelimiter:   ",",
+			ReloadInterval: 30 * time.Second,
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package lookup_test
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/pkg/mapstructure"
+	"github.com/gekatateam/neptunus/plugins/processors/lookup"
+)
+
+func TestLookup(t *testing.T) {
+	tests := map[string]struct {
+		file         string
+		content      string
+		config       map[string]any
+		event        *core.Event
+		expectLabels map[string]string
+		expectFields map[string]any
+	}{
+		"csv-composite-key": {
+			file: "hosts.csv",
+			content: "dc,host,owner,rack\n" +
+				"eu,web-1,team-a,r1\n" +
+				"us,web-1,team-b,r7\n",
+			config: map[string]any{
+				"key_columns":  []string{"dc", "host"},
+				"match_labels": map[string]string{"dc": "region"},
+				"match_fields": map[string]string{"host": "host.name"},
+				"labels":       map[string]string{"owner": "owner"},
+				"fields":       map[string]string{"host.rack": "rack"},
+			},
+			event: &core.Event{
+				Labels: map[string]string{"region": "us"},
+				Data:   map[string]any{"host": map[string]any{"name": "web-1"}},
+			},
+			expectLabels: map[string]string{"owner": "team-b"},
+			expectFields: map[string]any{"host.rack": "r7"},
+		},
+		"jsonl-numeric-key": {
+			file: "codes.jsonl",
+			content: `{"code": 404, "info": {"text": "not found"}}` + "\n" +
+				`{"code": 500, "info": {"text": "server error"}}` + "\n",
+			config: map[string]any{
+				"key_columns":  []string{"code"},
+				"match_fields": map[string]string{"code": "status"},
+				"fields":       map[string]string{"status_info": "info"},
+			},
+			event: &core.Event{
+				Labels: map[string]string{},
+				Data:   map[string]any{"status": 500},
+			},
+			expectFields: map[string]any{"status_info.text": "server error"},
+		},
+		"json-no-match": {
+			file:    "codes.json",
+			content: `[{"code": "a", "text": "b"}]`,
+			config: map[string]any{
+				"key_columns":  []string{"code"},
+				"match_labels": map[string]string{"code": "code"},
+				"labels":       map[string]string{"text": "text"},
+			},
+			event: &core.Event{
+				Labels: map[string]string{"code": "c"},
+			},
+			expectLabels: map[string]string{"code": "c"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), test.file)
+			if err := os.WriteFile(file, []byte(test.content), 0644); err != nil {
+				t.Fatalf("lookup file not written: %v", err)
+			}
+
+			test.config["file"] = file
+			processor := &lookup.Lookup{
+				BaseProcessor: &core.BaseProcessor{
+					Log: logger.Mock(),
+					Obs: metrics.ObserveMock,
+				},
+				CsvDelimiter: ",",
+			}
+			if err := mapstructure.Decode(test.config, processor); err != nil {
+				t.Fatalf("processor config not applied: %v", err)
+			}
+			if err := processor.Init(); err != nil {
+				t.Fatalf("processor not initialized: %v", err)
+			}
+			defer processor.Close()
+
+			output := runProcessor(processor, test.event)
+
+			if len(output) != 1 {
+				t.Fatalf("unexpected output events count - want: 1, got: %v", len(output))
+			}
+
+			e := <-output
+			if test.expectLabels == nil {
+				test.expectLabels = map[string]string{}
+			}
+			for k, v := range test.event.Labels {
+				if _, ok := test.expectLabels[k]; !ok {
+					test.expectLabels[k] = v
+				}
+			}
+
+			if len(e.Labels) != len(test.expectLabels) {
+				t.Fatalf("unexpected labels - want: %v, got: %v", test.expectLabels, e.Labels)
+			}
+
+			for k, v := range test.expectLabels {
+				if got, _ := e.GetLabel(k); got != v {
+					t.Fatalf("unexpected label %v - want: %v, got: %v", k, v, got)
+				}
+			}
+
+			for k, v := range test.expectFields {
+				if got, _ := e.GetField(k); got != v {
+					t.Fatalf("unexpected field %v - want: %v, got: %v", k, v, got)
+				}
+			}
+		})
+	}
+}
+
+func TestLookupReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "table.csv")
+	if err := os.WriteFile(file, []byte("id,name\n1,old\n"), 0644); err != nil {
+		t.Fatalf("lookup file not written: %v", err)
+	}
+
+	config := map[string]any{
+		"file":            file,
+		"reload_interval": "10ms",
+		"key_columns":     []string{"id"},
+		"match_labels":    map[string]string{"id": "id"},
+		"labels":          map[string]string{"name": "name"},
+	}
+	processor := &lookup.Lookup{
+		BaseProcessor: &core.BaseProcessor{
+			Log: logger.Mock(),
+			Obs: metrics.ObserveMock,
+		},
+		CsvDelimiter: ",",
+	}
+	if err := mapstructure.Decode(config, processor); err != nil {
+		t.Fatalf("processor config not applied: %v", err)
+	}
+	if err := processor.Init(); err != nil {
+		t.Fatalf("processor not initialized: %v", err)
+	}
+	defer processor.Close()
+
+	if err := os.WriteFile(file, []byte("id,name\n1,new-name\n"), 0644); err != nil {
+		t.Fatalf("lookup file not written: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	e := <-runProcessor(processor, &core.Event{Labels: map[string]string{"id": "1"}})
+	if got, _ := e.GetLabel("name"); got != "new-name" {
+		t.Fatalf("unexpected label after reload - want: new-name, got: %v", got)
+	}
+}
+
+func runProcessor(processor *lookup.Lookup, e *core.Event) chan *core.Event {
+	input := make(chan *core.Event, 1)
+	output := make(chan *core.Event, 1)
+	drop := make(chan *core.Event, 1)
+	processor.SetChannels(input, output, drop)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		processor.Run()
+		wg.Done()
+	}()
+
+	input <- e
+	close(input)
+	wg.Wait()
+
+	return output
+}
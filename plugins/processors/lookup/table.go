@@ -0,0 +1,254 @@
+package lookup
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// key parts are joined with a char that is unlikely to be in a value
+const keySeparator = "\x00"
+
+type table map[string]map[string]any
+
+type tableConfig struct {
+	file      string
+	format    string
+	delimiter rune
+	columns   []string
+	interval  time.Duration
+}
+
+// loadTable reads whole file and builds rows index by key columns
+func loadTable(c tableConfig) (table, error) {
+	content, err := os.ReadFile(c.file)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]any
+	switch c.format {
+	case "csv":
+		rows, err = readCsv(content, c.delimiter)
+	case "json":
+		err = json.Unmarshal(content, &rows)
+	case "jsonl":
+		rows, err = readJsonl(content)
+	default:
+		err = fmt.Errorf("unknown format: %v", c.format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	t := make(table, len(rows))
+	parts := make([]string, len(c.columns))
+	for i, row := range rows {
+		for j, column := range c.columns {
+			v, ok := row[column]
+			if !ok || v == nil {
+				return nil, fmt.Errorf("row %v has no key column %v", i+1, column)
+			}
+			parts[j] = fmt.Sprint(v)
+		}
+
+		// duplicate keys are overwritten by the last row
+		t[strings.Join(parts, keySeparator)] = row
+	}
+
+	return t, nil
+}
+
+func readCsv(content []byte, delimiter rune) ([]map[string]any, error) {
+	r := csv.NewReader(bytes.NewReader(content))
+	r.Comma = delimiter
+
+	header, err := r.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("csv file has no header")
+		}
+		return nil, err
+	}
+
+	var rows []map[string]any
+	for {
+		record, err := r.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+
+		row := make(map[string]any, len(header))
+		for i, column := range header {
+			row[column] = record[i]
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+func readJsonl(content []byte) ([]map[string]any, error) {
+	var rows []map[string]any
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		row := make(map[string]any)
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			return nil, fmt.Errorf("line %v: %w", line, err)
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, scanner.Err()
+}
+
+var ts = &tableStorage{
+	s:  make(map[uint64]*sharedTable),
+	mu: &sync.Mutex{},
+}
+
+// tables are shared between processors in set,
+// so file is loaded and watched only once
+type tableStorage struct {
+	s  map[uint64]*sharedTable
+	mu *sync.Mutex
+}
+
+func (s *tableStorage) load(k uint64, c tableConfig, log *slog.Logger) (*sharedTable, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t, ok := s.s[k]; ok {
+		t.readers++
+		return t, nil
+	}
+
+	stat, err := os.Stat(c.file)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := loadTable(c)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &sharedTable{
+		id:      k,
+		readers: 1,
+		config:  c,
+		log:     log,
+		modTime: stat.ModTime(),
+		size:    stat.Size(),
+		done:    make(chan struct{}),
+		wg:      &sync.WaitGroup{},
+	}
+	t.data.Store(&data)
+	s.s[k] = t
+
+	if c.interval > 0 {
+		t.wg.Add(1)
+		go t.watch()
+	}
+
+	return t, nil
+}
+
+type sharedTable struct {
+	id      uint64
+	readers int32
+
+	data   atomic.Pointer[table]
+	config tableConfig
+	log    *slog.Logger
+
+	modTime time.Time
+	size    int64
+
+	done chan struct{}
+	wg   *sync.WaitGroup
+}
+
+func (t *sharedTable) get(parts []string) (map[string]any, bool) {
+	row, ok := (*t.data.Load())[strings.Join(parts, keySeparator)]
+	return row, ok
+}
+
+// watch checks file modification time and size every interval
+// and replaces whole table if file changed
+// if new file can not be loaded, old table is kept
+func (t *sharedTable) watch() {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(t.config.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-ticker.C:
+			stat, err := os.Stat(t.config.file)
+			if err != nil {
+				t.log.Error("lookup file stat failed",
+					"error", err,
+				)
+				continue
+			}
+
+			if stat.ModTime().Equal(t.modTime) && stat.Size() == t.size {
+				continue
+			}
+
+			data, err := loadTable(t.config)
+			if err != nil {
+				t.log.Error("lookup file reload failed, previous version is used",
+					"error", err,
+				)
+				continue
+			}
+
+			t.data.Store(&data)
+			t.modTime, t.size = stat.ModTime(), stat.Size()
+			t.log.Info(fmt.Sprintf("lookup file reloaded, %v rows loaded", len(data)))
+		}
+	}
+}
+
+// table watcher is stopped when the last processor closes it
+func (t *sharedTable) close() {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	t.readers--
+	if t.readers > 0 {
+		return
+	}
+
+	close(t.done)
+	t.wg.Wait()
+	delete(ts.s, t.id)
+}
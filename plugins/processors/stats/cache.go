@@ -8,7 +8,7 @@ import (
 )
 
 type cache interface {
-	observe(m *metric, v float64)
+	observe(m *metric, v any)
 	flush(out chan<- *core.Event, flushFn func(m *metric, ch chan<- *core.Event))
 	clear()
 }
@@ -19,7 +19,7 @@ func newIndividualCache() individualCache {
 	return make(individualCache)
 }
 
-func (c individualCache) observe(m *metric, v float64) {
+func (c individualCache) observe(m *metric, v any) {
 	if metric, ok := c[m.hash()]; ok {
 		m = metric
 	} else { // hit an uncached netric
@@ -94,7 +94,7 @@ type sharedCache struct {
 	mu        *sync.Mutex
 }
 
-func (c *sharedCache) observe(m *metric, v float64) {
+func (c *sharedCache) observe(m *metric, v any) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -1,8 +1,12 @@
 package stats
 
 import (
+	"fmt"
 	"hash/fnv"
 	"math"
+
+	"github.com/axiomhq/hyperloglog"
+	"github.com/beorn7/perks/quantile"
 )
 
 var statsMap = map[string]bool{
@@ -12,6 +16,10 @@ var statsMap = map[string]bool{
 	"avg":   true,
 	"min":   true,
 	"max":   true,
+
+	"histogram":   true,
+	"quantiles":   true,
+	"cardinality": true,
 }
 
 type metric struct {
@@ -31,7 +39,45 @@ func (m *metric) hash() uint64 {
 	return h.Sum64()
 }
 
-func (m *metric) observe(value float64) {
+func (m *metric) observe(raw any) {
+	// cardinality is calculated for any values, not only for numbers
+	if m.Stats.Cardinality {
+		if m.Value.hll == nil {
+			m.Value.hll = hyperloglog.New()
+		}
+
+		if s, ok := raw.(string); ok {
+			m.Value.hll.Insert([]byte(s))
+		} else {
+			m.Value.hll.Insert([]byte(fmt.Sprint(raw)))
+		}
+	}
+
+	value, ok := convert(raw)
+	if !ok {
+		return
+	}
+
+	// histogram buckets are cumulative, like in Prometheus
+	if m.Stats.Histogram {
+		if m.Value.Buckets == nil {
+			m.Value.Buckets = make([]float64, len(m.Stats.Buckets))
+		}
+
+		for i, le := range m.Stats.Buckets {
+			if value <= le {
+				m.Value.Buckets[i] += 1
+			}
+		}
+	}
+
+	if m.Stats.Quantiles {
+		if m.Value.sketch == nil {
+			m.Value.sketch = newSketch(m.Stats.Objectives)
+		}
+		m.Value.sketch.Insert(value)
+	}
+
 	// count calc
 	if m.Value.Count+1 == math.MaxFloat64 {
 		m.Value.Count = 0
@@ -69,13 +115,50 @@ func (m *metric) observe(value float64) {
 	}
 }
 
-// count, sum and gauge are not reset
+// count, sum, gauge and histogram are not reset
 func (m *metric) reset() {
 	m.Observed = false
 	m.Value.count2 = 0
 	m.Value.Avg = 0
 	m.Value.Min = 0
 	m.Value.Max = 0
+
+	if m.Value.sketch != nil {
+		m.Value.sketch.Reset()
+	}
+
+	if m.Value.hll != nil {
+		m.Value.hll = hyperloglog.New()
+	}
+}
+
+// Query returns estimated quantile value
+// or zero, if no values observed since last reset
+func (m *metric) Query(q float64) float64 {
+	if m.Value.sketch == nil || m.Value.sketch.Count() == 0 {
+		return 0
+	}
+	return m.Value.sketch.Query(q)
+}
+
+// Cardinality returns estimated number of distinct values
+// observed since last reset
+func (m *metric) Cardinality() float64 {
+	if m.Value.hll == nil {
+		return 0
+	}
+	return float64(m.Value.hll.Estimate())
+}
+
+// newSketch creates streaming quantiles sketch
+// with allowed error that depends on quantile, like in Prometheus summaries,
+// e.g. 0.5 -> 0.05, 0.9 -> 0.01, 0.99 -> 0.001
+func newSketch(objectives []float64) *quantile.Stream {
+	targets := make(map[float64]float64, len(objectives))
+	for _, q := range objectives {
+		targets[q] = math.Min(q, 1-q) / 10
+	}
+	return quantile.NewTargeted(targets)
 }
 
 type metricDescr struct {
@@ -96,6 +179,10 @@ type metricValue struct {
 	Min    float64
 	Max    float64
 	count2 float64 // count for moving average
+
+	Buckets []float64 // cumulative counts for each bucket upper bound
+	sketch  *quantile.Stream
+	hll     *hyperloglog.Sketch
 }
 
 type metricStats struct {
@@ -105,10 +192,20 @@ type metricStats struct {
 	Avg   bool
 	Min   bool
 	Max   bool
+
+	Histogram   bool
+	Quantiles   bool
+	Cardinality bool
+
+	Buckets    []float64 // histogram buckets upper bounds
+	Objectives []float64 // quantiles to calculate
 }
 
-func stats(stats []string) metricStats {
-	s := metricStats{}
+func stats(stats []string, buckets, objectives []float64) metricStats {
+	s := metricStats{
+		Buckets:    buckets,
+		Objectives: objectives,
+	}
 	for _, v := range stats {
 		switch v {
 		case "sum":
@@ -123,6 +220,12 @@ func stats(stats []string) metricStats {
 			s.Min = true
 		case "max":
 			s.Max = true
+		case "histogram":
+			s.Histogram = true
+		case "quantiles":
+			s.Quantiles = true
+		case "cardinality":
+			s.Cardinality = true
 		}
 	}
 	return s
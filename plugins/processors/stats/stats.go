@@ -2,7 +2,10 @@ package stats
 
 import (
 	"fmt"
+	"math"
 	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gekatateam/neptunus/core"
@@ -18,6 +21,8 @@ type Stats struct {
 	RoutingKey          string              `mapstructure:"routing_key"`
 	Labels              []string            `mapstructure:"labels"`
 	DropOrigin          bool                `mapstructure:"drop_origin"`
+	Buckets             []float64           `mapstructure:"buckets"`
+	Quantiles           []float64           `mapstructure:"quantiles"`
 	Fields              map[string][]string `mapstructure:"fields"`
 
 	cache  cache
@@ -41,6 +46,17 @@ func (p *Stats) Init() error {
 		return fmt.Errorf("unknown mode: %v, expected one of: shared, individual", p.Mode)
 	}
 
+	slices.Sort(p.Buckets)
+	p.Buckets = slices.Compact(p.Buckets)
+
+	slices.Sort(p.Quantiles)
+	p.Quantiles = slices.Compact(p.Quantiles)
+	for _, q := range p.Quantiles {
+		if q <= 0 || q >= 1 {
+			return fmt.Errorf("quantile must be between 0 and 1, got %v", q)
+		}
+	}
+
 	for k, v := range p.Fields {
 		if len(v) == 0 {
 			return fmt.Errorf("field %v has no configured stats", k)
@@ -53,7 +69,7 @@ func (p *Stats) Init() error {
 			}
 		}
 
-		p.fields[k] = stats(fields)
+		p.fields[k] = stats(fields, p.Buckets, p.Quantiles)
 	}
 
 	return nil
@@ -132,6 +148,34 @@ func (p *Stats) Flush() {
 			e.SetField("stats.max", m.Value.Max)
 		}
 
+		if m.Stats.Histogram {
+			buckets := make([]any, 0, len(m.Stats.Buckets))
+			for i, le := range m.Stats.Buckets {
+				var count float64
+				if m.Value.Buckets != nil {
+					count = m.Value.Buckets[i]
+				}
+
+				buckets = append(buckets, map[string]any{
+					"le":    le,
+					"count": count,
+				})
+			}
+			e.SetField("stats.histogram", buckets)
+		}
+
+		if m.Stats.Quantiles {
+			quantiles := make(map[string]any, len(m.Stats.Objectives))
+			for _, q := range m.Stats.Objectives {
+				quantiles[quantileName(q)] = m.Query(q)
+			}
+			e.SetField("stats.quantiles", quantiles)
+		}
+
+		if m.Stats.Cardinality {
+			e.SetField("stats.cardinality", m.Cardinality())
+		}
+
 		ch <- e
 	})
 }
@@ -158,9 +202,10 @@ func (p *Stats) Observe(e *core.Event) {
 			continue // if event has no field, skip it
 		}
 
-		fv, ok := convert(f)
-		if !ok {
-			continue // if field is not a number, skip it
+		// if field is not a number, skip it
+		// except cardinality, which is calculated for any values
+		if _, ok := convert(f); !ok && !stats.Cardinality {
+			continue
 		}
 
 		m := &metric{
@@ -171,10 +216,17 @@ func (p *Stats) Observe(e *core.Event) {
 			},
 		}
 
-		p.cache.observe(m, fv)
+		p.cache.observe(m, f)
 	}
 }
 
+// quantileName returns quantile name in percentile form
+// "." is a path separator, so 0.999 is named as p99_9
+func quantileName(q float64) string {
+	percent := math.Round(q*100*1e6) / 1e6 // avoid float artifacts, like 95.00000000000001
+	return "p" + strings.ReplaceAll(strconv.FormatFloat(percent, 'f', -1, 64), ".", "_")
+}
+
 func convert(value any) (float64, bool) {
 	switch v := value.(type) {
 	case float64:
@@ -212,6 +264,8 @@ func init() {
 			Period:     time.Minute,
 			RoutingKey: "neptunus.generated.metric",
 			Mode:       "shared",
+			Buckets:    []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+			Quantiles:  []float64{0.5, 0.9, 0.99},
 		}
 	})
 }
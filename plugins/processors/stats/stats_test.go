@@ -267,6 +267,95 @@ func TestStats(t *testing.T) {
 				},
 			},
 		},
+		"histogram-quantiles-cardinality-test": {
+			config: map[string]any{
+				"period":      "1m",
+				"routing_key": "ngm",
+				"labels":      []string{"code", "proto"},
+				"drop_origin": true, // in all tests processor drops origin events
+				"mode":        "individual",
+				"buckets":     []float64{10, 1, 5},
+				"quantiles":   []float64{0.5},
+				"fields": map[string][]string{
+					"duration": {"histogram", "quantiles"},
+					"uri":      {"cardinality"},
+				},
+			},
+			input:  make(chan *core.Event, 100),
+			output: make(chan *core.Event, 100),
+			drop:   make(chan *core.Event, 100),
+			events: []*core.Event{
+				{
+					Labels: map[string]string{
+						"code":   "200",
+						"proto":  "HTTP/1.0",
+						"client": "1.2.3.4",
+					},
+					Data: map[string]any{
+						"duration": 1,
+						"uri":      "/users/111",
+					},
+				},
+				{
+					Labels: map[string]string{
+						"code":   "200",
+						"proto":  "HTTP/1.0",
+						"client": "1.2.3.4",
+					},
+					Data: map[string]any{
+						"duration": 3,
+						"uri":      "/users/222",
+					},
+				},
+				{
+					Labels: map[string]string{
+						"code":   "200",
+						"proto":  "HTTP/1.0",
+						"client": "1.2.3.4",
+					},
+					Data: map[string]any{
+						"duration": 7,
+						"uri":      "/users/111",
+					},
+				},
+				{
+					Labels: map[string]string{
+						"code":   "200",
+						"proto":  "HTTP/1.0",
+						"client": "1.2.3.4",
+					},
+					Data: map[string]any{
+						"duration": 20,
+						"uri":      "/users/333",
+					},
+				},
+				{
+					Labels: map[string]string{
+						"code":   "200",
+						"proto":  "HTTP/1.0",
+						"client": "1.2.3.4",
+					},
+					Data: map[string]any{
+						"duration": 5,
+						"uri":      "/users/111",
+					},
+				},
+			},
+			expectEvents: map[uint64]map[string]float64{
+				7803659511733462090: {
+					"stats.histogram.0.le":    1,
+					"stats.histogram.0.count": 1,
+					"stats.histogram.1.le":    5,
+					"stats.histogram.1.count": 3,
+					"stats.histogram.2.le":    10,
+					"stats.histogram.2.count": 4,
+					"stats.quantiles.p50":     5,
+				},
+				18067368347691208282: {
+					"stats.cardinality": 3,
+				},
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -296,8 +385,8 @@ func TestStats(t *testing.T) {
 				test.input <- e
 			}
 			close(test.input)
-			processor.Close()
 			wg.Wait()
+			processor.Close()
 			close(test.drop)
 			close(test.output)
 
@@ -0,0 +1,199 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// bucket is a token bucket with summary counters
+type bucket struct {
+	key   string
+	parts *keyParts // used to fill summary event
+
+	tokens float64
+	last   time.Time
+
+	passed    int
+	throttled int
+}
+
+type keyParts struct {
+	labels map[string]string
+	fields map[string]any
+}
+
+type storage interface {
+	// take takes a token from a key bucket
+	// if bucket is empty and wait is true, token is reserved
+	// and returned duration is time to wait until it becomes available
+	take(key string, parts *keyParts, now time.Time, wait bool) (ok bool, delay time.Duration)
+	// summary returns buckets with throttled events since last call, resets counters
+	// and removes buckets that are full and idle
+	summary(now time.Time) []bucket
+	// leave returns final summary on plugin stop
+	leave(now time.Time) []bucket
+}
+
+type individualStorage struct {
+	b map[string]*bucket
+
+	rate  float64 // tokens per second
+	burst float64
+}
+
+func newIndividualStorage(rate, burst float64) *individualStorage {
+	return &individualStorage{
+		b:     make(map[string]*bucket),
+		rate:  rate,
+		burst: burst,
+	}
+}
+
+func (s *individualStorage) take(key string, parts *keyParts, now time.Time, wait bool) (bool, time.Duration) {
+	b, ok := s.b[key]
+	if !ok {
+		b = &bucket{
+			key:    key,
+			parts:  parts,
+			tokens: s.burst,
+			last:   now,
+		}
+		s.b[key] = b
+	}
+
+	s.refill(b, now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		b.passed++
+		return true, 0
+	}
+
+	b.throttled++
+	if !wait {
+		return false, 0
+	}
+
+	// token is borrowed from the future, so next events will wait longer
+	b.tokens--
+	return false, time.Duration((-b.tokens) / s.rate * float64(time.Second))
+}
+
+func (s *individualStorage) refill(b *bucket, now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(s.burst, b.tokens+elapsed.Seconds()*s.rate)
+		b.last = now
+	}
+}
+
+func (s *individualStorage) summary(now time.Time) []bucket {
+	var summary []bucket
+	for k, b := range s.b {
+		if b.throttled > 0 {
+			summary = append(summary, *b)
+		}
+
+		b.passed, b.throttled = 0, 0
+
+		s.refill(b, now)
+		if b.tokens >= s.burst {
+			delete(s.b, k)
+		}
+	}
+	return summary
+}
+
+func (s *individualStorage) leave(now time.Time) []bucket {
+	summary := s.summary(now)
+	clear(s.b)
+	return summary
+}
+
+var ss = &sharedStorages{
+	s:  make(map[uint64]*sharedStorage),
+	mu: &sync.Mutex{},
+}
+
+type sharedStorages struct {
+	s  map[uint64]*sharedStorage
+	mu *sync.Mutex
+}
+
+func (s *sharedStorages) delete(k uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.s, k)
+}
+
+func (s *sharedStorages) newStorage(k uint64, rate, burst float64, interval time.Duration) *sharedStorage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if storage, ok := s.s[k]; ok {
+		storage.writers++
+		return storage
+	}
+
+	storage := &sharedStorage{
+		id:       k,
+		writers:  1,
+		storage:  newIndividualStorage(rate, burst),
+		interval: interval,
+		mu:       &sync.Mutex{},
+	}
+	s.s[k] = storage
+
+	return storage
+}
+
+func newSharedStorage(k uint64, rate, burst float64, interval time.Duration) *sharedStorage {
+	return ss.newStorage(k, rate, burst, interval)
+}
+
+// shared storage is used by all processors in set
+// summary is returned to a processor, whose ticker fired first in current interval
+type sharedStorage struct {
+	id      uint64
+	writers int32
+
+	storage  *individualStorage
+	interval time.Duration
+	lastSum  time.Time
+	mu       *sync.Mutex
+}
+
+func (s *sharedStorage) take(key string, parts *keyParts, now time.Time, wait bool) (bool, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.storage.take(key, parts, now, wait)
+}
+
+func (s *sharedStorage) summary(now time.Time) []bucket {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// processors tickers are not synchronized,
+	// so other processors ticks in the same interval are skipped
+	if now.Sub(s.lastSum) < s.interval/2 {
+		return nil
+	}
+	s.lastSum = now
+
+	return s.storage.summary(now)
+}
+
+// last stopped processor returns final summary
+func (s *sharedStorage) leave(now time.Time) []bucket {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writers--
+	if s.writers > 0 {
+		return nil
+	}
+
+	ss.delete(s.id)
+	return s.storage.leave(now)
+}
@@ -0,0 +1,227 @@
+package throttle
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+)
+
+type Throttle struct {
+	id                  uint64
+	*core.BaseProcessor `mapstructure:"-"`
+	Mode                string        `mapstructure:"mode"`
+	KeyLabels           []string      `mapstructure:"key_labels"`
+	KeyFields           []string      `mapstructure:"key_fields"`
+	Limit               int           `mapstructure:"limit"`
+	Period              time.Duration `mapstructure:"period"`
+	Burst               int           `mapstructure:"burst"`
+	Action              string        `mapstructure:"action"`
+	Summary             bool          `mapstructure:"summary"`
+	SummaryInterval     time.Duration `mapstructure:"summary_interval"`
+	RoutingKey          string        `mapstructure:"routing_key"`
+
+	storage storage
+}
+
+// key parts are joined with a char that is unlikely to be in a value
+const keySeparator = "\x00"
+
+const (
+	actionDrop  = "drop"
+	actionTag   = "tag"
+	actionDelay = "delay"
+)
+
+func (p *Throttle) Init() error {
+	if p.Limit <= 0 {
+		return errors.New("limit must be greater than zero")
+	}
+
+	if p.Period <= 0 {
+		return errors.New("period must be greater than zero")
+	}
+
+	if p.Burst <= 0 {
+		p.Burst = p.Limit
+	}
+
+	if p.SummaryInterval < time.Second {
+		p.SummaryInterval = time.Second
+	}
+
+	switch p.Action {
+	case actionDrop, actionTag, actionDelay:
+	default:
+		return fmt.Errorf("unknown action: %v, expected one of: drop, tag, delay", p.Action)
+	}
+
+	rate := float64(p.Limit) / p.Period.Seconds()
+
+	switch p.Mode {
+	case "individual":
+		p.storage = newIndividualStorage(rate, float64(p.Burst))
+	case "shared":
+		p.storage = newSharedStorage(p.id, rate, float64(p.Burst), p.SummaryInterval)
+	default:
+		return fmt.Errorf("unknown mode: %v, expected one of: shared, individual", p.Mode)
+	}
+
+	return nil
+}
+
+func (p *Throttle) Close() error {
+	return nil
+}
+
+func (p *Throttle) SetId(id uint64) {
+	p.id = id
+}
+
+func (p *Throttle) Run() {
+	ticker := time.NewTicker(p.SummaryInterval)
+
+	for {
+		select {
+		case now := <-ticker.C:
+			p.flush(p.storage.summary(now), now)
+		case e, ok := <-p.In:
+			if !ok {
+				ticker.Stop()
+				now := time.Now()
+				p.flush(p.storage.leave(now), now)
+				return
+			}
+
+			now := time.Now()
+			key, parts, ok := p.key(e)
+			if !ok {
+				p.Log.Debug("event has no configured label or field, skipped",
+					slog.Group("event",
+						"id", e.Id,
+						"key", e.RoutingKey,
+					),
+				)
+				p.Out <- e
+				p.Observe(metrics.EventAccepted, time.Since(now))
+				continue
+			}
+
+			allowed, delay := p.storage.take(key, parts, now, p.Action == actionDelay)
+			if allowed {
+				if p.Action == actionTag {
+					e.SetLabel("::throttled", "false")
+				}
+				p.Out <- e
+				p.Observe(metrics.EventAccepted, time.Since(now))
+				continue
+			}
+
+			p.Log.Debug("event throttled",
+				slog.Group("event",
+					"id", e.Id,
+					"key", e.RoutingKey,
+				),
+			)
+
+			switch p.Action {
+			case actionDrop:
+				p.Drop <- e
+				p.Observe(metrics.EventAccepted, time.Since(now))
+			case actionTag:
+				e.SetLabel("::throttled", "true")
+				p.Out <- e
+				p.Observe(metrics.EventAccepted, time.Since(now))
+			case actionDelay:
+				time.Sleep(delay)
+				p.Out <- e
+				p.Observe(metrics.EventAccepted, time.Since(now))
+			}
+		}
+	}
+}
+
+func (p *Throttle) flush(summary []bucket, now time.Time) {
+	if !p.Summary {
+		return
+	}
+
+	for _, b := range summary {
+		e := core.NewEvent(p.RoutingKey)
+		e.Timestamp = now
+
+		e.SetLabel("::type", "throttle")
+		for k, v := range b.parts.labels {
+			e.SetLabel(k, v)
+		}
+
+		for k, v := range b.parts.fields {
+			e.SetField(k, v)
+		}
+
+		// human-readable key, labels and fields are also in the event
+		e.SetField("throttle.key", strings.ReplaceAll(b.key, keySeparator, ":"))
+		e.SetField("throttle.passed", b.passed)
+		e.SetField("throttle.throttled", b.throttled)
+		e.SetField("throttle.action", p.Action)
+
+		p.Out <- e
+	}
+}
+
+// key returns token bucket key for an event and key values, that are copied to summary events
+// values are joined with zero byte, so "a:b"+"c" and "a"+"b:c" go to different buckets
+// events without any of key labels or fields are not throttled
+func (p *Throttle) key(e *core.Event) (string, *keyParts, bool) {
+	parts := &keyParts{
+		labels: make(map[string]string, len(p.KeyLabels)),
+		fields: make(map[string]any, len(p.KeyFields)),
+	}
+	values := make([]string, 0, len(p.KeyLabels)+len(p.KeyFields))
+
+	for _, k := range p.KeyLabels {
+		v, ok := e.GetLabel(k)
+		if !ok {
+			return "", nil, false
+		}
+		parts.labels[k] = v
+		values = append(values, v)
+	}
+
+	for _, k := range p.KeyFields {
+		v, err := e.GetField(k)
+		if err != nil {
+			return "", nil, false
+		}
+
+		switch f := v.(type) {
+		case string:
+			values = append(values, f)
+		case map[string]any, []any, nil:
+			return "", nil, false
+		default:
+			values = append(values, fmt.Sprint(f))
+		}
+		parts.fields[k] = v
+	}
+
+	return strings.Join(values, keySeparator), parts, true
+}
+
+func init() {
+	plugins.AddProcessor("throttle", func() core.Processor {
+		return &Throttle{
+			Mode:            "shared",
+			Period:          time.Second,
+			Action:          actionDrop,
+			Summary:         true,
+			SummaryInterval: time.Minute,
+			RoutingKey:      "neptunus.generated.throttle",
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package throttle_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/pkg/mapstructure"
+	"github.com/gekatateam/neptunus/plugins/processors/throttle"
+)
+
+func TestThrottle(t *testing.T) {
+	tests := map[string]struct {
+		config          map[string]any
+		events          []*core.Event
+		expectOut       int
+		expectDrop      int
+		expectThrottled int
+		expectSummary   map[string]int // key -> throttled
+	}{
+		"drop-by-label": {
+			config: map[string]any{
+				"mode":       "individual",
+				"key_labels": []string{"source"},
+				"limit":      2,
+				"period":     "1h",
+				"action":     "drop",
+				"summary":    true,
+			},
+			events: []*core.Event{
+				{Labels: map[string]string{"source": "a"}},
+				{Labels: map[string]string{"source": "a"}},
+				{Labels: map[string]string{"source": "a"}},
+				{Labels: map[string]string{"source": "b"}},
+				{Labels: map[string]string{"source": "a"}},
+				{Labels: map[string]string{"no-source": "a"}},
+			},
+			expectOut:     4 + 1, // passed, skipped and summary
+			expectDrop:    2,
+			expectSummary: map[string]int{"a": 2},
+		},
+		"composite-key-parts-not-merged": {
+			config: map[string]any{
+				"mode":       "individual",
+				"key_labels": []string{"a", "b"},
+				"limit":      1,
+				"period":     "1h",
+				"action":     "drop",
+				"summary":    true,
+			},
+			events: []*core.Event{
+				{Labels: map[string]string{"a": "x:y", "b": "z"}},
+				{Labels: map[string]string{"a": "x", "b": "y:z"}},
+				{Labels: map[string]string{"a": "x", "b": "y:z"}},
+			},
+			expectOut:     2 + 1, // passed and summary
+			expectDrop:    1,
+			expectSummary: map[string]int{"x:y:z": 1},
+		},
+		"tag-by-field-with-burst": {
+			config: map[string]any{
+				"mode":       "shared",
+				"key_fields": []string{"alert"},
+				"limit":      1,
+				"burst":      2,
+				"period":     "1h",
+				"action":     "tag",
+				"summary":    false,
+			},
+			events: []*core.Event{
+				{Labels: map[string]string{}, Data: map[string]any{"alert": "disk"}},
+				{Labels: map[string]string{}, Data: map[string]any{"alert": "disk"}},
+				{Labels: map[string]string{}, Data: map[string]any{"alert": "disk"}},
+				{Labels: map[string]string{}, Data: map[string]any{"alert": "cpu"}},
+			},
+			expectOut:       4,
+			expectThrottled: 1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			processor := &throttle.Throttle{
+				BaseProcessor: &core.BaseProcessor{
+					Log: logger.Mock(),
+					Obs: metrics.ObserveMock,
+				},
+				SummaryInterval: time.Hour,
+				RoutingKey:      "summary",
+			}
+			if err := mapstructure.Decode(test.config, processor); err != nil {
+				t.Fatalf("processor config not applied: %v", err)
+			}
+			if err := processor.Init(); err != nil {
+				t.Fatalf("processor not initialized: %v", err)
+			}
+
+			input := make(chan *core.Event, 100)
+			output := make(chan *core.Event, 100)
+			drop := make(chan *core.Event, 100)
+			processor.SetChannels(input, output, drop)
+
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			go func() {
+				processor.Run()
+				wg.Done()
+			}()
+
+			for _, e := range test.events {
+				input <- e
+			}
+			close(input)
+			wg.Wait()
+			processor.Close()
+
+			if len(output) != test.expectOut {
+				t.Fatalf("unexpected output events count - want: %v, got: %v", test.expectOut, len(output))
+			}
+
+			if len(drop) != test.expectDrop {
+				t.Fatalf("unexpected dropped events count - want: %v, got: %v", test.expectDrop, len(drop))
+			}
+
+			throttled := 0
+			summary := make(map[string]int)
+			close(output)
+			for e := range output {
+				if e.RoutingKey == "summary" {
+					key, _ := e.GetField("throttle.key")
+					count, _ := e.GetField("throttle.throttled")
+					summary[key.(string)] = count.(int)
+					continue
+				}
+
+				if l, _ := e.GetLabel("::throttled"); l == "true" {
+					throttled++
+				}
+			}
+
+			if throttled != test.expectThrottled {
+				t.Fatalf("unexpected throttled events count - want: %v, got: %v", test.expectThrottled, throttled)
+			}
+
+			if len(summary) != len(test.expectSummary) {
+				t.Fatalf("unexpected summary - want: %v, got: %v", test.expectSummary, summary)
+			}
+
+			for k, v := range test.expectSummary {
+				if summary[k] != v {
+					t.Fatalf("unexpected summary - want: %v, got: %v", test.expectSummary, summary)
+				}
+			}
+		})
+	}
+}
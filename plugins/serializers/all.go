@@ -1,6 +1,7 @@
 package serializers
 
 import (
+	_ "github.com/gekatateam/neptunus/plugins/serializers/csv"
 	_ "github.com/gekatateam/neptunus/plugins/serializers/json"
 	_ "github.com/gekatateam/neptunus/plugins/serializers/template_text"
 )
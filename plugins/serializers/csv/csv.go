@@ -0,0 +1,229 @@
+package csv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/plugins"
+)
+
+type Csv struct {
+	*core.BaseSerializer `mapstructure:"-"`
+	Delimiter            string   `mapstructure:"delimiter"`
+	Header               string   `mapstructure:"header"` // once, each, none
+	QuoteAll             bool     `mapstructure:"quote_all"`
+	UseCrlf              bool     `mapstructure:"use_crlf"`
+	Columns              []Column `mapstructure:"columns"`
+
+	delimiter     string
+	newline       string
+	header        []byte
+	headerWritten *atomic.Bool
+}
+
+const (
+	headerOnce = "once"
+	headerEach = "each"
+	headerNone = "none"
+)
+
+type Column struct {
+	Name  string `mapstructure:"name"`
+	Field string `mapstructure:"field"`
+}
+
+func (s *Csv) Init() error {
+	if len([]rune(s.Delimiter)) != 1 || s.Delimiter == `"` || s.Delimiter == "\r" || s.Delimiter == "\n" {
+		return errors.New("delimiter must be a single character, not a quote or a line break")
+	}
+	s.delimiter = s.Delimiter
+
+	s.newline = "\n"
+	if s.UseCrlf {
+		s.newline = "\r\n"
+	}
+
+	if len(s.Columns) == 0 {
+		return errors.New("at least one column required")
+	}
+
+	for i, c := range s.Columns {
+		if len(c.Field) == 0 {
+			return fmt.Errorf("column %v: field required", i)
+		}
+
+		if len(c.Name) == 0 {
+			s.Columns[i].Name = c.Field
+		}
+	}
+
+	switch s.Header {
+	case headerOnce, headerEach, headerNone:
+	default:
+		return fmt.Errorf("unknown header mode: %v, expected one of: once, each, none", s.Header)
+	}
+	s.headerWritten = &atomic.Bool{}
+
+	if s.Header != headerNone {
+		names := make([]string, 0, len(s.Columns))
+		for _, c := range s.Columns {
+			names = append(names, c.Name)
+		}
+
+		buf := &bytes.Buffer{}
+		s.writeRecord(buf, names)
+		s.header = buf.Bytes()
+	}
+
+	return nil
+}
+
+func (s *Csv) Close() error {
+	return nil
+}
+
+// Serialize writes one row per event, header is written before rows
+// on each call or only on the first call with at least one row, depending on mode
+// rows are separated by newline, there is no newline after the last row
+// events with unserializable fields are skipped
+func (s *Csv) Serialize(events ...*core.Event) ([]byte, error) {
+	now := time.Now()
+	buf := bytes.NewBuffer(make([]byte, 0, 4096))
+
+	record := make([]string, len(s.Columns))
+	rows := 0
+	for _, e := range events {
+		if err := s.fillRecord(e, record); err != nil {
+			s.Log.Error("serialization failed",
+				"error", err,
+				slog.Group("event",
+					"id", e.Id,
+					"key", e.RoutingKey,
+				),
+			)
+			s.Observe(metrics.EventFailed, time.Since(now))
+			now = time.Now()
+			continue
+		}
+
+		if buf.Len() > 0 {
+			buf.WriteString(s.newline)
+		}
+		s.writeRecord(buf, record)
+		rows++
+
+		s.Observe(metrics.EventAccepted, time.Since(now))
+		now = time.Now()
+	}
+
+	if rows == 0 && len(events) > 0 {
+		return nil, errors.New("all events serialization failed")
+	}
+
+	if rows > 0 && s.writeHeader() {
+		return append(append(append(make([]byte, 0, len(s.header)+len(s.newline)+buf.Len()),
+			s.header...), s.newline...), buf.Bytes()...), nil
+	}
+
+	return buf.Bytes(), nil
+}
+
+// in once mode, header is written only by first caller,
+// because serializer may be used by concurrent goroutines of one output
+func (s *Csv) writeHeader() bool {
+	switch s.Header {
+	case headerEach:
+		return true
+	case headerOnce:
+		return s.headerWritten.CompareAndSwap(false, true)
+	default:
+		return false
+	}
+}
+
+// missing fields are written as empty values
+// maps and slices are written as json
+func (s *Csv) fillRecord(e *core.Event, record []string) error {
+	for i, c := range s.Columns {
+		field, err := e.GetField(c.Field)
+		if err != nil {
+			record[i] = ""
+			continue
+		}
+
+		switch v := field.(type) {
+		case nil:
+			record[i] = ""
+		case string:
+			record[i] = v
+		case []byte:
+			record[i] = string(v)
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case float32:
+			record[i] = strconv.FormatFloat(float64(v), 'f', -1, 32)
+		case time.Time:
+			record[i] = v.Format(time.RFC3339Nano)
+		case map[string]any, []any:
+			raw, err := json.MarshalNoEscape(v)
+			if err != nil {
+				return fmt.Errorf("column %v: %w", c.Name, err)
+			}
+			record[i] = string(raw)
+		default:
+			record[i] = fmt.Sprint(v)
+		}
+	}
+
+	return nil
+}
+
+func (s *Csv) writeRecord(buf *bytes.Buffer, record []string) {
+	for i, v := range record {
+		if i > 0 {
+			buf.WriteString(s.delimiter)
+		}
+
+		if !s.QuoteAll && !s.needsQuotes(v) {
+			buf.WriteString(v)
+			continue
+		}
+
+		buf.WriteByte('"')
+		buf.WriteString(strings.ReplaceAll(v, `"`, `""`))
+		buf.WriteByte('"')
+	}
+}
+
+// field must be quoted if it contains delimiter, quote or line break
+// or starts with a space, like encoding/csv does
+func (s *Csv) needsQuotes(v string) bool {
+	if len(v) == 0 {
+		return false
+	}
+
+	if v == `\.` || v[0] == ' ' || v[0] == '\t' {
+		return true
+	}
+
+	return strings.Contains(v, s.delimiter) || strings.ContainsAny(v, "\"\r\n")
+}
+
+func init() {
+	plugins.AddSerializer("csv", func() core.Serializer {
+		return &Csv{
+			Delimiter: ",",
+			Header:    headerOnce,
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package csv_test
+
+import (
+	"testing"
+
+	"github.com/gekatateam/neptunus/core"
+	"github.com/gekatateam/neptunus/logger"
+	"github.com/gekatateam/neptunus/metrics"
+	"github.com/gekatateam/neptunus/pkg/mapstructure"
+	"github.com/gekatateam/neptunus/plugins/serializers/csv"
+)
+
+func TestCsv(t *testing.T) {
+	events := []*core.Event{
+		{Data: map[string]any{"id": 1, "user": map[string]any{"name": "John"}, "note": "say \"hi\", please"}},
+		{Data: map[string]any{"id": 2.5, "tags": []any{"a", "b"}}},
+	}
+
+	tests := map[string]struct {
+		config map[string]any
+		expect string
+	}{
+		"header-and-minimal-quoting": {
+			config: map[string]any{
+				"columns": []map[string]any{
+					{"name": "id", "field": "id"},
+					{"name": "user", "field": "user.name"},
+					{"field": "note"},
+					{"name": "tags", "field": "tags"},
+				},
+			},
+			expect: "id,user,note,tags\n" +
+				"1,John,\"say \"\"hi\"\", please\",\n" +
+				"2.5,,,\"[\"\"a\"\",\"\"b\"\"]\"",
+		},
+		"no-header-quote-all-crlf": {
+			config: map[string]any{
+				"header":    "none",
+				"quote_all": true,
+				"use_crlf":  true,
+				"delimiter": ";",
+				"columns": []map[string]any{
+					{"field": "id"},
+					{"field": "user.name"},
+				},
+			},
+			expect: "\"1\";\"John\"\r\n" +
+				"\"2.5\";\"\"",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			serializer := &csv.Csv{
+				BaseSerializer: &core.BaseSerializer{
+					Log: logger.Mock(),
+					Obs: metrics.ObserveMock,
+				},
+				Delimiter: ",",
+				Header:    "once",
+			}
+			if err := mapstructure.Decode(test.config, serializer); err != nil {
+				t.Fatalf("serializer config not applied: %v", err)
+			}
+			if err := serializer.Init(); err != nil {
+				t.Fatalf("serializer not initialized: %v", err)
+			}
+
+			data, err := serializer.Serialize(events...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(data) != test.expect {
+				t.Fatalf("unexpected result - want: %q, got: %q", test.expect, string(data))
+			}
+		})
+	}
+}
+
+func TestCsv_HeaderMode(t *testing.T) {
+	tests := map[string]struct {
+		header string
+		calls  [][]*core.Event
+		expect []string
+	}{
+		"once-per-stream": {
+			header: "once",
+			calls: [][]*core.Event{
+				{{Data: map[string]any{"id": 1}}},
+				{{Data: map[string]any{"id": 2}}},
+				{{Data: map[string]any{"id": 3}}, {Data: map[string]any{"id": 4}}},
+			},
+			expect: []string{"id\n1", "2", "3\n4"},
+		},
+		"once-after-empty-call": {
+			header: "once",
+			calls: [][]*core.Event{
+				{},
+				{{Data: map[string]any{"id": 1}}},
+				{{Data: map[string]any{"id": 2}}},
+			},
+			expect: []string{"", "id\n1", "2"},
+		},
+		"each-call": {
+			header: "each",
+			calls: [][]*core.Event{
+				{{Data: map[string]any{"id": 1}}},
+				{{Data: map[string]any{"id": 2}}, {Data: map[string]any{"id": 3}}},
+			},
+			expect: []string{"id\n1", "id\n2\n3"},
+		},
+		"none": {
+			header: "none",
+			calls: [][]*core.Event{
+				{{Data: map[string]any{"id": 1}}},
+				{{Data: map[string]any{"id": 2}}},
+			},
+			expect: []string{"1", "2"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			serializer := &csv.Csv{
+				BaseSerializer: &core.BaseSerializer{
+					Log: logger.Mock(),
+					Obs: metrics.ObserveMock,
+				},
+				Delimiter: ",",
+				Header:    test.header,
+				Columns:   []csv.Column{{Field: "id"}},
+			}
+			if err := serializer.Init(); err != nil {
+				t.Fatalf("serializer not initialized: %v", err)
+			}
+
+			for i, events := range test.calls {
+				data, err := serializer.Serialize(events...)
+				if err != nil {
+					t.Fatalf("call %v: unexpected error: %v", i, err)
+				}
+
+				if string(data) != test.expect[i] {
+					t.Fatalf("call %v: unexpected result - want: %q, got: %q", i, test.expect[i], string(data))
+				}
+			}
+		})
+	}
+}